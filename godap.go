@@ -76,7 +76,7 @@ func main() {
 	rootCmd := &cobra.Command{
 		Use:   "godap <server address>",
 		Short: "A complete TUI for LDAP.",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.RangeArgs(0, 1),
 		Run: func(cmd *cobra.Command, args []string) {
 			err := validateFlagSet(cmd)
 
@@ -84,10 +84,24 @@ func main() {
 				log.Fatalf(fmt.Sprint(err))
 			}
 
-			tui.LdapServer = args[0]
+			if len(args) > 0 {
+				tui.LdapServer = args[0]
+			} else if !tui.DiscoverDCs {
+				log.Fatalf("A server address is required (or use -d <domain> --discover)")
+			}
+
+			if tui.DiscoverDCs && tui.DomainName == "" {
+				log.Fatalf("A domain (-d) is required for DC discovery")
+			}
 
 			if tui.LdapPort == 0 {
-				if tui.Ldaps {
+				if tui.GlobalCatalog {
+					if tui.Ldaps {
+						tui.LdapPort = 3269
+					} else {
+						tui.LdapPort = 3268
+					}
+				} else if tui.Ldaps {
 					tui.LdapPort = 636
 				} else {
 					tui.LdapPort = 389
@@ -109,6 +123,8 @@ func main() {
 	rootCmd.Flags().StringVarP(&tui.NtlmHashFile, "hashfile", "", "", "Path to a file containing the NTLM hash (or - for stdin)")
 	rootCmd.Flags().StringVarP(&tui.RootDN, "rootDN", "r", "", "Initial root DN")
 	rootCmd.Flags().StringVarP(&tui.SearchFilter, "filter", "f", "(objectClass=*)", "Initial LDAP search filter")
+	rootCmd.Flags().StringSliceVarP(&tui.SearchAttributes, "attrs", "", nil, "Comma-separated attributes to request in the search page (all user attributes by default)")
+	rootCmd.Flags().StringSliceVarP(&tui.LibraryPaths, "library", "", nil, "Comma-separated YAML/JSON query library files or directories to load into the search library")
 	rootCmd.Flags().BoolVarP(&tui.Emojis, "emojis", "E", true, "Prefix objects with emojis")
 	rootCmd.Flags().BoolVarP(&tui.Colors, "colors", "C", true, "Colorize objects")
 	rootCmd.Flags().BoolVarP(&tui.FormatAttrs, "format", "F", true, "Format attributes into human-readable values")
@@ -117,11 +133,12 @@ func main() {
 	rootCmd.Flags().BoolVarP(&tui.CacheEntries, "cache", "M", true, "Keep loaded entries in memory while the program is open and don't query them again")
 	rootCmd.Flags().BoolVarP(&tui.Deleted, "deleted", "D", false, "Include deleted objects in all queries performed")
 	rootCmd.Flags().Int32VarP(&tui.Timeout, "timeout", "T", 10, "Timeout for LDAP connections in seconds")
-	rootCmd.Flags().BoolVarP(&tui.LoadSchema, "schema", "s", false, "Load schema GUIDs from the LDAP server during initialization")
+	rootCmd.Flags().BoolVarP(&tui.LoadSchema, "schema", "s", false, "Load schema GUIDs and attribute syntaxes from the LDAP server during initialization")
 	rootCmd.Flags().Uint32VarP(&tui.PagingSize, "paging", "G", 800, "Default paging size for regular queries")
+	rootCmd.Flags().IntVar(&tui.ChildrenPageSize, "children-page", 200, "Number of children loaded at a time when expanding objects in the explorer (0 to load all)")
 	rootCmd.Flags().BoolVarP(&tui.Insecure, "insecure", "I", false, "Skip TLS verification for LDAPS/StartTLS")
 	rootCmd.Flags().BoolVarP(&tui.Ldaps, "ldaps", "S", false, "Use LDAPS for initial connection")
-	rootCmd.Flags().StringVarP(&tui.SocksServer, "socks", "x", "", "Use a SOCKS proxy for initial connection")
+	rootCmd.Flags().StringVarP(&tui.SocksServer, "socks", "x", "", "Proxy chain to use for the LDAP and KDC connections (comma-separated socks4/socks4a/socks5/http/ssh URIs)")
 	rootCmd.Flags().StringVarP(&tui.KdcHost, "kdc", "", "", "Address of the KDC to use with Kerberos authentication (optional: only if the KDC differs from the specified LDAP server)")
 	rootCmd.Flags().StringVarP(&tui.TimeFormat, "timefmt", "", "", "Time format for LDAP timestamps")
 	rootCmd.Flags().StringVarP(&tui.CertFile, "crt", "", "", "Path to a file containing the certificate to use for the bind")
@@ -130,6 +147,11 @@ func main() {
 	rootCmd.Flags().StringVarP(&tui.AttrSort, "attrsort", "", "none", "Sort attributes by name (none, asc, desc)")
 	rootCmd.Flags().IntVarP(&tui.TimeOffset, "offset", "", 0, "Offset in hours to apply to formatted timestamps")
 	rootCmd.Flags().StringVarP(&tui.ExportDir, "exportdir", "", "data", "Custom directory to save godap exports taken with Ctrl+S")
+	rootCmd.Flags().IntVarP(&tui.KeepaliveInterval, "keepalive", "", 60, "Interval in seconds between keepalive reads that detect and restore dropped connections (0 to disable)")
+	rootCmd.Flags().BoolVarP(&tui.GlobalCatalog, "gc", "", false, "Connect to the Global Catalog (3268/3269) and browse every domain partition of the forest")
+	rootCmd.Flags().BoolVarP(&tui.DiscoverDCs, "discover", "", false, "Discover the domain controllers of the domain (-d) via DNS SRV records and connect to the best one")
+	rootCmd.Flags().StringVarP(&tui.DnsServer, "dns", "", "", "DNS server to use for DC discovery (queries go over TCP when a proxy is set)")
+	rootCmd.Flags().StringVarP(&tui.DcSite, "site", "", "", "AD site to prefer during DC discovery")
 	rootCmd.Flags().StringVarP(&tui.BackendFlavor, "backend", "b", "msad", "LDAP backend flavor (msad, basic or auto)")
 
 	versionCmd := &cobra.Command{
@@ -0,0 +1,294 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type DialFunc func(network, address string) (net.Conn, error)
+
+// Kinds of SRV records that can point to a domain controller
+const (
+	SourceSite = "site"
+	SourceDC   = "dc"
+	SourceGC   = "gc"
+)
+
+type DC struct {
+	Host     string
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+	Source   string
+
+	Reachable bool
+	Latency   time.Duration
+}
+
+func (dc DC) Address() string {
+	return net.JoinHostPort(dc.Host, strconv.Itoa(int(dc.Port)))
+}
+
+func (dc DC) String() string {
+	status := "unreachable"
+	if dc.Reachable {
+		status = fmt.Sprintf("%dms", dc.Latency.Milliseconds())
+	}
+
+	return fmt.Sprintf("%s [%s] (prio=%d weight=%d %s)", dc.Address(), dc.Source, dc.Priority, dc.Weight, status)
+}
+
+// Resolver performs the SRV lookups used to find domain controllers.
+// If Server is empty the system resolver configuration is used.
+// If Dial is set, DNS queries are sent over TCP through it
+// (e.g. through a SOCKS proxy) instead of being sent directly.
+type Resolver struct {
+	Server  string
+	Dial    DialFunc
+	Timeout time.Duration
+}
+
+func NewResolver(server string, dial DialFunc, timeout time.Duration) *Resolver {
+	if server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+	}
+
+	return &Resolver{Server: server, Dial: dial, Timeout: timeout}
+}
+
+func (r *Resolver) netResolver() *net.Resolver {
+	if r.Server == "" && r.Dial == nil {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if r.Server != "" {
+				address = r.Server
+			}
+
+			// The Go resolver switches to TCP framing
+			// when the returned conn is not a PacketConn
+			if r.Dial != nil {
+				return dialTimeout(r.Dial, "tcp", address, r.Timeout)
+			}
+
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
+
+func (r *Resolver) lookup(ctx context.Context, name string, source string) ([]DC, error) {
+	_, records, err := r.netResolver().LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	dcs := make([]DC, 0, len(records))
+	for _, record := range records {
+		dcs = append(dcs, DC{
+			Host:     strings.TrimSuffix(record.Target, "."),
+			Port:     record.Port,
+			Priority: record.Priority,
+			Weight:   record.Weight,
+			Source:   source,
+		})
+	}
+
+	return dcs, nil
+}
+
+// LookupDCs queries the SRV records that advertise the DCs and GCs
+// of a domain. When a site is specified, the DCs registered
+// for that site are also included and preferred over the others.
+func (r *Resolver) LookupDCs(domain string, site string) ([]DC, error) {
+	domain = strings.TrimSuffix(domain, ".")
+	if domain == "" {
+		return nil, errors.New("A domain is required for DC discovery")
+	}
+
+	ctx := context.Background()
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	queries := []struct {
+		name   string
+		source string
+	}{
+		{"_ldap._tcp.dc._msdcs." + domain, SourceDC},
+		{"_gc._tcp." + domain, SourceGC},
+	}
+
+	if site != "" {
+		queries = append([]struct {
+			name   string
+			source string
+		}{{"_ldap._tcp." + site + "._sites.dc._msdcs." + domain, SourceSite}}, queries...)
+	}
+
+	var dcs []DC
+	var errs []error
+
+	seen := make(map[string]bool)
+	for _, query := range queries {
+		found, err := r.lookup(ctx, query.name, query.source)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", query.name, err))
+			continue
+		}
+
+		for _, dc := range found {
+			key := strings.ToLower(dc.Address())
+			if !seen[key] {
+				seen[key] = true
+				dcs = append(dcs, dc)
+			}
+		}
+	}
+
+	if len(dcs) == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("No domain controllers found for '%s'", domain)
+	}
+
+	return dcs, nil
+}
+
+// Runs dial with a timeout, since dialers such
+// as proxy chains may not enforce one themselves
+func dialTimeout(dial DialFunc, network string, address string, timeout time.Duration) (net.Conn, error) {
+	if timeout <= 0 {
+		return dial(network, address)
+	}
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+
+	done := make(chan dialResult, 1)
+	go func() {
+		conn, err := dial(network, address)
+		done <- dialResult{conn, err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.conn, result.err
+	case <-time.After(timeout):
+		// Connections established too late are discarded
+		go func() {
+			if result := <-done; result.conn != nil {
+				result.conn.Close()
+			}
+		}()
+
+		return nil, fmt.Errorf("%s: connection timed out", address)
+	}
+}
+
+// Probe checks in parallel whether each DC accepts TCP connections on
+// the given port (or on the port of its SRV record if it's 0) and
+// measures how long the connection took
+func Probe(dcs []DC, dial DialFunc, timeout time.Duration, port int) {
+	if dial == nil {
+		dial = func(network, address string) (net.Conn, error) {
+			return net.DialTimeout(network, address, timeout)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for idx := range dcs {
+		wg.Add(1)
+		go func(dc *DC) {
+			defer wg.Done()
+
+			address := dc.Address()
+			if port != 0 {
+				address = net.JoinHostPort(dc.Host, strconv.Itoa(port))
+			}
+
+			startTime := time.Now()
+			conn, err := dialTimeout(dial, "tcp", address, timeout)
+			if err != nil {
+				return
+			}
+			conn.Close()
+
+			dc.Reachable = true
+			dc.Latency = time.Since(startTime)
+		}(&dcs[idx])
+	}
+
+	wg.Wait()
+}
+
+var sourceRank = map[string]int{
+	SourceSite: 0,
+	SourceDC:   1,
+	SourceGC:   2,
+}
+
+// Rank orders DCs by reachability, SRV source (site-specific first),
+// priority (lower first), weight (higher first) and latency
+func Rank(dcs []DC) {
+	sort.SliceStable(dcs, func(i, j int) bool {
+		a, b := dcs[i], dcs[j]
+
+		if a.Reachable != b.Reachable {
+			return a.Reachable
+		}
+		if sourceRank[a.Source] != sourceRank[b.Source] {
+			return sourceRank[a.Source] < sourceRank[b.Source]
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+
+		return a.Latency < b.Latency
+	})
+}
+
+// Best returns the first ranked DC from the desired kind of record
+func Best(dcs []DC, gc bool) (DC, bool) {
+	for _, dc := range dcs {
+		if (dc.Source == SourceGC) == gc {
+			return dc, true
+		}
+	}
+
+	return DC{}, false
+}
+
+// Discover looks up the DCs of a domain, probes them
+// on the given port (see Probe) and ranks them
+func Discover(r *Resolver, domain string, site string, port int) ([]DC, error) {
+	dcs, err := r.LookupDCs(domain, site)
+	if err != nil {
+		return nil, err
+	}
+
+	Probe(dcs, r.Dial, r.Timeout, port)
+	Rank(dcs)
+
+	return dcs, nil
+}
@@ -0,0 +1,269 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type srvAnswer struct {
+	target   string
+	port     uint16
+	priority uint16
+	weight   uint16
+}
+
+var stubRecords = map[string][]srvAnswer{
+	"_ldap._tcp.dc._msdcs.corp.local.": {
+		{"dc2.corp.local.", 389, 10, 100},
+		{"dc1.corp.local.", 389, 0, 50},
+		{"dc3.corp.local.", 389, 0, 100},
+	},
+	"_gc._tcp.corp.local.": {
+		{"dc1.corp.local.", 3268, 0, 100},
+	},
+	"_ldap._tcp.branch._sites.dc._msdcs.corp.local.": {
+		{"dc2.corp.local.", 389, 10, 100},
+	},
+}
+
+func stubResponse(t *testing.T, query []byte) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		t.Fatalf("parsing query: %v", err)
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		t.Fatalf("parsing question: %v", err)
+	}
+
+	header.Response = true
+	header.Authoritative = true
+
+	answers, ok := stubRecords[strings.ToLower(question.Name.String())]
+	if !ok || question.Type != dnsmessage.TypeSRV {
+		header.RCode = dnsmessage.RCodeNameError
+	}
+
+	builder := dnsmessage.NewBuilder(nil, header)
+	builder.EnableCompression()
+	builder.StartQuestions()
+	builder.Question(question)
+	builder.StartAnswers()
+
+	for _, answer := range answers {
+		if question.Type != dnsmessage.TypeSRV {
+			break
+		}
+
+		builder.SRVResource(dnsmessage.ResourceHeader{
+			Name:  question.Name,
+			Type:  dnsmessage.TypeSRV,
+			Class: dnsmessage.ClassINET,
+			TTL:   60,
+		}, dnsmessage.SRVResource{
+			Priority: answer.priority,
+			Weight:   answer.weight,
+			Port:     answer.port,
+			Target:   dnsmessage.MustNewName(answer.target),
+		})
+	}
+
+	msg, err := builder.Finish()
+	if err != nil {
+		t.Fatalf("building response: %v", err)
+	}
+
+	return msg
+}
+
+func startUDPStub(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(stubResponse(t, buf[:n]), addr)
+		}
+	}()
+
+	return pc.LocalAddr().String()
+}
+
+func startTCPStub(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					var length uint16
+					if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+						return
+					}
+
+					query := make([]byte, length)
+					if _, err := io.ReadFull(conn, query); err != nil {
+						return
+					}
+
+					response := stubResponse(t, query)
+					binary.Write(conn, binary.BigEndian, uint16(len(response)))
+					conn.Write(response)
+				}
+			}(conn)
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestLookupDCsUDP(t *testing.T) {
+	r := NewResolver(startUDPStub(t), nil, 5*time.Second)
+
+	dcs, err := r.LookupDCs("corp.local", "branch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// dc2:389 is both a site and a domain-wide record and must be deduplicated
+	if len(dcs) != 4 {
+		t.Fatalf("got %d DCs, want 4: %v", len(dcs), dcs)
+	}
+
+	if dcs[0].Host != "dc2.corp.local" || dcs[0].Source != SourceSite {
+		t.Errorf("expected the site DC first, got %v", dcs[0])
+	}
+}
+
+func TestLookupDCsThroughDialer(t *testing.T) {
+	stubAddr := startTCPStub(t)
+
+	dialed := 0
+	dial := func(network, address string) (net.Conn, error) {
+		dialed++
+		if network != "tcp" {
+			t.Errorf("got network %q, want tcp", network)
+		}
+		return net.Dial(network, address)
+	}
+
+	r := NewResolver(stubAddr, dial, 5*time.Second)
+
+	dcs, err := r.LookupDCs("corp.local", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dialed == 0 {
+		t.Error("DNS queries were not sent through the dialer")
+	}
+
+	if len(dcs) != 4 {
+		t.Fatalf("got %d DCs, want 4: %v", len(dcs), dcs)
+	}
+}
+
+func TestLookupDCsNotFound(t *testing.T) {
+	r := NewResolver(startUDPStub(t), nil, 5*time.Second)
+
+	if _, err := r.LookupDCs("other.local", ""); err == nil {
+		t.Error("expected an error for a domain without SRV records")
+	}
+}
+
+func TestRank(t *testing.T) {
+	dcs := []DC{
+		{Host: "gc", Source: SourceGC, Reachable: true},
+		{Host: "down", Source: SourceSite},
+		{Host: "prio10", Source: SourceDC, Priority: 10, Reachable: true},
+		{Host: "light", Source: SourceDC, Weight: 10, Reachable: true},
+		{Host: "slow", Source: SourceDC, Weight: 100, Reachable: true, Latency: 50 * time.Millisecond},
+		{Host: "fast", Source: SourceDC, Weight: 100, Reachable: true, Latency: time.Millisecond},
+		{Host: "site", Source: SourceSite, Priority: 20, Reachable: true},
+	}
+
+	Rank(dcs)
+
+	expected := []string{"site", "fast", "slow", "light", "prio10", "gc", "down"}
+	for idx, host := range expected {
+		if dcs[idx].Host != host {
+			t.Errorf("position %d: got %q, want %q", idx, dcs[idx].Host, host)
+		}
+	}
+
+	best, ok := Best(dcs, true)
+	if !ok || best.Host != "gc" {
+		t.Errorf("got best GC %v, want gc", best)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	dcs := []DC{
+		{Host: "up", Port: 389},
+		{Host: "hanging", Port: 389},
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var dialed []string
+	var dialedLock sync.Mutex
+
+	// The dialer of a stalled proxy that never gives up
+	dial := func(network, address string) (net.Conn, error) {
+		dialedLock.Lock()
+		dialed = append(dialed, address)
+		dialedLock.Unlock()
+
+		if strings.HasPrefix(address, "hanging") {
+			<-release
+			return nil, io.EOF
+		}
+
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	Probe(dcs, dial, 100*time.Millisecond, 636)
+
+	if !dcs[0].Reachable || dcs[1].Reachable {
+		t.Errorf("unexpected reachability: %+v", dcs)
+	}
+
+	dialedLock.Lock()
+	defer dialedLock.Unlock()
+
+	for _, address := range dialed {
+		if !strings.HasSuffix(address, ":636") {
+			t.Errorf("probed %s instead of port 636", address)
+		}
+	}
+}
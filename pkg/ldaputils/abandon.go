@@ -0,0 +1,194 @@
+package ldaputils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// OID of the StartTLS extended operation
+const startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// messageTracker wraps the network connection of an LDAPConn to learn the
+// message IDs that the library assigns to searches, which it doesn't expose,
+// so that searches cancelled halfway can be abandoned on the server.
+// After StartTLS the writes it sees are encrypted, so it stops tracking.
+type messageTracker struct {
+	net.Conn
+
+	lock      sync.Mutex
+	pending   []*trackedSearch
+	encrypted bool
+	abandonID int64
+}
+
+// A search whose message ID is learnt once the library sends it
+type trackedSearch struct {
+	key string
+	id  int64
+}
+
+func newMessageTracker(conn net.Conn) *messageTracker {
+	// Abandon requests take their IDs from the top of the range,
+	// so that they don't collide with the ones of the library
+	return &messageTracker{Conn: conn, abandonID: math.MaxInt32}
+}
+
+// Identifies a search request by what it asks for, since
+// its message ID is what is being looked for
+func searchKey(baseDN string, scope int64, filter *ber.Packet, cookie []byte) string {
+	filterStr, err := ldap.DecompileFilter(filter)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s\x00%d\x00%s\x00%x", baseDN, scope, filterStr, cookie)
+}
+
+func requestKey(req *ldap.SearchRequest) string {
+	filter, err := ldap.CompileFilter(req.Filter)
+	if err != nil {
+		return ""
+	}
+
+	var cookie []byte
+	if paging, ok := ldap.FindControl(req.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging); ok {
+		cookie = paging.Cookie
+	}
+
+	return searchKey(req.BaseDN, int64(req.Scope), filter, cookie)
+}
+
+// Reads the key of an outgoing search request, or reports whether it's a StartTLS request
+func packetKey(packet *ber.Packet) (key string, startTLS bool) {
+	if len(packet.Children) < 2 || packet.Children[1].ClassType != ber.ClassApplication {
+		return "", false
+	}
+
+	op := packet.Children[1]
+	switch op.Tag {
+	case ldap.ApplicationExtendedRequest:
+		return "", len(op.Children) > 0 && op.Children[0].Data.String() == startTLSOID
+	case ldap.ApplicationSearchRequest:
+	default:
+		return "", false
+	}
+
+	if len(op.Children) < 7 {
+		return "", false
+	}
+
+	baseDN, _ := op.Children[0].Value.(string)
+	scope, _ := op.Children[1].Value.(int64)
+
+	var cookie []byte
+	if len(packet.Children) > 2 {
+		for _, child := range packet.Children[2].Children {
+			control, err := ldap.DecodeControl(child)
+			if paging, ok := control.(*ldap.ControlPaging); err == nil && ok {
+				cookie = paging.Cookie
+			}
+		}
+	}
+
+	return searchKey(baseDN, scope, op.Children[6], cookie), false
+}
+
+// Write records the message IDs of the searches being tracked
+// as the library sends them, one message per call
+func (t *messageTracker) Write(b []byte) (int, error) {
+	t.lock.Lock()
+	if !t.encrypted {
+		if packet, err := ber.DecodePacketErr(b); err == nil {
+			key, startTLS := packetKey(packet)
+			t.encrypted = startTLS
+
+			for _, search := range t.pending {
+				if key != "" && search.id == 0 && search.key == key {
+					search.id, _ = packet.Children[0].Value.(int64)
+					break
+				}
+			}
+		}
+	}
+	t.lock.Unlock()
+
+	return t.Conn.Write(b)
+}
+
+// Starts tracking req, which must be sent after this call
+func (t *messageTracker) track(req *ldap.SearchRequest) *trackedSearch {
+	if t == nil {
+		return nil
+	}
+
+	search := &trackedSearch{key: requestKey(req)}
+
+	t.lock.Lock()
+	t.pending = append(t.pending, search)
+	t.lock.Unlock()
+
+	return search
+}
+
+func (t *messageTracker) untrack(search *trackedSearch) {
+	if t == nil {
+		return
+	}
+
+	t.lock.Lock()
+	t.pending = slices.DeleteFunc(t.pending, func(other *trackedSearch) bool {
+		return other == search
+	})
+	t.lock.Unlock()
+}
+
+// Asks the server to stop processing a tracked search (RFC 4511 4.11).
+// Abandon requests have no response, so nothing is waited for.
+func (t *messageTracker) abandon(search *trackedSearch) error {
+	if t == nil {
+		return nil
+	}
+
+	t.lock.Lock()
+	if t.encrypted || search.id == 0 {
+		t.lock.Unlock()
+		return nil
+	}
+
+	messageID := t.abandonID
+	t.abandonID -= 1
+	t.lock.Unlock()
+
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Request")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	packet.AppendChild(ber.NewInteger(ber.ClassApplication, ber.TypePrimitive, ldap.ApplicationAbandonRequest, search.id, "Abandon Request"))
+
+	// Writes of a single message aren't interleaved
+	// with the ones of the library by the connection
+	_, err := t.Conn.Write(packet.Bytes())
+	return err
+}
+
+// TLSConnectionState returns the TLS state of the connection,
+// which may be either LDAPS or upgraded with StartTLS
+func (lc *LDAPConn) TLSConnectionState() (tls.ConnectionState, bool) {
+	conn, tracker := lc.connTracker()
+	if state, ok := conn.TLSConnectionState(); ok {
+		return state, true
+	}
+
+	if tracker != nil {
+		if tlsConn, ok := tracker.Conn.(*tls.Conn); ok {
+			return tlsConn.ConnectionState(), true
+		}
+	}
+
+	return tls.ConnectionState{}, false
+}
@@ -0,0 +1,64 @@
+package ldaputils
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestSearchPageAbandon(t *testing.T) {
+	client, server := net.Pipe()
+
+	tracker := newMessageTracker(client)
+	conn := ldap.NewConn(tracker, false)
+	conn.Start()
+	defer conn.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// The server never answers, so the search only ends when cancelled
+	abandoned := make(chan [2]int64, 1)
+	go func() {
+		defer close(abandoned)
+
+		search, err := ber.ReadPacket(server)
+		if err != nil {
+			return
+		}
+		cancel()
+
+		abandon, err := ber.ReadPacket(server)
+		if err != nil || abandon.Children[1].Tag != ldap.ApplicationAbandonRequest {
+			return
+		}
+
+		searchID := search.Children[0].Value.(int64)
+		abandonedID, _ := ber.ParseInt64(abandon.Children[1].Data.Bytes())
+		abandoned <- [2]int64{searchID, abandonedID}
+	}()
+
+	req := ldap.NewSearchRequest(
+		"DC=corp,DC=local",
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		"(objectClass=user)", nil,
+		[]ldap.Control{ldap.NewControlPaging(100)},
+	)
+
+	_, _, err := searchPage(ctx, conn, tracker, req)
+	if err != context.Canceled {
+		t.Fatalf("got error %v", err)
+	}
+
+	ids, ok := <-abandoned
+	if !ok {
+		t.Fatal("the search was not abandoned")
+	}
+
+	if ids[0] != ids[1] {
+		t.Errorf("abandoned message %d instead of %d", ids[1], ids[0])
+	}
+}
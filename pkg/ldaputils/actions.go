@@ -1,6 +1,7 @@
 package ldaputils
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/hex"
 	"fmt"
@@ -8,8 +9,10 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Macmod/godap/v2/pkg/adidns"
+	"github.com/Macmod/godap/v2/pkg/proxy"
 
 	ber "github.com/go-asn1-ber/asn1-ber"
 	"github.com/go-ldap/ldap/gssapi"
@@ -36,6 +39,32 @@ type LDAPConn struct {
 	RootDN        string
 	DefaultRootDN string
 	Flavor        LDAPFlavor
+
+	// Connections to the Global Catalog (3268/3269) see a partial
+	// replica of every domain of the forest, so domain-wide
+	// searches are performed from the empty base instead
+	GlobalCatalog bool
+
+	tlsConfig *tls.Config
+	connState
+
+	// Controls advertised by the server and schema of the attributes,
+	// once read. Jobs read them too, so they're guarded by cacheLock,
+	// which isn't held while they're being read from the server.
+	cacheLock         sync.Mutex
+	supportedControls []string
+	attributeSchemas  map[string]AttributeSchema
+	attrTypes         map[uint32]string
+	dnAttributes      []string
+}
+
+// SearchBase returns the base DN for domain-wide (or forest-wide, in GC mode) searches
+func (lc *LDAPConn) SearchBase() string {
+	if lc.GlobalCatalog {
+		return ""
+	}
+
+	return lc.DefaultRootDN
 }
 
 func (lc *LDAPConn) GuessFlavor() {
@@ -47,7 +76,7 @@ func (lc *LDAPConn) GuessFlavor() {
 		nil,
 	)
 
-	searchResult, err := lc.Conn.Search(rootDSESearch)
+	searchResult, err := lc.search(rootDSESearch)
 	if err != nil {
 		return
 	}
@@ -73,37 +102,43 @@ func (lc *LDAPConn) UpgradeToTLS(tlsConfig *tls.Config) error {
 		return err
 	}
 
+	// Upgraded connections are upgraded again when reconnecting
+	lc.startTLS = true
+	lc.tlsConfig = tlsConfig
+
 	return nil
 }
 
 func NewLDAPConn(ldapServer string, ldapPort int, ldaps bool, tlsConfig *tls.Config, pagingSize uint32, rootDN string, proxyConn net.Conn) (*LDAPConn, error) {
-	var conn *ldap.Conn
+	var netConn net.Conn = proxyConn
 	var err error = nil
 
 	if proxyConn == nil {
+		address := net.JoinHostPort(ldapServer, fmt.Sprint(ldapPort))
 		if ldaps {
-			conn, err = ldap.DialTLS("tcp", fmt.Sprintf("%s:%d", ldapServer, ldapPort), tlsConfig)
+			netConn, err = tls.DialWithDialer(&net.Dialer{Timeout: ldap.DefaultTimeout}, "tcp", address, tlsConfig)
 		} else {
-			conn, err = ldap.Dial("tcp", fmt.Sprintf("%s:%d", ldapServer, ldapPort))
+			netConn, err = net.DialTimeout("tcp", address, ldap.DefaultTimeout)
 		}
-	} else {
-		if ldaps {
-			conn = ldap.NewConn(tls.Client(proxyConn, tlsConfig), true)
-		} else {
-			conn = ldap.NewConn(proxyConn, false)
+
+		if err != nil {
+			return nil, ldap.NewError(ldap.ErrorNetwork, err)
 		}
-		conn.Start()
+	} else if ldaps {
+		netConn = tls.Client(proxyConn, tlsConfig)
 	}
 
-	if err != nil {
-		return nil, err
-	}
+	tracker := newMessageTracker(netConn)
+	conn := ldap.NewConn(tracker, ldaps)
+	conn.Start()
 
 	return &LDAPConn{
 		Conn:          conn,
 		PagingSize:    pagingSize,
 		RootDN:        rootDN,
 		DefaultRootDN: rootDN,
+		tlsConfig:     tlsConfig,
+		connState:     connState{tracker: tracker},
 	}, nil
 }
 
@@ -132,7 +167,9 @@ func (lc *LDAPConn) NTLMBindWithHash(ntlmDomain string, ntlmUsername string, ntl
 	return err
 }
 
-func (lc *LDAPConn) KerbBindWithCCache(ccachePath string, server string, krbDomain string, spnTarget string, etype string) error {
+// KerbBindWithCCache performs a Kerberos bind using a CCACHE file.
+// If dial is not nil, the KDC traffic is sent through it.
+func (lc *LDAPConn) KerbBindWithCCache(ccachePath string, server string, krbDomain string, spnTarget string, etype string, dial func(network, address string) (net.Conn, error)) error {
 	var err error
 	var etypeid int32
 
@@ -157,9 +194,23 @@ func (lc *LDAPConn) KerbBindWithCCache(ccachePath string, server string, krbDoma
 
 	var realm config.Realm
 	realm.Realm = strings.ToUpper(krbDomain)
-	realm.KDC = []string{fmt.Sprintf("%s:88", server)}
+	realm.KDC = []string{net.JoinHostPort(server, "88")}
 	realm.DefaultDomain = strings.ToUpper(krbDomain)
 
+	// The Kerberos client only dials plain TCP, so the KDC
+	// is reached through a local forwarder using the dialer
+	if dial != nil {
+		forwarder, err := proxy.NewForwarder(dial, realm.KDC[0])
+		if err != nil {
+			return err
+		}
+		defer forwarder.Close()
+
+		krbConf.LibDefaults.TCPConnGen = func(string) (*net.TCPConn, error) {
+			return net.DialTCP("tcp", nil, forwarder.Addr())
+		}
+	}
+
 	krbConf.Realms = []config.Realm{realm}
 
 	rawKrbClient, err := client.NewFromCCache(ccache, krbConf)
@@ -182,25 +233,7 @@ func (lc *LDAPConn) KerbBindWithCCache(ccachePath string, server string, krbDoma
 
 // Search
 func (lc *LDAPConn) Query(baseDN string, searchFilter string, scope int, showDeleted bool) ([]*ldap.Entry, error) {
-	var controls []ldap.Control = nil
-	if showDeleted {
-		controls = []ldap.Control{ldap.NewControlMicrosoftShowDeleted()}
-	}
-
-	searchRequest := ldap.NewSearchRequest(
-		baseDN,
-		scope, ldap.NeverDerefAliases, 0, 0, false,
-		searchFilter,
-		[]string{},
-		controls,
-	)
-
-	sr, err := lc.Conn.SearchWithPaging(searchRequest, lc.PagingSize)
-	if err != nil {
-		return nil, err
-	}
-
-	return sr.Entries, nil
+	return lc.QueryContext(context.Background(), baseDN, searchFilter, scope, showDeleted, nil)
 }
 
 func (lc *LDAPConn) FindNamingContexts() ([]string, error) {
@@ -213,7 +246,7 @@ func (lc *LDAPConn) FindNamingContexts() ([]string, error) {
 		nil,
 	)
 
-	searchResult, err := lc.Conn.Search(searchRequest)
+	searchResult, err := lc.search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -231,6 +264,64 @@ func (lc *LDAPConn) FindNamingContexts() ([]string, error) {
 	return []string{}, fmt.Errorf("Naming contexts not found")
 }
 
+// FindDomainPartitions returns the DNs of the domain partitions of the
+// forest from the crossRef objects in the configuration partition
+func (lc *LDAPConn) FindDomainPartitions() ([]string, error) {
+	rootDSE, err := lc.Query("", "(objectClass=*)", ldap.ScopeBaseObject, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rootDSE) < 1 {
+		return nil, fmt.Errorf("RootDSE not found")
+	}
+
+	configurationDN := rootDSE[0].GetAttributeValue("configurationNamingContext")
+
+	// systemFlags 0x2 (FLAG_CR_NTDS_DOMAIN) marks domain partitions
+	crossRefs, err := lc.Query(
+		"CN=Partitions,"+configurationDN,
+		"(&(objectClass=crossRef)(systemFlags:1.2.840.113556.1.4.803:=2))",
+		ldap.ScopeSingleLevel,
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions []string
+	for _, crossRef := range crossRefs {
+		ncName := crossRef.GetAttributeValue("nCName")
+		if ncName != "" {
+			partitions = append(partitions, ncName)
+		}
+	}
+
+	slices.Sort(partitions)
+
+	return partitions, nil
+}
+
+// QueryDomainPartitions returns the root entries of the domain partitions
+func (lc *LDAPConn) QueryDomainPartitions(showDeleted bool) ([]*ldap.Entry, error) {
+	partitions, err := lc.FindDomainPartitions()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*ldap.Entry
+	for _, partition := range partitions {
+		partitionEntry, err := lc.Query(partition, "(objectClass=*)", ldap.ScopeBaseObject, showDeleted)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, partitionEntry...)
+	}
+
+	return entries, nil
+}
+
 func (lc *LDAPConn) FindRootDN() (string, error) {
 	var rootDN string
 	avoidablePrefixes := []string{
@@ -269,7 +360,7 @@ func (lc *LDAPConn) FindRootFQDN() (string, error) {
 		nil,
 	)
 
-	searchResult, err := lc.Conn.Search(searchRequest)
+	searchResult, err := lc.search(searchRequest)
 	if err != nil {
 		return "", err
 	}
@@ -297,7 +388,7 @@ func (lc *LDAPConn) QueryGroupMembersBasic(groupDN string) ([]string, error) {
 		nil,
 	)
 
-	result, err := lc.Conn.SearchWithPaging(search, lc.PagingSize)
+	result, err := lc.searchWithPaging(search, lc.PagingSize)
 	if err != nil {
 		return nil, err
 	}
@@ -324,14 +415,14 @@ func (lc *LDAPConn) QueryObjectGroupsBasic(memberDN string) ([]*ldap.Entry, erro
 	)
 
 	search := ldap.NewSearchRequest(
-		lc.DefaultRootDN,
+		lc.SearchBase(),
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		memberQuery,
 		[]string{"cn", "objectClass"},
 		nil,
 	)
 
-	result, err := lc.Conn.Search(search)
+	result, err := lc.search(search)
 	if err != nil {
 		return nil, err
 	}
@@ -340,22 +431,21 @@ func (lc *LDAPConn) QueryObjectGroupsBasic(memberDN string) ([]*ldap.Entry, erro
 }
 
 func (lc *LDAPConn) QueryGroupMembers(groupDN string) (group []*ldap.Entry, err error) {
+	return lc.QueryGroupMembersContext(context.Background(), groupDN)
+}
+
+func (lc *LDAPConn) QueryGroupMembersContext(ctx context.Context, groupDN string) (group []*ldap.Entry, err error) {
 	ldapQuery := fmt.Sprintf("(memberOf=%s)", ldap.EscapeFilter(groupDN))
 
 	search := ldap.NewSearchRequest(
-		lc.DefaultRootDN,
+		lc.SearchBase(),
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		ldapQuery,
 		[]string{"sAMAccountName", "objectCategory", "objectSid"},
 		nil,
 	)
 
-	result, err := lc.Conn.SearchWithPaging(search, lc.PagingSize)
-	if err != nil {
-		return nil, err
-	}
-
-	return result.Entries, nil
+	return lc.searchPages(ctx, search, nil)
 }
 
 type dnQueueElem struct {
@@ -364,24 +454,23 @@ type dnQueueElem struct {
 }
 
 func (lc *LDAPConn) QueryGroupMembersDeep(groupDN string, maxDepth int) (group []*ldap.Entry, err error) {
+	return lc.QueryGroupMembersDeepContext(context.Background(), groupDN, maxDepth)
+}
+
+func (lc *LDAPConn) QueryGroupMembersDeepContext(ctx context.Context, groupDN string, maxDepth int) (group []*ldap.Entry, err error) {
 	// Use LDAP_MATCHING_RULE_IN_CHAIN to avoid running multiple queries
 	if maxDepth < 0 {
 		ldapQuery := fmt.Sprintf("(memberOf:1.2.840.113556.1.4.1941:=%s)", ldap.EscapeFilter(groupDN))
 
 		search := ldap.NewSearchRequest(
-			lc.DefaultRootDN,
+			lc.SearchBase(),
 			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 			ldapQuery,
 			[]string{"sAMAccountName", "objectCategory", "objectSid"},
 			nil,
 		)
 
-		result, err := lc.Conn.SearchWithPaging(search, lc.PagingSize)
-		if err != nil {
-			return nil, err
-		}
-
-		return result.Entries, nil
+		return lc.searchPages(ctx, search, nil)
 	}
 
 	// Otherwise, query manually up to the specified depth
@@ -397,7 +486,7 @@ func (lc *LDAPConn) QueryGroupMembersDeep(groupDN string, maxDepth int) (group [
 
 		queriesNeeded = queriesNeeded[1:]
 
-		entries, err := lc.QueryGroupMembers(currentDN)
+		entries, err := lc.QueryGroupMembersContext(ctx, currentDN)
 		if err != nil {
 			return nil, err
 		}
@@ -421,7 +510,7 @@ func (lc *LDAPConn) QueryGroupMembersDeep(groupDN string, maxDepth int) (group [
 func (lc *LDAPConn) AddMemberToGroup(memberDN string, groupDN string) error {
 	modifyRequest := ldap.NewModifyRequest(groupDN, nil)
 	modifyRequest.Add("member", []string{memberDN})
-	err := lc.Conn.Modify(modifyRequest)
+	err := lc.modify(modifyRequest)
 	if err != nil {
 		return err
 	}
@@ -432,48 +521,46 @@ func (lc *LDAPConn) AddMemberToGroup(memberDN string, groupDN string) error {
 func (lc *LDAPConn) RemoveMemberFromGroup(memberDN string, groupDN string) error {
 	modifyRequest := ldap.NewModifyRequest(groupDN, nil)
 	modifyRequest.Delete("member", []string{memberDN})
-	err := lc.Conn.Modify(modifyRequest)
+	err := lc.modify(modifyRequest)
 	return err
 }
 
 func (lc *LDAPConn) QueryObjectGroups(memberDN string) ([]*ldap.Entry, error) {
+	return lc.QueryObjectGroupsContext(context.Background(), memberDN)
+}
+
+func (lc *LDAPConn) QueryObjectGroupsContext(ctx context.Context, memberDN string) ([]*ldap.Entry, error) {
 	// Queries the immediate groups that contain the member
 	memberQuery := fmt.Sprintf("(member=%s)", memberDN)
 	search := ldap.NewSearchRequest(
-		lc.DefaultRootDN,
+		lc.SearchBase(),
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		memberQuery,
-		[]string{"name", "objectCategory", "objectSid"},
+		[]string{"name", "objectCategory", "objectSid", "groupType"},
 		nil,
 	)
 
-	result, err := lc.Conn.Search(search)
-	if err != nil {
-		return nil, err
-	}
-
-	return result.Entries, nil
+	return lc.searchPages(ctx, search, nil)
 }
 
 func (lc *LDAPConn) QueryObjectGroupsDeep(objectDN string, maxDepth int) (group []*ldap.Entry, err error) {
+	return lc.QueryObjectGroupsDeepContext(context.Background(), objectDN, maxDepth)
+}
+
+func (lc *LDAPConn) QueryObjectGroupsDeepContext(ctx context.Context, objectDN string, maxDepth int) (group []*ldap.Entry, err error) {
 	// Use LDAP_MATCHING_RULE_IN_CHAIN to avoid running multiple queries
 	if maxDepth < 0 {
 		ldapQuery := fmt.Sprintf("(member:1.2.840.113556.1.4.1941:=%s)", ldap.EscapeFilter(objectDN))
 
 		search := ldap.NewSearchRequest(
-			lc.DefaultRootDN,
+			lc.SearchBase(),
 			ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 			ldapQuery,
-			[]string{"name", "objectCategory", "objectSid"},
+			[]string{"name", "objectCategory", "objectSid", "groupType"},
 			nil,
 		)
 
-		result, err := lc.Conn.SearchWithPaging(search, lc.PagingSize)
-		if err != nil {
-			return nil, err
-		}
-
-		return result.Entries, nil
+		return lc.searchPages(ctx, search, nil)
 	}
 
 	foundDNs := map[string]bool{}
@@ -487,7 +574,7 @@ func (lc *LDAPConn) QueryObjectGroupsDeep(objectDN string, maxDepth int) (group
 		depth = elem.Depth
 		queriesNeeded = queriesNeeded[1:]
 
-		entries, err := lc.QueryObjectGroups(currentDN)
+		entries, err := lc.QueryObjectGroupsContext(ctx, currentDN)
 		if err != nil {
 			return nil, err
 		}
@@ -511,7 +598,7 @@ func (lc *LDAPConn) QueryObjectGroupsDeep(objectDN string, maxDepth int) (group
 func (lc *LDAPConn) FindFirst(identifier string) (*ldap.Entry, error) {
 	samOrDn, _ := SamOrDN(identifier)
 
-	entries, err := lc.Query(lc.DefaultRootDN, samOrDn, ldap.ScopeWholeSubtree, false)
+	entries, err := lc.Query(lc.SearchBase(), samOrDn, ldap.ScopeWholeSubtree, false)
 	if err != nil {
 		return nil, err
 	}
@@ -524,7 +611,7 @@ func (lc *LDAPConn) FindFirst(identifier string) (*ldap.Entry, error) {
 }
 
 func (lc *LDAPConn) QueryFirst(filter string) (*ldap.Entry, error) {
-	entries, err := lc.Query(lc.DefaultRootDN, filter, ldap.ScopeWholeSubtree, false)
+	entries, err := lc.Query(lc.SearchBase(), filter, ldap.ScopeWholeSubtree, false)
 	if err != nil {
 		return nil, err
 	}
@@ -599,7 +686,7 @@ func (lc *LDAPConn) ResetPassword(objectDN string, newPassword string) error {
 
 	passReq := ldap.NewModifyRequest(objectDN, control)
 	passReq.Replace(ldapAttrUnicodePw, []string{pwdEncoded})
-	return lc.Conn.Modify(passReq)
+	return lc.modify(passReq)
 }
 
 func getSupportedControl(conn ldap.Client) ([]string, error) {
@@ -633,7 +720,7 @@ func (lc *LDAPConn) DeleteObject(targetDN string) error {
 
 	deleteRequest := ldap.NewDelRequest(targetDN, nil)
 
-	err = lc.Conn.Del(deleteRequest)
+	err = lc.del(deleteRequest)
 	if err != nil {
 		return err
 	}
@@ -695,6 +782,39 @@ func AddEntriesToRequest(req *ldap.AddRequest, entries AttrEntries) {
 	}
 }
 
+// Attributes maintained by the server that can't be
+// provided when creating a copy of an existing object
+var nonCopyableAttrs = []string{
+	"distinguishedname", "name", "objectguid", "objectsid", "objectcategory",
+	"whencreated", "whenchanged", "usncreated", "usnchanged",
+	"instancetype", "samaccounttype", "primarygroupid", "memberof",
+	"dscorepropagationdata", "ntsecuritydescriptor", "iscriticalsystemobject",
+	"pwdlastset", "lastlogon", "lastlogontimestamp", "lastlogoff", "logoncount",
+	"badpwdcount", "badpasswordtime", "lockouttime", "sidhistory",
+	"msds-keyversionnumber", "serviceprincipalname", "dnshostname",
+}
+
+// CopyableAttributes returns the attributes of an entry that
+// can be used as a template to create a copy of it elsewhere
+func CopyableAttributes(entry *ldap.Entry) AttrEntries {
+	attrs := AttrEntries{}
+	for _, attr := range entry.Attributes {
+		if slices.Contains(nonCopyableAttrs, strings.ToLower(attr.Name)) {
+			continue
+		}
+
+		attrs[attr.Name] = attr.Values
+	}
+
+	return attrs
+}
+
+func (lc *LDAPConn) AddObject(objectDN string, attrs AttrEntries) error {
+	addRequest := ldap.NewAddRequest(objectDN, nil)
+	AddEntriesToRequest(addRequest, attrs)
+	return lc.add(addRequest)
+}
+
 func (lc *LDAPConn) AddGroup(objectName string, parentDN string, dynamicTTL int) error {
 	addRequest := ldap.NewAddRequest("CN="+objectName+","+parentDN, nil)
 	groupTemplate := GetGroupTemplate(objectName)
@@ -704,7 +824,7 @@ func (lc *LDAPConn) AddGroup(objectName string, parentDN string, dynamicTTL int)
 	}
 
 	AddEntriesToRequest(addRequest, groupTemplate)
-	return lc.Conn.Add(addRequest)
+	return lc.add(addRequest)
 }
 
 func (lc *LDAPConn) AddOrganizationalUnit(objectName string, parentDN string, dynamicTTL int) error {
@@ -716,7 +836,7 @@ func (lc *LDAPConn) AddOrganizationalUnit(objectName string, parentDN string, dy
 	}
 
 	AddEntriesToRequest(addRequest, ouTemplate)
-	return lc.Conn.Add(addRequest)
+	return lc.add(addRequest)
 }
 
 func (lc *LDAPConn) AddContainer(objectName string, parentDN string, dynamicTTL int) error {
@@ -728,7 +848,7 @@ func (lc *LDAPConn) AddContainer(objectName string, parentDN string, dynamicTTL
 	}
 
 	AddEntriesToRequest(addRequest, containerTemplate)
-	return lc.Conn.Add(addRequest)
+	return lc.add(addRequest)
 }
 
 func (lc *LDAPConn) AddComputer(objectName string, parentDN string, dynamicTTL int) error {
@@ -740,7 +860,7 @@ func (lc *LDAPConn) AddComputer(objectName string, parentDN string, dynamicTTL i
 	}
 
 	AddEntriesToRequest(addRequest, computerTemplate)
-	return lc.Conn.Add(addRequest)
+	return lc.add(addRequest)
 }
 
 func (lc *LDAPConn) AddUser(objectName string, parentDN string, dynamicTTL int) error {
@@ -761,7 +881,7 @@ func (lc *LDAPConn) AddUser(objectName string, parentDN string, dynamicTTL int)
 	}
 
 	AddEntriesToRequest(addRequest, userTemplate)
-	return lc.Conn.Add(addRequest)
+	return lc.add(addRequest)
 }
 
 func (lc *LDAPConn) AddADIDNSZone(objectName string, props []adidns.DNSProperty, isForest bool) (string, error) {
@@ -787,10 +907,14 @@ func (lc *LDAPConn) AddADIDNSZone(objectName string, props []adidns.DNSProperty,
 
 	addRequest.Attribute("dNSProperty", dNSPropertyList)
 
-	return zoneDN, lc.Conn.Add(addRequest)
+	return zoneDN, lc.add(addRequest)
 }
 
 func (lc *LDAPConn) GetADIDNSZones(name string, isForest bool) ([]adidns.DNSZone, error) {
+	return lc.GetADIDNSZonesContext(context.Background(), name, isForest)
+}
+
+func (lc *LDAPConn) GetADIDNSZonesContext(ctx context.Context, name string, isForest bool) ([]adidns.DNSZone, error) {
 	zoneContainer := "DomainDNSZones"
 	if isForest {
 		zoneContainer = "ForestDNSZones"
@@ -802,7 +926,7 @@ func (lc *LDAPConn) GetADIDNSZones(name string, isForest bool) ([]adidns.DNSZone
 		queryFilter = fmt.Sprintf("(&%s(name=%s))", queryFilter, ldap.EscapeFilter(name))
 	}
 
-	zoneEntries, err := lc.Query(queryDN, queryFilter, ldap.ScopeSingleLevel, false)
+	zoneEntries, err := lc.QueryContext(ctx, queryDN, queryFilter, ldap.ScopeSingleLevel, false, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -905,7 +1029,7 @@ func (lc *LDAPConn) AddADIDNSNode(nodeName string, zoneDN string, records []adid
 		addRequest.Attribute("dnsRecord", dNSRecordList)
 	}
 
-	return nodeDN, lc.Conn.Add(addRequest)
+	return nodeDN, lc.add(addRequest)
 }
 
 func (lc *LDAPConn) AddADIDNSRecords(nodeDN string, records []adidns.DNSRecord) error {
@@ -923,7 +1047,7 @@ func (lc *LDAPConn) AddADIDNSRecords(nodeDN string, records []adidns.DNSRecord)
 		modifyRequest.Add("dnsRecord", dNSRecordList)
 	}
 
-	return lc.Conn.Modify(modifyRequest)
+	return lc.modify(modifyRequest)
 }
 
 func (lc *LDAPConn) ReplaceADIDNSRecords(nodeDN string, records []adidns.DNSRecord) error {
@@ -941,7 +1065,7 @@ func (lc *LDAPConn) ReplaceADIDNSRecords(nodeDN string, records []adidns.DNSReco
 		modifyRequest.Replace("dnsRecord", dNSRecordList)
 	}
 
-	return lc.Conn.Modify(modifyRequest)
+	return lc.modify(modifyRequest)
 }
 
 // Attributes
@@ -951,7 +1075,7 @@ func (lc *LDAPConn) AddAttribute(targetDN string, attributeToAdd string, attribu
 	modifyRequest := ldap.NewModifyRequest(targetDN, nil)
 	modifyRequest.Add(attributeToAdd, attributeValues)
 
-	err = lc.Conn.Modify(modifyRequest)
+	err = lc.modify(modifyRequest)
 	if err != nil {
 		return err
 	}
@@ -965,7 +1089,7 @@ func (lc *LDAPConn) ModifyAttribute(targetDN string, attributeToModify string, a
 	modifyRequest := ldap.NewModifyRequest(targetDN, nil)
 	modifyRequest.Replace(attributeToModify, attributeValues)
 
-	err = lc.Conn.Modify(modifyRequest)
+	err = lc.modify(modifyRequest)
 	return err
 }
 
@@ -975,17 +1099,35 @@ func (lc *LDAPConn) DeleteAttribute(targetDN string, attributeToDelete string) e
 	modifyRequest := ldap.NewModifyRequest(targetDN, nil)
 	modifyRequest.Delete(attributeToDelete, []string{})
 
-	err = lc.Conn.Modify(modifyRequest)
+	err = lc.modify(modifyRequest)
 	return err
 }
 
+// ModifyAttributeValues removes and adds some values of
+// an attribute in a single request, keeping the others
+func (lc *LDAPConn) ModifyAttributeValues(targetDN string, targetAttribute string, valuesToDelete []string, valuesToAdd []string) error {
+	modifyRequest := ldap.NewModifyRequest(targetDN, nil)
+	if len(valuesToDelete) > 0 {
+		modifyRequest.Delete(targetAttribute, valuesToDelete)
+	}
+	if len(valuesToAdd) > 0 {
+		modifyRequest.Add(targetAttribute, valuesToAdd)
+	}
+
+	if len(modifyRequest.Changes) == 0 {
+		return nil
+	}
+
+	return lc.modify(modifyRequest)
+}
+
 func (lc *LDAPConn) DeleteAttributeValues(targetDN string, targetAttribute string, valuesToDelete []string) error {
 	var err error
 
 	modifyRequest := ldap.NewModifyRequest(targetDN, nil)
 	modifyRequest.Delete(targetAttribute, valuesToDelete)
 
-	err = lc.Conn.Modify(modifyRequest)
+	err = lc.modify(modifyRequest)
 	return err
 }
 
@@ -1006,7 +1148,7 @@ func (lc *LDAPConn) MoveObject(sourceDN string, targetDN string) error {
 
 	modifyDNRequest := ldap.NewModifyDNRequest(sourceDN, targetFirstRDN, true, targetNewParent)
 
-	err = lc.Conn.ModifyDN(modifyDNRequest)
+	err = lc.modifyDN(modifyDNRequest)
 
 	return err
 }
@@ -1066,7 +1208,7 @@ func (lc *LDAPConn) GetSecurityDescriptor(object string) (queryResult string, er
 		)
 	}
 
-	result, err := lc.Conn.Search(searchReq)
+	result, err := lc.search(searchReq)
 	if err != nil {
 		return "", err
 	}
@@ -1082,14 +1224,14 @@ func (lc *LDAPConn) GetSecurityDescriptor(object string) (queryResult string, er
 
 func (lc *LDAPConn) FindFirstAttr(filter string, attr string) (string, error) {
 	objectSearch := ldap.NewSearchRequest(
-		lc.DefaultRootDN,
+		lc.SearchBase(),
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		filter,
 		[]string{attr},
 		nil,
 	)
 
-	result, err := lc.Conn.Search(objectSearch)
+	result, err := lc.search(objectSearch)
 	if err != nil {
 		return "", err
 	}
@@ -1147,7 +1289,7 @@ func (lc *LDAPConn) ModifyDACL(objectName string, newSD string) error {
 
 	modifyReq.Replace("nTSecurityDescriptor", []string{newSD})
 
-	err := lc.Conn.Modify(modifyReq)
+	err := lc.modify(modifyReq)
 	return err
 }
 
@@ -1184,14 +1326,14 @@ func (lc *LDAPConn) FindSamForSID(SID string) (resolvedSID string, err error) {
 
 	query := fmt.Sprintf("(objectSID=%s)", SID)
 	searchReq := ldap.NewSearchRequest(
-		lc.DefaultRootDN,
+		lc.SearchBase(),
 		ldap.ScopeWholeSubtree, 0, 0, 0, false,
 		query,
 		[]string{},
 		nil,
 	)
 
-	result, err := lc.Conn.Search(searchReq)
+	result, err := lc.search(searchReq)
 	if err != nil {
 		return "", err
 	}
@@ -1219,7 +1361,7 @@ func (lc *LDAPConn) FindPrimaryGroupForSID(SID string) (groupSID string, err err
 		nil,
 	)
 
-	result, err := lc.Conn.Search(searchReq)
+	result, err := lc.search(searchReq)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,136 @@
+package ldaputils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const ControlTypeAttributeScopedQuery = "1.2.840.113556.1.4.1504"
+
+// ControlAttributeScopedQuery makes a base search run against the
+// objects referenced by a DN-valued attribute of the base object
+// instead of the base object itself
+type ControlAttributeScopedQuery struct {
+	SourceAttribute string
+}
+
+func (c *ControlAttributeScopedQuery) GetControlType() string {
+	return ControlTypeAttributeScopedQuery
+}
+
+func (c *ControlAttributeScopedQuery) Encode() *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, ControlTypeAttributeScopedQuery, "Control Type"))
+	packet.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, true, "Criticality"))
+
+	value := ber.Encode(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, nil, "Control Value(ASQ)")
+	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "ASQRequestValue")
+	seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, c.SourceAttribute, "sourceAttribute"))
+	value.AppendChild(seq)
+
+	packet.AppendChild(value)
+	return packet
+}
+
+func (c *ControlAttributeScopedQuery) String() string {
+	return fmt.Sprintf("Control Type: %s (%q)  Criticality: %t  Source Attribute: %s", "Attribute Scoped Query",
+		ControlTypeAttributeScopedQuery, true, c.SourceAttribute)
+}
+
+// Attributes whose values are SPNs of other accounts rather than DNs
+var spnLinkAttributes = []string{"msds-allowedtodelegateto"}
+
+// Builds a filter matching the accounts that hold the
+// SPNs (or whose hosts are the targets of the SPNs)
+func spnTargetsFilter(spns []string) string {
+	var sb strings.Builder
+	sb.WriteString("(|")
+	for _, spn := range spns {
+		sb.WriteString("(servicePrincipalName=" + ldap.EscapeFilter(spn) + ")")
+
+		// service/host[:port][/service name]
+		if _, target, ok := strings.Cut(spn, "/"); ok {
+			host, _, _ := strings.Cut(target, "/")
+			host, _, _ = strings.Cut(host, ":")
+			sb.WriteString("(dNSHostName=" + ldap.EscapeFilter(host) + ")")
+		}
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// QueryLinkedPages streams the objects referenced by an attribute of
+// objectDN that match searchFilter, requesting the attributes in opts.
+// DN-valued attributes are followed in a single search with the ASQ
+// control when the server supports it, or with a base search per value
+// otherwise, and SPN-valued attributes are resolved to their accounts.
+func (lc *LDAPConn) QueryLinkedPages(ctx context.Context, objectDN string, attribute string, searchFilter string, opts QueryOptions, onPage PageHandler) (QueryProgress, error) {
+	if searchFilter == "" {
+		searchFilter = "(objectClass=*)"
+	}
+
+	if slices.Contains(spnLinkAttributes, strings.ToLower(attribute)) {
+		spns, err := lc.queryAttributeValues(objectDN, attribute)
+		if err != nil || len(spns) == 0 {
+			return QueryProgress{}, err
+		}
+
+		filter := fmt.Sprintf("(&%s%s)", searchFilter, spnTargetsFilter(spns))
+		return lc.streamPages(ctx, queryRequest(lc.SearchBase(), filter, ldap.ScopeWholeSubtree, opts), onPage)
+	}
+
+	if lc.SupportsControl(ControlTypeAttributeScopedQuery) {
+		req := queryRequest(objectDN, searchFilter, ldap.ScopeBaseObject, opts)
+		req.Controls = append(req.Controls, &ControlAttributeScopedQuery{SourceAttribute: attribute})
+		return lc.streamPages(ctx, req, onPage)
+	}
+
+	dns, err := lc.queryAttributeValues(objectDN, attribute)
+	if err != nil {
+		return QueryProgress{}, err
+	}
+
+	var progress QueryProgress
+	for _, dn := range dns {
+		if ctx.Err() != nil {
+			return progress, ctx.Err()
+		}
+
+		result, err := lc.search(queryRequest(dn, searchFilter, ldap.ScopeBaseObject, opts))
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			continue
+		} else if err != nil {
+			return progress, err
+		}
+
+		progress.Pages += 1
+		progress.Entries += len(result.Entries)
+		if onPage != nil && len(result.Entries) > 0 {
+			onPage(result.Entries, progress)
+		}
+	}
+
+	return progress, nil
+}
+
+// Reads the values of an attribute of an object
+func (lc *LDAPConn) queryAttributeValues(objectDN string, attribute string) ([]string, error) {
+	entries, err := lc.QueryWithOptions(
+		objectDN, "(objectClass=*)", ldap.ScopeBaseObject,
+		QueryOptions{Attributes: []string{attribute}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("Object '%s' not found", objectDN)
+	}
+
+	return entries[0].GetEqualFoldAttributeValues(attribute), nil
+}
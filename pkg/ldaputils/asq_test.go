@@ -0,0 +1,17 @@
+package ldaputils
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestControlAttributeScopedQueryEncoding(t *testing.T) {
+	control := &ControlAttributeScopedQuery{SourceAttribute: "member"}
+
+	want := "3028" + octetString(ControlTypeAttributeScopedQuery) + "010101" +
+		"040a" + "3008" + octetString("member")
+
+	if got := hex.EncodeToString(control.Encode().Bytes()); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
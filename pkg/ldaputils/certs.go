@@ -0,0 +1,322 @@
+package ldaputils
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Attributes holding X.509 certificates. Binary msPKI* attributes
+// are also tried as certificates, since several of them hold one.
+var CertificateAttributes = []string{
+	"usercertificate",
+	"cacertificate",
+	"crosscertificatepair",
+	"usersmimecertificate",
+}
+
+// IsCertificateAttribute reports whether the values of
+// an attribute may be X.509 certificates
+func IsCertificateAttribute(name string) bool {
+	lowerName := strings.ToLower(name)
+	for _, certAttr := range CertificateAttributes {
+		if lowerName == certAttr {
+			return true
+		}
+	}
+
+	return strings.HasPrefix(lowerName, "mspki")
+}
+
+// A crossCertificatePair value (RFC 4523)
+type certificatePair struct {
+	Forward asn1.RawValue `asn1:"optional,explicit,tag:0"`
+	Reverse asn1.RawValue `asn1:"optional,explicit,tag:1"`
+}
+
+// ParseCertificates parses a certificate value, which holds one
+// certificate or, for crossCertificatePair, up to two of them
+func ParseCertificates(raw []byte) ([]*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(raw)
+	if err == nil {
+		return []*x509.Certificate{cert}, nil
+	}
+
+	var pair certificatePair
+	if _, pairErr := asn1.Unmarshal(raw, &pair); pairErr != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for _, der := range [][]byte{pair.Forward.Bytes, pair.Reverse.Bytes} {
+		if len(der) == 0 {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+// CertificateExpired reports whether a certificate value
+// holds a certificate that is past its validity
+func CertificateExpired(raw []byte) bool {
+	certs, err := ParseCertificates(raw)
+	if err != nil {
+		return false
+	}
+
+	for _, cert := range certs {
+		if time.Now().After(cert.NotAfter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	oidSubjectAltName    = asn1.ObjectIdentifier{2, 5, 29, 17}
+	oidUPN               = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}
+	oidNTDSCASecurityExt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 25, 2}
+	oidNTDSObjectSID     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 25, 2, 1}
+	oidTemplateName      = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2}
+)
+
+// An otherName of a GeneralName ([0] in a SEQUENCE OF GeneralName)
+type otherName struct {
+	TypeID asn1.ObjectIdentifier
+	Value  asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// Reads the otherNames of a SEQUENCE OF GeneralName, such as the
+// SAN extension or the SID extension of ADCS, by their type
+func parseOtherNames(der []byte) map[string][]string {
+	var names []asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &names); err != nil {
+		return nil
+	}
+
+	values := make(map[string][]string)
+	for _, name := range names {
+		if name.Class != asn1.ClassContextSpecific || name.Tag != 0 {
+			continue
+		}
+
+		// The [0] IMPLICIT tag replaces the tag of the SEQUENCE
+		var other otherName
+		if _, err := asn1.UnmarshalWithParams(name.FullBytes, &other, "tag:0"); err != nil {
+			continue
+		}
+
+		// The explicit tag wraps UTF8Strings (UPN) or OCTET STRINGs (SID)
+		var value asn1.RawValue
+		if _, err := asn1.Unmarshal(other.Value.Bytes, &value); err != nil {
+			continue
+		}
+
+		values[other.TypeID.String()] = append(values[other.TypeID.String()], string(value.Bytes))
+	}
+
+	return values
+}
+
+// Names of the extended key usages seen in AD certificates
+var ExtKeyUsageNames = map[string]string{
+	"1.3.6.1.5.5.7.3.1":       "Server Authentication",
+	"1.3.6.1.5.5.7.3.2":       "Client Authentication",
+	"1.3.6.1.5.5.7.3.3":       "Code Signing",
+	"1.3.6.1.5.5.7.3.4":       "Secure Email",
+	"1.3.6.1.5.5.7.3.8":       "Time Stamping",
+	"1.3.6.1.5.5.7.3.9":       "OCSP Signing",
+	"1.3.6.1.4.1.311.10.3.4":  "Encrypting File System",
+	"1.3.6.1.4.1.311.10.3.12": "Document Signing",
+	"1.3.6.1.4.1.311.20.2.1":  "Certificate Request Agent",
+	"1.3.6.1.4.1.311.20.2.2":  "Smart Card Logon",
+	"1.3.6.1.4.1.311.21.5":    "Private Key Archival",
+	"1.3.6.1.4.1.311.21.6":    "Key Recovery Agent",
+	"1.3.6.1.5.2.3.4":         "PKINIT Client Authentication",
+	"1.3.6.1.5.2.3.5":         "KDC Authentication",
+	"2.5.29.37.0":             "Any Purpose",
+}
+
+// OIDs of the key usages known by crypto/x509
+var extKeyUsageOIDs = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "2.5.29.37.0",
+	x509.ExtKeyUsageServerAuth:      "1.3.6.1.5.5.7.3.1",
+	x509.ExtKeyUsageClientAuth:      "1.3.6.1.5.5.7.3.2",
+	x509.ExtKeyUsageCodeSigning:     "1.3.6.1.5.5.7.3.3",
+	x509.ExtKeyUsageEmailProtection: "1.3.6.1.5.5.7.3.4",
+	x509.ExtKeyUsageTimeStamping:    "1.3.6.1.5.5.7.3.8",
+	x509.ExtKeyUsageOCSPSigning:     "1.3.6.1.5.5.7.3.9",
+}
+
+func extKeyUsageName(oid string) string {
+	if name, ok := ExtKeyUsageNames[oid]; ok {
+		return name
+	}
+	return oid
+}
+
+// CertificateSummary holds the fields of a certificate shown by godap
+type CertificateSummary struct {
+	Subject    string
+	Issuer     string
+	Serial     string
+	NotBefore  time.Time
+	NotAfter   time.Time
+	SANs       []string
+	EKUs       []string
+	Template   string
+	SID        string
+	Thumbprint string
+	SHA256     string
+}
+
+// Expired reports whether the certificate is past its validity
+func (summary CertificateSummary) Expired() bool {
+	return time.Now().After(summary.NotAfter)
+}
+
+// SummarizeCertificate extracts the fields shown for a certificate,
+// including the UPNs and SID that map it to an account in AD
+func SummarizeCertificate(cert *x509.Certificate) CertificateSummary {
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+
+	summary := CertificateSummary{
+		Subject:    cert.Subject.String(),
+		Issuer:     cert.Issuer.String(),
+		Serial:     strings.ToUpper(cert.SerialNumber.Text(16)),
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+		Thumbprint: strings.ToUpper(hex.EncodeToString(sha1Sum[:])),
+		SHA256:     strings.ToUpper(hex.EncodeToString(sha256Sum[:])),
+	}
+
+	for _, name := range cert.DNSNames {
+		summary.SANs = append(summary.SANs, "DNS:"+name)
+	}
+	for _, email := range cert.EmailAddresses {
+		summary.SANs = append(summary.SANs, "Email:"+email)
+	}
+	for _, ip := range cert.IPAddresses {
+		summary.SANs = append(summary.SANs, "IP:"+ip.String())
+	}
+	for _, uri := range cert.URIs {
+		summary.SANs = append(summary.SANs, "URI:"+uri.String())
+	}
+
+	for _, usage := range cert.ExtKeyUsage {
+		summary.EKUs = append(summary.EKUs, extKeyUsageName(extKeyUsageOIDs[usage]))
+	}
+	for _, oid := range cert.UnknownExtKeyUsage {
+		summary.EKUs = append(summary.EKUs, extKeyUsageName(oid.String()))
+	}
+
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidSubjectAltName):
+			for _, upn := range parseOtherNames(ext.Value)[oidUPN.String()] {
+				summary.SANs = append(summary.SANs, "UPN:"+upn)
+			}
+		case ext.Id.Equal(oidNTDSCASecurityExt):
+			if sids := parseOtherNames(ext.Value)[oidNTDSObjectSID.String()]; len(sids) > 0 {
+				summary.SID = sids[0]
+			}
+		case ext.Id.Equal(oidTemplateName):
+			// BMPString with the name of the template (v1 templates)
+			var template asn1.RawValue
+			if _, err := asn1.Unmarshal(ext.Value, &template); err == nil {
+				summary.Template = decodeBMPString(template.Bytes)
+			}
+		}
+	}
+
+	return summary
+}
+
+func decodeBMPString(raw []byte) string {
+	var runes []rune
+	for idx := 0; idx+1 < len(raw); idx += 2 {
+		runes = append(runes, rune(raw[idx])<<8|rune(raw[idx+1]))
+	}
+	return string(runes)
+}
+
+// Lines shown for a certificate in the attributes panel
+func (summary CertificateSummary) Lines(opts FormatOptions) []string {
+	validity := fmt.Sprintf("Validity: %s - %s",
+		summary.NotBefore.Add(time.Hour*time.Duration(opts.TimeOffset)).Format(opts.TimeFormat),
+		summary.NotAfter.Add(time.Hour*time.Duration(opts.TimeOffset)).Format(opts.TimeFormat))
+	if summary.Expired() {
+		validity += " (Expired)"
+	}
+
+	lines := []string{
+		"Subject: " + summary.Subject,
+		"Issuer: " + summary.Issuer,
+		validity,
+	}
+
+	if len(summary.SANs) > 0 {
+		lines = append(lines, "SAN: "+strings.Join(summary.SANs, ", "))
+	}
+	if len(summary.EKUs) > 0 {
+		lines = append(lines, "EKU: "+strings.Join(summary.EKUs, ", "))
+	}
+	if summary.Template != "" {
+		lines = append(lines, "Template: "+summary.Template)
+	}
+	if summary.SID != "" {
+		lines = append(lines, "SID: "+summary.SID)
+	}
+
+	return append(lines, "Serial: "+summary.Serial, "Thumbprint: "+summary.Thumbprint)
+}
+
+// EncodeCertificatePEM returns the certificates of a value as PEM blocks
+func EncodeCertificatePEM(certs []*x509.Certificate) []byte {
+	var output []byte
+	for _, cert := range certs {
+		output = append(output, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return output
+}
+
+// Values that aren't certificates are shown as they are
+func formatCertificate(raw []byte, opts FormatOptions) []string {
+	certs, err := ParseCertificates(raw)
+	if err != nil {
+		return []string{string(raw)}
+	}
+
+	var lines []string
+	for _, cert := range certs {
+		lines = append(lines, SummarizeCertificate(cert).Lines(opts)...)
+	}
+
+	return lines
+}
+
+func init() {
+	for _, name := range CertificateAttributes {
+		RegisterAttributeFormatter(name, formatCertificate)
+	}
+}
@@ -0,0 +1,105 @@
+package ldaputils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Wraps a value in an explicit [tag], which asn1.Marshal
+// doesn't add to RawValues by itself
+func explicitTag(t *testing.T, tag int, value asn1.RawValue) asn1.RawValue {
+	inner, err := asn1.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: tag, IsCompound: true, Bytes: inner}
+}
+
+// Builds a SEQUENCE OF GeneralName with a single otherName
+func marshalOtherName(t *testing.T, typeID asn1.ObjectIdentifier, value asn1.RawValue) []byte {
+	inner, err := asn1.MarshalWithParams(otherName{TypeID: typeID, Value: explicitTag(t, 0, value)}, "tag:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := asn1.Marshal([]asn1.RawValue{{FullBytes: inner}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return names
+}
+
+func TestSummarizeCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upn := asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte("john@corp.local")}
+	sid := asn1.RawValue{Tag: asn1.TagOctetString, Bytes: []byte("S-1-5-21-1-2-3-1104")}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x1234),
+		Subject:      pkix.Name{CommonName: "john"},
+		NotBefore:    time.Now().Add(-48 * time.Hour),
+		NotAfter:     time.Now().Add(-24 * time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		UnknownExtKeyUsage: []asn1.ObjectIdentifier{
+			{1, 3, 6, 1, 4, 1, 311, 20, 2, 2},
+		},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidSubjectAltName, Value: marshalOtherName(t, oidUPN, upn)},
+			{Id: oidNTDSCASecurityExt, Value: marshalOtherName(t, oidNTDSObjectSID, sid)},
+		},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := ParseCertificates(der)
+	if err != nil || len(certs) != 1 {
+		t.Fatalf("ParseCertificates: %v", err)
+	}
+
+	summary := SummarizeCertificate(certs[0])
+	if !slices.Equal(summary.SANs, []string{"UPN:john@corp.local"}) {
+		t.Errorf("unexpected SANs: %v", summary.SANs)
+	}
+
+	if !slices.Equal(summary.EKUs, []string{"Client Authentication", "Smart Card Logon"}) {
+		t.Errorf("unexpected EKUs: %v", summary.EKUs)
+	}
+
+	if summary.SID != "S-1-5-21-1-2-3-1104" || summary.Serial != "1234" || !summary.Expired() {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+
+	if !CertificateExpired(der) || len(EncodeCertificatePEM(certs)) == 0 {
+		t.Errorf("expected an expired certificate")
+	}
+
+	// Both certificates of a crossCertificatePair are read
+	pair, err := asn1.Marshal(certificatePair{
+		Forward: explicitTag(t, 0, asn1.RawValue{FullBytes: der}),
+		Reverse: explicitTag(t, 1, asn1.RawValue{FullBytes: der}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if certs, err := ParseCertificates(pair); err != nil || len(certs) != 2 {
+		t.Errorf("unexpected crossCertificatePair parse: %d certs, %v", len(certs), err)
+	}
+}
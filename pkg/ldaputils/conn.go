@@ -0,0 +1,406 @@
+package ldaputils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Health of the underlying LDAP connection
+type ConnState int
+
+const (
+	StateConnected ConnState = iota
+	StateDegraded
+	StateReconnecting
+	StateDisconnected
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnected:
+		return "ON"
+	case StateDegraded:
+		return "DEGRADED"
+	case StateReconnecting:
+		return "RECONNECTING"
+	}
+
+	return "OFF"
+}
+
+// ConnParams stores what is needed to re-dial and re-bind
+// a dropped connection with the original auth method
+type ConnParams struct {
+	Server string
+	Port   int
+	Ldaps  bool
+
+	// Optional dialer (e.g. a proxy chain)
+	Dial func(network, address string) (net.Conn, error)
+
+	// Called on the new connection after it's established
+	Bind func(lc *LDAPConn) error
+}
+
+// Attempts and initial delay (doubled at each attempt) used when reconnecting
+var (
+	ReconnectAttempts = 3
+	ReconnectBackoff  = 500 * time.Millisecond
+)
+
+// connState holds the reconnection state of an LDAPConn
+type connState struct {
+	params   *ConnParams
+	startTLS bool
+
+	state         ConnState
+	OnStateChange func(ConnState)
+
+	// Tracks the message IDs of searches on the current connection
+	tracker *messageTracker
+
+	connLock      sync.RWMutex
+	reconnectLock sync.Mutex
+	retrying      bool
+	closed        atomic.Bool
+	keepaliveStop chan struct{}
+}
+
+// IsNetworkError reports whether an operation failed
+// because the connection to the server was lost
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.As(err, &netErr)
+}
+
+func (lc *LDAPConn) State() ConnState {
+	lc.connLock.RLock()
+	defer lc.connLock.RUnlock()
+	return lc.state
+}
+
+func (lc *LDAPConn) setState(state ConnState) {
+	lc.connLock.Lock()
+	changed := lc.state != state
+	lc.state = state
+	callback := lc.OnStateChange
+	lc.connLock.Unlock()
+
+	if changed && callback != nil {
+		callback(state)
+	}
+}
+
+func (lc *LDAPConn) conn() *ldap.Conn {
+	lc.connLock.RLock()
+	defer lc.connLock.RUnlock()
+	return lc.Conn
+}
+
+func (lc *LDAPConn) connTracker() (*ldap.Conn, *messageTracker) {
+	lc.connLock.RLock()
+	defer lc.connLock.RUnlock()
+	return lc.Conn, lc.tracker
+}
+
+// SetConnParams enables transparent reconnection for the connection
+func (lc *LDAPConn) SetConnParams(params ConnParams) {
+	lc.params = &params
+}
+
+// Reconnect re-dials and re-binds the connection, upgrading
+// it with StartTLS again if it had been upgraded before
+func (lc *LDAPConn) Reconnect() error {
+	return lc.reconnectFrom(lc.conn())
+}
+
+// Reconnects only if the connection that failed is still the
+// current one, so that concurrent failures reconnect only once.
+// A single attempt is made right away, since the caller may be
+// waiting on it, and the others are made in the background.
+func (lc *LDAPConn) reconnectFrom(failed *ldap.Conn) error {
+	lc.reconnectLock.Lock()
+	defer lc.reconnectLock.Unlock()
+
+	if lc.conn() != failed {
+		return nil
+	}
+
+	if lc.params == nil {
+		return fmt.Errorf("Reconnection is not configured for this connection")
+	}
+
+	if lc.retrying {
+		return fmt.Errorf("Reconnection in progress")
+	}
+
+	lc.setState(StateReconnecting)
+
+	err := lc.redial()
+	if err == nil {
+		lc.setState(StateConnected)
+		return nil
+	}
+
+	lc.retrying = true
+	go lc.reconnectInBackground(failed)
+
+	return fmt.Errorf("Reconnection failed: %w (retrying in the background)", err)
+}
+
+// Retries reconnecting, waiting longer after each failed attempt
+func (lc *LDAPConn) reconnectInBackground(failed *ldap.Conn) {
+	lc.reconnectLock.Lock()
+	defer lc.reconnectLock.Unlock()
+	defer func() { lc.retrying = false }()
+
+	delay := ReconnectBackoff
+	for attempt := 1; attempt < ReconnectAttempts; attempt++ {
+		// Callers that fail meanwhile see that the
+		// reconnection is in progress instead of waiting
+		lc.reconnectLock.Unlock()
+		time.Sleep(delay)
+		lc.reconnectLock.Lock()
+
+		delay *= 2
+
+		if lc.closed.Load() || lc.conn() != failed {
+			return
+		}
+
+		if lc.redial() == nil {
+			lc.setState(StateConnected)
+			return
+		}
+	}
+
+	lc.setState(StateDisconnected)
+}
+
+// Reports whether reconnection attempts are being made in the background
+func (lc *LDAPConn) isRetrying() bool {
+	lc.reconnectLock.Lock()
+	defer lc.reconnectLock.Unlock()
+	return lc.retrying
+}
+
+// Dials and binds a new connection, which only
+// replaces the current one once it's ready
+func (lc *LDAPConn) redial() error {
+	var proxyConn net.Conn
+	var err error
+
+	if lc.params.Dial != nil {
+		address := net.JoinHostPort(lc.params.Server, fmt.Sprint(lc.params.Port))
+		proxyConn, err = lc.params.Dial("tcp", address)
+		if err != nil {
+			return err
+		}
+	}
+
+	newConn, err := NewLDAPConn(
+		lc.params.Server, lc.params.Port, lc.params.Ldaps,
+		lc.tlsConfig, lc.PagingSize, lc.RootDN, proxyConn,
+	)
+	if err != nil {
+		return err
+	}
+
+	if lc.startTLS && !lc.params.Ldaps {
+		err = newConn.UpgradeToTLS(lc.tlsConfig)
+	}
+
+	if err == nil && lc.params.Bind != nil {
+		err = lc.params.Bind(newConn)
+	}
+
+	if err != nil {
+		newConn.Conn.Close()
+		return err
+	}
+
+	lc.connLock.Lock()
+	oldConn := lc.Conn
+	lc.Conn = newConn.Conn
+	lc.tracker = newConn.tracker
+	lc.connLock.Unlock()
+
+	if oldConn != nil {
+		oldConn.Close()
+	}
+
+	return nil
+}
+
+// Runs a read operation, retrying it once
+// after reconnecting if the connection was lost
+func (lc *LDAPConn) retryRead(op func(conn *ldap.Conn) error) error {
+	conn := lc.conn()
+	err := op(conn)
+	if !IsNetworkError(err) || lc.params == nil {
+		return err
+	}
+
+	if reconnErr := lc.reconnectFrom(conn); reconnErr != nil {
+		return reconnErr
+	}
+
+	return op(lc.conn())
+}
+
+// Runs a write operation, which is not retried since it may
+// have been applied, but restores the connection if it was lost
+func (lc *LDAPConn) checkWrite(op func(conn *ldap.Conn) error) error {
+	conn := lc.conn()
+	err := op(conn)
+	if !IsNetworkError(err) || lc.params == nil {
+		return err
+	}
+
+	if reconnErr := lc.reconnectFrom(conn); reconnErr != nil {
+		return fmt.Errorf("%w (%s)", err, reconnErr)
+	}
+
+	return fmt.Errorf("%w (connection restored, the operation was not retried)", err)
+}
+
+func (lc *LDAPConn) search(req *ldap.SearchRequest) (result *ldap.SearchResult, err error) {
+	err = lc.retryRead(func(conn *ldap.Conn) error {
+		result, err = conn.Search(req)
+		return err
+	})
+
+	return result, err
+}
+
+func (lc *LDAPConn) searchWithPaging(req *ldap.SearchRequest, pagingSize uint32) (result *ldap.SearchResult, err error) {
+	err = lc.retryRead(func(conn *ldap.Conn) error {
+		// Paging controls are added to the request by SearchWithPaging
+		// and must not be reused in the retried request
+		retryReq := *req
+		retryReq.Controls = append([]ldap.Control{}, req.Controls...)
+
+		result, err = conn.SearchWithPaging(&retryReq, pagingSize)
+		return err
+	})
+
+	return result, err
+}
+
+func (lc *LDAPConn) add(req *ldap.AddRequest) error {
+	return lc.checkWrite(func(conn *ldap.Conn) error {
+		return conn.Add(req)
+	})
+}
+
+func (lc *LDAPConn) modify(req *ldap.ModifyRequest) error {
+	return lc.checkWrite(func(conn *ldap.Conn) error {
+		return conn.Modify(req)
+	})
+}
+
+func (lc *LDAPConn) modifyDN(req *ldap.ModifyDNRequest) error {
+	return lc.checkWrite(func(conn *ldap.Conn) error {
+		return conn.ModifyDN(req)
+	})
+}
+
+func (lc *LDAPConn) del(req *ldap.DelRequest) error {
+	return lc.checkWrite(func(conn *ldap.Conn) error {
+		return conn.Del(req)
+	})
+}
+
+// StartKeepalive periodically reads the RootDSE to keep the connection
+// from idling out and to detect (and restore) dropped connections.
+// Round-trips slower than slowThreshold mark the connection as degraded.
+func (lc *LDAPConn) StartKeepalive(interval time.Duration, slowThreshold time.Duration) {
+	lc.StopKeepalive()
+
+	stop := make(chan struct{})
+	lc.keepaliveStop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			// The background reconnection reports its own state
+			if lc.isRetrying() {
+				continue
+			}
+
+			startTime := time.Now()
+			_, err := lc.search(ldap.NewSearchRequest(
+				"",
+				ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false,
+				"(objectClass=*)",
+				[]string{"currentTime"},
+				nil,
+			))
+
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			// The probe itself may have started a background
+			// reconnection, which is still retrying
+			if lc.isRetrying() {
+				continue
+			}
+
+			if err != nil {
+				if IsNetworkError(err) || lc.State() == StateDisconnected {
+					lc.setState(StateDisconnected)
+				} else {
+					lc.setState(StateDegraded)
+				}
+			} else if time.Since(startTime) > slowThreshold {
+				lc.setState(StateDegraded)
+			} else {
+				lc.setState(StateConnected)
+			}
+		}
+	}()
+}
+
+func (lc *LDAPConn) StopKeepalive() {
+	if lc.keepaliveStop != nil {
+		close(lc.keepaliveStop)
+		lc.keepaliveStop = nil
+	}
+}
+
+// Close stops the keepalive and the reconnection
+// attempts and closes the underlying connection
+func (lc *LDAPConn) Close() {
+	lc.StopKeepalive()
+	lc.closed.Store(true)
+
+	if conn := lc.conn(); conn != nil {
+		conn.Close()
+	}
+}
@@ -0,0 +1,519 @@
+package ldaputils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// FilterType is the kind of a node of a parsed search filter
+type FilterType int
+
+const (
+	FilterAnd FilterType = iota
+	FilterOr
+	FilterNot
+	FilterEquality
+	FilterSubstrings
+	FilterGreaterOrEqual
+	FilterLessOrEqual
+	FilterPresent
+	FilterApproxMatch
+	FilterExtensibleMatch
+)
+
+// Matching rules of AD used in extensible match filters
+const (
+	MatchingRuleBitAnd  = "1.2.840.113556.1.4.803"
+	MatchingRuleBitOr   = "1.2.840.113556.1.4.804"
+	MatchingRuleInChain = "1.2.840.113556.1.4.1941"
+)
+
+// Filter is a node of the AST of a search filter (RFC 4515)
+type Filter struct {
+	Type FilterType
+
+	// Subfilters of &, | and !
+	Children []*Filter
+
+	Attribute string
+
+	// Unescaped assertion value of simple and extensible matches
+	Value string
+
+	// Parts of a substrings match (e.g. "a*b*c" has
+	// Initial "a", Any ["b"] and Final "c")
+	Initial string
+	Any     []string
+	Final   string
+
+	MatchingRule string
+	DNAttributes bool
+
+	// Text of the item as typed, kept for pretty-printing
+	raw string
+}
+
+// FilterSyntaxError reports where a search filter is malformed
+type FilterSyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (err *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s", err.Pos+1, err.Msg)
+}
+
+type filterParser struct {
+	text string
+	pos  int
+}
+
+func (p *filterParser) fail(msg string, args ...any) error {
+	return &FilterSyntaxError{Pos: p.pos, Msg: fmt.Sprintf(msg, args...)}
+}
+
+func (p *filterParser) peek() byte {
+	if p.pos < len(p.text) {
+		return p.text[p.pos]
+	}
+	return 0
+}
+
+// ParseFilter parses a search filter into its AST
+func ParseFilter(text string) (*Filter, error) {
+	p := &filterParser{text: strings.TrimSpace(text)}
+
+	filter, err := p.parseFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.text) {
+		return nil, p.fail("unexpected text after the end of the filter")
+	}
+
+	return filter, nil
+}
+
+func (p *filterParser) parseFilter() (*Filter, error) {
+	if p.peek() != '(' {
+		return nil, p.fail("expected '('")
+	}
+	p.pos++
+
+	var filter *Filter
+	var err error
+
+	switch p.peek() {
+	case '&', '|':
+		filter = &Filter{Type: FilterAnd}
+		if p.peek() == '|' {
+			filter.Type = FilterOr
+		}
+		p.pos++
+
+		for p.peek() == '(' {
+			child, err := p.parseFilter()
+			if err != nil {
+				return nil, err
+			}
+			filter.Children = append(filter.Children, child)
+		}
+	case '!':
+		p.pos++
+
+		child, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		filter = &Filter{Type: FilterNot, Children: []*Filter{child}}
+	default:
+		filter, err = p.parseItem()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek() != ')' {
+		if p.pos >= len(p.text) {
+			return nil, p.fail("missing ')'")
+		}
+		return nil, p.fail("expected ')' but found '%c'", p.peek())
+	}
+	p.pos++
+
+	return filter, nil
+}
+
+func isAttributeChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == ';' || c == '.' || c == '_'
+}
+
+func (p *filterParser) parseAttribute() string {
+	start := p.pos
+	for p.pos < len(p.text) && isAttributeChar(p.text[p.pos]) {
+		p.pos++
+	}
+	return p.text[start:p.pos]
+}
+
+func (p *filterParser) parseItem() (*Filter, error) {
+	start := p.pos - 1
+	filter := &Filter{Attribute: p.parseAttribute()}
+
+	// Extensible match: attr[:dn][:rule]:=value or [:dn]:rule:=value
+	if p.peek() == ':' {
+		filter.Type = FilterExtensibleMatch
+
+		for p.peek() == ':' && !strings.HasPrefix(p.text[p.pos:], ":=") {
+			p.pos++
+			part := p.parseAttribute()
+
+			switch {
+			case strings.EqualFold(part, "dn") && !filter.DNAttributes && filter.MatchingRule == "":
+				filter.DNAttributes = true
+			case part != "" && filter.MatchingRule == "":
+				filter.MatchingRule = part
+			default:
+				return nil, p.fail("invalid extensible match")
+			}
+		}
+
+		if !strings.HasPrefix(p.text[p.pos:], ":=") {
+			return nil, p.fail("expected ':='")
+		}
+		p.pos += 2
+
+		if filter.Attribute == "" && filter.MatchingRule == "" {
+			return nil, p.fail("extensible match without attribute or matching rule")
+		}
+	} else {
+		if filter.Attribute == "" {
+			return nil, p.fail("expected an attribute name")
+		}
+
+		switch {
+		case strings.HasPrefix(p.text[p.pos:], ">="):
+			filter.Type = FilterGreaterOrEqual
+			p.pos += 2
+		case strings.HasPrefix(p.text[p.pos:], "<="):
+			filter.Type = FilterLessOrEqual
+			p.pos += 2
+		case strings.HasPrefix(p.text[p.pos:], "~="):
+			filter.Type = FilterApproxMatch
+			p.pos += 2
+		case p.peek() == '=':
+			filter.Type = FilterEquality
+			p.pos++
+		default:
+			return nil, p.fail("expected '=', '>=', '<=' or '~=' after '%s'", filter.Attribute)
+		}
+	}
+
+	parts, err := p.parseValue(filter.Type == FilterEquality)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(parts) == 1:
+		filter.Value = parts[0]
+	case len(parts) == 2 && parts[0] == "" && parts[1] == "":
+		filter.Type = FilterPresent
+	default:
+		filter.Type = FilterSubstrings
+		filter.Initial = parts[0]
+		filter.Any = parts[1 : len(parts)-1]
+		filter.Final = parts[len(parts)-1]
+	}
+
+	filter.raw = p.text[start : p.pos+1]
+	return filter, nil
+}
+
+// Reads an assertion value up to the closing parenthesis, unescaping
+// it and splitting it at each '*' when wildcards are allowed
+func (p *filterParser) parseValue(wildcards bool) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+
+		switch c {
+		case ')':
+			return append(parts, current.String()), nil
+		case '(':
+			return nil, p.fail("unescaped '(' in value (use \\28)")
+		case '*':
+			if !wildcards {
+				return nil, p.fail("unexpected '*' in value (use \\2a)")
+			}
+			parts = append(parts, current.String())
+			current.Reset()
+		case '\\':
+			if p.pos+2 >= len(p.text) {
+				return nil, p.fail("incomplete escape sequence")
+			}
+
+			decoded, err := hex.DecodeString(p.text[p.pos+1 : p.pos+3])
+			if err != nil {
+				return nil, p.fail("invalid escape sequence '%s'", p.text[p.pos:p.pos+3])
+			}
+
+			current.Write(decoded)
+			p.pos += 2
+		default:
+			current.WriteByte(c)
+		}
+
+		p.pos++
+	}
+
+	return nil, p.fail("missing ')'")
+}
+
+// Operator of the simple items of a filter
+func (f *Filter) operator() string {
+	switch f.Type {
+	case FilterGreaterOrEqual:
+		return ">="
+	case FilterLessOrEqual:
+		return "<="
+	case FilterApproxMatch:
+		return "~="
+	case FilterExtensibleMatch:
+		return ":="
+	}
+	return "="
+}
+
+// String returns the filter in its canonical form
+func (f *Filter) String() string {
+	switch f.Type {
+	case FilterAnd, FilterOr, FilterNot:
+		var sb strings.Builder
+		sb.WriteString("(" + map[FilterType]string{FilterAnd: "&", FilterOr: "|", FilterNot: "!"}[f.Type])
+		for _, child := range f.Children {
+			sb.WriteString(child.String())
+		}
+		sb.WriteString(")")
+		return sb.String()
+	case FilterPresent:
+		return "(" + f.Attribute + "=*)"
+	case FilterSubstrings:
+		parts := []string{ldap.EscapeFilter(f.Initial)}
+		for _, part := range f.Any {
+			parts = append(parts, ldap.EscapeFilter(part))
+		}
+		parts = append(parts, ldap.EscapeFilter(f.Final))
+		return "(" + f.Attribute + "=" + strings.Join(parts, "*") + ")"
+	case FilterExtensibleMatch:
+		attr := f.Attribute
+		if f.DNAttributes {
+			attr += ":dn"
+		}
+		if f.MatchingRule != "" {
+			attr += ":" + f.MatchingRule
+		}
+		return "(" + attr + ":=" + ldap.EscapeFilter(f.Value) + ")"
+	}
+
+	return "(" + f.Attribute + f.operator() + ldap.EscapeFilter(f.Value) + ")"
+}
+
+// Pretty returns the filter indented with one subfilter per line,
+// keeping subfilters shorter than width in a single line
+func (f *Filter) Pretty(width int) string {
+	var sb strings.Builder
+	f.pretty(&sb, "", width)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (f *Filter) text() string {
+	if f.raw != "" {
+		return f.raw
+	}
+	return f.String()
+}
+
+func (f *Filter) pretty(sb *strings.Builder, indent string, width int) {
+	isComposite := f.Type == FilterAnd || f.Type == FilterOr || f.Type == FilterNot
+	if !isComposite || len(indent)+len(f.String()) <= width {
+		if isComposite {
+			sb.WriteString(indent + "(" + string(f.String()[1]))
+			for _, child := range f.Children {
+				sb.WriteString(child.text())
+			}
+			sb.WriteString(")\n")
+		} else {
+			sb.WriteString(indent + f.text() + "\n")
+		}
+		return
+	}
+
+	sb.WriteString(indent + "(" + string(f.String()[1]) + "\n")
+	for _, child := range f.Children {
+		child.pretty(sb, indent+"  ", width)
+	}
+	sb.WriteString(indent + ")\n")
+}
+
+// Values of an attribute of an entry, where the DN of
+// the entry stands for distinguishedName and entryDN
+func entryValues(entry *ldap.Entry, attribute string) []string {
+	values := entry.GetAttributeValues(attribute)
+	if len(values) == 0 && (strings.EqualFold(attribute, "distinguishedName") || strings.EqualFold(attribute, "entryDN")) {
+		return []string{entry.DN}
+	}
+	return values
+}
+
+// Compares values numerically when both are integers, and
+// case-insensitively otherwise (which also orders generalized times)
+func compareValues(a string, b string) int {
+	numA, errA := strconv.ParseInt(a, 10, 64)
+	numB, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case numA < numB:
+			return -1
+		case numA > numB:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func (f *Filter) matchesValue(value string) bool {
+	switch f.Type {
+	case FilterEquality, FilterApproxMatch:
+		if strings.EqualFold(value, f.Value) {
+			return true
+		}
+
+		// AD accepts the short name of a class for objectCategory,
+		// which holds the DN of the class (e.g. CN=Person,CN=Schema,...)
+		if strings.EqualFold(f.Attribute, "objectCategory") && !strings.Contains(f.Value, "=") {
+			rdn, _, _ := strings.Cut(value, ",")
+			return strings.EqualFold(strings.TrimPrefix(strings.ToLower(rdn), "cn="), f.Value)
+		}
+
+		return false
+	case FilterGreaterOrEqual:
+		return compareValues(value, f.Value) >= 0
+	case FilterLessOrEqual:
+		return compareValues(value, f.Value) <= 0
+	case FilterSubstrings:
+		value = strings.ToLower(value)
+		if !strings.HasPrefix(value, strings.ToLower(f.Initial)) {
+			return false
+		}
+		value = value[len(f.Initial):]
+
+		for _, part := range f.Any {
+			idx := strings.Index(value, strings.ToLower(part))
+			if idx < 0 {
+				return false
+			}
+			value = value[idx+len(part):]
+		}
+
+		return strings.HasSuffix(value, strings.ToLower(f.Final))
+	case FilterExtensibleMatch:
+		switch f.MatchingRule {
+		case MatchingRuleBitAnd, MatchingRuleBitOr:
+			num, err := strconv.ParseInt(value, 10, 64)
+			mask, maskErr := strconv.ParseInt(f.Value, 10, 64)
+			if err != nil || maskErr != nil {
+				return false
+			}
+
+			if f.MatchingRule == MatchingRuleBitAnd {
+				return num&mask == mask
+			}
+			return num&mask != 0
+		}
+
+		// Other rules (including the transitive in-chain rule,
+		// which needs the server) are evaluated as equality
+		return strings.EqualFold(value, f.Value)
+	}
+
+	return false
+}
+
+// Matches evaluates the filter against an entry on the client side.
+// The transitive in-chain rule is only checked against direct values.
+func (f *Filter) Matches(entry *ldap.Entry) bool {
+	switch f.Type {
+	case FilterAnd:
+		for _, child := range f.Children {
+			if !child.Matches(entry) {
+				return false
+			}
+		}
+		return true
+	case FilterOr:
+		for _, child := range f.Children {
+			if child.Matches(entry) {
+				return true
+			}
+		}
+		return false
+	case FilterNot:
+		return !f.Children[0].Matches(entry)
+	case FilterPresent:
+		return strings.EqualFold(f.Attribute, "objectClass") || len(entryValues(entry, f.Attribute)) > 0
+	}
+
+	var values []string
+	if f.Attribute != "" {
+		values = entryValues(entry, f.Attribute)
+	} else {
+		for _, attr := range entry.Attributes {
+			values = append(values, attr.Values...)
+		}
+	}
+
+	if f.DNAttributes {
+		for _, rdn := range strings.Split(entry.DN, ",") {
+			if attr, value, ok := strings.Cut(rdn, "="); ok && (f.Attribute == "" || strings.EqualFold(attr, f.Attribute)) {
+				values = append(values, value)
+			}
+		}
+	}
+
+	for _, value := range values {
+		if f.matchesValue(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SearchInputFilter turns the text typed in a search box into a filter:
+// filters are kept as typed, and "attr=value" items get parentheses.
+// It returns false for anything else, which is taken as an object name.
+func SearchInputFilter(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "(") {
+		return text, true
+	}
+
+	if strings.Contains(text, "=") {
+		if _, err := ParseFilter("(" + text + ")"); err == nil {
+			return "(" + text + ")", true
+		}
+	}
+
+	return text, false
+}
@@ -0,0 +1,123 @@
+package ldaputils
+
+import (
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestParseFilter(t *testing.T) {
+	valid := map[string]string{
+		"(cn=admin)": "(cn=admin)",
+		"(&(objectCategory=person)(objectClass=user))":   "(&(objectCategory=person)(objectClass=user))",
+		"(|(cn=a*)(!(sn=*b*c)))":                         "(|(cn=a*)(!(sn=*b*c)))",
+		"(description=*)":                                "(description=*)",
+		"(cn=a\\28b\\29)":                                "(cn=a\\28b\\29)",
+		"(pwdLastSet<=0)":                                "(pwdLastSet<=0)",
+		"(userAccountControl:1.2.840.113556.1.4.803:=2)": "(userAccountControl:1.2.840.113556.1.4.803:=2)",
+		"(memberOf:1.2.840.113556.1.4.1941:=CN=G,DC=x)":  "(memberOf:1.2.840.113556.1.4.1941:=CN=G,DC=x)",
+		"(:dn:2.4.6.8.10:=Dino)":                         "(:dn:2.4.6.8.10:=Dino)",
+		"  (&)  ":                                        "(&)",
+	}
+
+	for input, expected := range valid {
+		filter, err := ParseFilter(input)
+		if err != nil {
+			t.Errorf("ParseFilter(%q) failed: %v", input, err)
+			continue
+		}
+
+		if filter.String() != expected {
+			t.Errorf("ParseFilter(%q).String() = %q, expected %q", input, filter.String(), expected)
+		}
+	}
+
+	invalid := map[string]int{
+		"cn=admin":         0,
+		"(cn=admin":        9,
+		"(&(cn=a)(sn=b)":   14,
+		"(cn=a(b)":         5,
+		"(=a)":             1,
+		"(cn~a)":           3,
+		"(cn=a\\zz)":       5,
+		"(cn=a))":          6,
+		"(uac:1.2.3:=a*b)": 13,
+		"(uac:1.2:3.4:=x)": 12,
+	}
+
+	for input, pos := range invalid {
+		_, err := ParseFilter(input)
+
+		syntaxErr, ok := err.(*FilterSyntaxError)
+		if !ok {
+			t.Errorf("ParseFilter(%q) = %v, expected a syntax error", input, err)
+			continue
+		}
+
+		if syntaxErr.Pos != pos {
+			t.Errorf("ParseFilter(%q) failed at %d, expected %d (%s)", input, syntaxErr.Pos, pos, syntaxErr.Msg)
+		}
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	entry := ldap.NewEntry("CN=John Doe,OU=Staff,DC=corp,DC=local", map[string][]string{
+		"objectClass":        {"top", "person", "user"},
+		"objectCategory":     {"CN=Person,CN=Schema,CN=Configuration,DC=corp,DC=local"},
+		"sAMAccountName":     {"jdoe"},
+		"userAccountControl": {"66050"},
+		"pwdLastSet":         {"133000000000000000"},
+		"whenCreated":        {"20240101120000.0Z"},
+	})
+
+	cases := map[string]bool{
+		"(sAMAccountName=JDOE)":                                   true,
+		"(&(objectCategory=person)(objectClass=user))":            true,
+		"(objectCategory=computer)":                               false,
+		"(sAMAccountName=j*e)":                                    true,
+		"(sAMAccountName=*x*)":                                    false,
+		"(mail=*)":                                                false,
+		"(!(mail=*))":                                             true,
+		"(userAccountControl:1.2.840.113556.1.4.803:=65538)":      true,
+		"(userAccountControl:1.2.840.113556.1.4.803:=16)":         false,
+		"(userAccountControl:1.2.840.113556.1.4.804:=18)":         true,
+		"(pwdLastSet<=133000000000000001)":                        true,
+		"(pwdLastSet>=200000000000000000)":                        false,
+		"(whenCreated>=20230101000000.0Z)":                        true,
+		"(|(sAMAccountName=admin)(distinguishedName=*OU=Staff*))": true,
+		"(ou:dn:=Staff)":                                          true,
+	}
+
+	for input, expected := range cases {
+		filter, err := ParseFilter(input)
+		if err != nil {
+			t.Errorf("ParseFilter(%q) failed: %v", input, err)
+			continue
+		}
+
+		if filter.Matches(entry) != expected {
+			t.Errorf("%q matched %v, expected %v", input, !expected, expected)
+		}
+	}
+}
+
+func TestSearchInputFilter(t *testing.T) {
+	cases := map[string]struct {
+		filter   string
+		isFilter bool
+	}{
+		"(cn=x)":   {"(cn=x)", true},
+		"cn=x":     {"(cn=x)", true},
+		"jdoe":     {"jdoe", false},
+		"(broken":  {"(broken", true},
+		"a=b)(c=d": {"a=b)(c=d", false},
+	}
+
+	for input, expected := range cases {
+		filter, isFilter := SearchInputFilter(input)
+		if filter != expected.filter || isFilter != expected.isFilter {
+			t.Errorf("SearchInputFilter(%q) = (%q, %v), expected (%q, %v)",
+				input, filter, isFilter, expected.filter, expected.isFilter)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package ldaputils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlagAttribute describes an integer attribute that is either
+// a combination of bit flags or one of a set of values
+type FlagAttribute struct {
+	Bits map[int]string
+	Enum map[int]string
+
+	// Constructed attributes can't be written
+	ReadOnly bool
+}
+
+// Values of msDS-SupportedEncryptionTypes
+var EncryptionTypeFlags = map[int]string{
+	0x1:   "DES-CBC-CRC",
+	0x2:   "DES-CBC-MD5",
+	0x4:   "RC4-HMAC",
+	0x8:   "AES128-CTS-HMAC-SHA1-96",
+	0x10:  "AES256-CTS-HMAC-SHA1-96",
+	0x20:  "FAST-Supported",
+	0x40:  "Compound-Identity-Supported",
+	0x80:  "Claims-Supported",
+	0x100: "Resource-SID-Compression-Disabled",
+}
+
+// Values of trustAttributes
+var TrustAttributeFlags = map[int]string{
+	0x1:   "NonTransitive",
+	0x2:   "UplevelOnly",
+	0x4:   "QuarantinedDomain",
+	0x8:   "ForestTransitive",
+	0x10:  "CrossOrganization",
+	0x20:  "WithinForest",
+	0x40:  "TreatAsExternal",
+	0x80:  "UsesRC4Encryption",
+	0x200: "CrossOrganizationNoTGTDelegation",
+	0x400: "PIMTrust",
+	0x800: "CrossOrganizationEnableTGTDelegation",
+}
+
+// Values of trustDirection
+var TrustDirectionMap = map[int]string{
+	0: "Disabled",
+	1: "Inbound",
+	2: "Outbound",
+	3: "Bidirectional",
+}
+
+// Values of trustType
+var TrustTypeMap = map[int]string{
+	1: "Downlevel (Windows NT)",
+	2: "Uplevel (Active Directory)",
+	3: "MIT (Kerberos realm)",
+	4: "DCE",
+	5: "AAD",
+}
+
+// Values of pwdProperties
+var PwdPropertiesFlags = map[int]string{
+	0x1:  "PasswordComplex",
+	0x2:  "PasswordNoAnonChange",
+	0x4:  "PasswordNoClearChange",
+	0x8:  "LockoutAdmins",
+	0x10: "PasswordStoreCleartext",
+	0x20: "RefusePasswordChange",
+}
+
+// Values of msDS-Behavior-Version (functional levels)
+var BehaviorVersionMap = map[int]string{
+	0:  "Windows 2000",
+	1:  "Windows Server 2003 Interim",
+	2:  "Windows Server 2003",
+	3:  "Windows Server 2008",
+	4:  "Windows Server 2008 R2",
+	5:  "Windows Server 2012",
+	6:  "Windows Server 2012 R2",
+	7:  "Windows Server 2016",
+	10: "Windows Server 2025",
+}
+
+// Values of systemFlags. The lowest bits mean different things
+// for attributes (ATTR_*) and for crossRef objects (CR_*)
+var SystemFlags = map[int]string{
+	0x1:         "AttrNotReplicated/CrNtdsNC",
+	0x2:         "AttrReqPartialSetMember/CrNtdsDomain",
+	0x4:         "AttrIsConstructed/CrNtdsNotGCReplicated",
+	0x10:        "AttrIsOperational",
+	0x20:        "SchemaBaseObject",
+	0x40:        "AttrIsRDN",
+	0x2000000:   "DisallowMoveOnDelete",
+	0x4000000:   "DomainDisallowMove",
+	0x8000000:   "DomainDisallowRename",
+	0x10000000:  "ConfigAllowLimitedMove",
+	0x20000000:  "ConfigAllowMove",
+	0x40000000:  "ConfigAllowRename",
+	-0x80000000: "DisallowDelete",
+}
+
+// Values of searchFlags
+var SearchFlags = map[int]string{
+	0x1:    "Indexed",
+	0x2:    "ContainerIndexed",
+	0x4:    "ANR",
+	0x8:    "PreserveOnDelete",
+	0x10:   "Copy",
+	0x20:   "TupleIndexed",
+	0x40:   "SubtreeIndexed",
+	0x80:   "Confidential",
+	0x100:  "NeverValueAudit",
+	0x200:  "RODCFiltered",
+	0x400:  "ExtendedLinkTracking",
+	0x800:  "BaseOnly",
+	0x1000: "PartitionSecret",
+}
+
+// Bits of groupType, for the combinations missing from GroupTypeMap
+var GroupTypeFlags = map[int]string{
+	0x1:         "BuiltinLocal",
+	0x2:         "Global",
+	0x4:         "DomainLocal",
+	0x8:         "Universal",
+	0x10:        "AppBasic",
+	0x20:        "AppQuery",
+	-0x80000000: "Security",
+}
+
+// Values of msDS-User-Account-Control-Computed
+var UacComputedFlags = map[int]string{
+	0x10:      "LockedOut",
+	0x800000:  "PwdExpired",
+	0x4000000: "PartialSecretsAccount",
+	0x8000000: "UseAESKeys",
+}
+
+// Flag and enum attributes, by their lowercase names
+var FlagAttributes = map[string]FlagAttribute{
+	"msds-supportedencryptiontypes":      {Bits: EncryptionTypeFlags},
+	"trustattributes":                    {Bits: TrustAttributeFlags},
+	"trustdirection":                     {Enum: TrustDirectionMap},
+	"trusttype":                          {Enum: TrustTypeMap},
+	"pwdproperties":                      {Bits: PwdPropertiesFlags},
+	"msds-behavior-version":              {Enum: BehaviorVersionMap},
+	"systemflags":                        {Bits: SystemFlags},
+	"searchflags":                        {Bits: SearchFlags},
+	"grouptype":                          {Bits: GroupTypeFlags},
+	"msds-user-account-control-computed": {Bits: UacComputedFlags, ReadOnly: true},
+}
+
+// FlagAttributeOf returns the flags of an attribute, if it has them
+func FlagAttributeOf(name string) (FlagAttribute, bool) {
+	flags, ok := FlagAttributes[strings.ToLower(name)]
+	return flags, ok
+}
+
+// SortedFlags returns the values of a flag table in ascending order
+// of their unsigned value, so the sign bit comes last
+func SortedFlags(flags map[int]string) []int {
+	keys := make([]int, 0, len(flags))
+	for key := range flags {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i int, j int) bool {
+		return uint32(keys[i]) < uint32(keys[j])
+	})
+
+	return keys
+}
+
+// DecodeFlags names the bits set in a value, reporting
+// the ones missing from the table in hex
+func DecodeFlags(value int, flags map[int]string) []string {
+	names := []string{}
+
+	remaining := uint32(value)
+	for _, flag := range SortedFlags(flags) {
+		if remaining&uint32(flag) != 0 {
+			names = append(names, flags[flag])
+			remaining &^= uint32(flag)
+		}
+	}
+
+	if remaining != 0 {
+		names = append(names, fmt.Sprintf("Unknown(0x%x)", remaining))
+	}
+
+	if len(names) == 0 {
+		names = append(names, "(None)")
+	}
+
+	return names
+}
+
+// Format formats a value of the attribute
+func (flags FlagAttribute) Format(value string) []string {
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		return []string{value}
+	}
+
+	if flags.Enum != nil {
+		if name, ok := flags.Enum[intValue]; ok {
+			return []string{name}
+		}
+		return []string{value}
+	}
+
+	return DecodeFlags(intValue, flags.Bits)
+}
+
+// GUIDs of the well-known containers referenced by wellKnownObjects
+var WellKnownContainerGUIDs = map[string]string{
+	"a9d1ca15768811d1aded00c04fd8d5cd": "Users",
+	"aa312825768811d1aded00c04fd8d5cd": "Computers",
+	"ab1d30f3768811d1aded00c04fd8d5cd": "System",
+	"a361b2ffffd211d1aa4b00c04fd7d83a": "Domain Controllers",
+	"2fbac1870ade11d297c400c04fd8d5cd": "Infrastructure",
+	"18e2ea80684f11d2b9aa00c04f79f805": "Deleted Objects",
+	"ab8153b7768811d1aded00c04fd8d5cd": "LostAndFound",
+	"22b70c67d56e4efb91e9300fca3dc1aa": "Foreign Security Principals",
+	"09460c08ae1e4a4ea0f64aee7daa1e5a": "Program Data",
+	"f4be92a4c777485e878e9421d53087db": "Microsoft Program Data",
+	"6227f0af1fc2410d8e3bb10615bb5b0f": "NTDS Quotas",
+	"1eb93889e40c45df9f0c64d23bbb6237": "Managed Service Accounts",
+	"683a24e2e8164bd3af86ac3c2cf3f981": "Keys",
+}
+
+// ParseDNBinary splits a DN-Binary value (B:<hex length>:<hex>:<DN>)
+func ParseDNBinary(value string) (data []byte, dn string, err error) {
+	parts := strings.SplitN(value, ":", 4)
+	if len(parts) != 4 || parts[0] != "B" {
+		return nil, "", fmt.Errorf("Invalid DN-Binary value")
+	}
+
+	data, err = hex.DecodeString(parts[2])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, parts[3], nil
+}
+
+// Formats a wellKnownObjects value as the name of the container
+// it points to, followed by its DN
+func formatWellKnownObject(value string) string {
+	data, dn, err := ParseDNBinary(value)
+	if err != nil {
+		return value
+	}
+
+	name, ok := WellKnownContainerGUIDs[hex.EncodeToString(data)]
+	if !ok {
+		name = "GUID{" + hex.EncodeToString(data) + "}"
+	}
+
+	return name + " -> " + dn
+}
+
+func init() {
+	for name, flags := range FlagAttributes {
+		flags := flags
+		RegisterAttributeFormatter(name, func(raw []byte, opts FormatOptions) []string {
+			return flags.Format(string(raw))
+		})
+	}
+
+	// groupType keeps the names of its usual combinations
+	RegisterAttributeFormatter("groupType", func(raw []byte, opts FormatOptions) []string {
+		groupTypeId, _ := strconv.Atoi(string(raw))
+		if groupType, ok := GroupTypeMap[groupTypeId]; ok {
+			return []string{groupType}
+		}
+		return DecodeFlags(groupTypeId, GroupTypeFlags)
+	})
+
+	for _, name := range []string{"wellKnownObjects", "otherWellKnownObjects"} {
+		RegisterAttributeFormatter(name, func(raw []byte, opts FormatOptions) []string {
+			return []string{formatWellKnownObject(string(raw))}
+		})
+	}
+}
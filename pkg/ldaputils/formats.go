@@ -148,9 +148,23 @@ func EncodeGUID(guid string) (string, error) {
 	return result, nil
 }
 
+// Layouts of GeneralizedTime values, with and
+// without the fraction used by AD
+var generalizedTimeLayouts = []string{"20060102150405.0Z", "20060102150405Z"}
+
+func parseGeneralizedTime(val string) (t time.Time, err error) {
+	for _, layout := range generalizedTimeLayouts {
+		t, err = time.Parse(layout, val)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return t, err
+}
+
 func FormatLDAPTime(val, format string, offset int) string {
-	layout := "20060102150405.0Z"
-	t, err := time.Parse(layout, val)
+	t, err := parseGeneralizedTime(val)
 	if err != nil {
 		return "Invalid date format"
 	}
@@ -178,98 +192,167 @@ func FormatLDAPTime2(val, format string, offset int) string {
 	return fmt.Sprintf("%s %s", t.Format(format), distString)
 }
 
-func FormatLDAPAttribute(attr *ldap.EntryAttribute, timeFormat string, timeOffset int) []string {
-	var formattedEntries = attr.Values
+// FormatOptions are the display preferences passed to formatters
+type FormatOptions struct {
+	TimeFormat string
+	TimeOffset int
+}
 
-	if len(attr.Values) == 0 {
-		return []string{"(Empty)"}
+// AttributeFormatter turns a single raw value into the
+// lines shown for it (flags may take several lines)
+type AttributeFormatter func(raw []byte, opts FormatOptions) []string
+
+// Formatters by syntax, and by attribute name (lowercase) for
+// attributes whose values need more than their syntax tells
+var (
+	syntaxFormatters    = map[AttributeSyntax]AttributeFormatter{}
+	attributeFormatters = map[string]AttributeFormatter{}
+)
+
+// RegisterSyntaxFormatter sets the formatter of the attributes of a syntax
+func RegisterSyntaxFormatter(syntax AttributeSyntax, formatter AttributeFormatter) {
+	syntaxFormatters[syntax] = formatter
+}
+
+// RegisterAttributeFormatter sets the formatter of an attribute,
+// which takes precedence over the formatter of its syntax
+func RegisterAttributeFormatter(name string, formatter AttributeFormatter) {
+	attributeFormatters[strings.ToLower(name)] = formatter
+}
+
+// FormatterOf returns the formatter of an attribute, with its syntax
+// taken from the schema of the connection, or nil if its values are
+// shown as they are
+func (lc *LDAPConn) FormatterOf(name string) AttributeFormatter {
+	if formatter, ok := attributeFormatters[strings.ToLower(name)]; ok {
+		return formatter
 	}
 
-	for idx, val := range attr.Values {
-		switch attr.Name {
-		case "objectSid":
-			formattedEntries = []string{"SID{" + ConvertSID(hex.EncodeToString(attr.ByteValues[idx])) + "}"}
-		case "objectGUID", "schemaIDGUID":
-			formattedEntries = []string{"GUID{" + ConvertGUID(hex.EncodeToString(attr.ByteValues[idx])) + "}"}
-		case "whenCreated", "whenChanged":
-			formattedEntries = []string{
-				FormatLDAPTime(val, timeFormat, timeOffset),
-			}
-		case "lastLogonTimestamp", "accountExpires", "badPasswordTime", "lastLogoff", "lastLogon", "pwdLastSet", "creationTime", "lockoutTime":
-			if val == "0" {
-				return []string{"(Never)"}
-			}
+	if formatter, ok := syntaxFormatters[lc.AttributeSyntaxOf(name)]; ok {
+		return formatter
+	}
 
-			if attr.Name == "accountExpires" && val == "9223372036854775807" {
-				return []string{"(Never Expire)"}
-			}
+	if IsCertificateAttribute(name) {
+		return formatCertificate
+	}
 
-			formattedEntries = []string{
-				FormatLDAPTime2(val, timeFormat, timeOffset),
-			}
-		case "userAccountControl":
-			uacInt, _ := strconv.Atoi(val)
+	return nil
+}
 
-			formattedEntries = []string{}
+func init() {
+	RegisterSyntaxFormatter(SyntaxSID, func(raw []byte, opts FormatOptions) []string {
+		if len(raw) < 8 {
+			return []string{"HEX{" + hex.EncodeToString(raw) + "}"}
+		}
+		return []string{"SID{" + ConvertSID(hex.EncodeToString(raw)) + "}"}
+	})
 
-			uacFlagKeys := make([]int, 0)
-			for k := range UacFlags {
-				uacFlagKeys = append(uacFlagKeys, k)
-			}
-			sort.Ints(uacFlagKeys)
-
-			for _, flag := range uacFlagKeys {
-				curFlag := UacFlags[flag]
-				if uacInt&flag != 0 {
-					if curFlag.Present != "" {
-						formattedEntries = append(formattedEntries, curFlag.Present)
-					}
-				} else {
-					if curFlag.NotPresent != "" {
-						formattedEntries = append(formattedEntries, curFlag.NotPresent)
-					}
+	RegisterSyntaxFormatter(SyntaxGUID, func(raw []byte, opts FormatOptions) []string {
+		if len(raw) != 16 {
+			return []string{"HEX{" + hex.EncodeToString(raw) + "}"}
+		}
+		return []string{"GUID{" + ConvertGUID(hex.EncodeToString(raw)) + "}"}
+	})
+
+	RegisterSyntaxFormatter(SyntaxGeneralizedTime, func(raw []byte, opts FormatOptions) []string {
+		return []string{FormatLDAPTime(string(raw), opts.TimeFormat, opts.TimeOffset)}
+	})
+
+	RegisterSyntaxFormatter(SyntaxFileTime, func(raw []byte, opts FormatOptions) []string {
+		switch string(raw) {
+		case FileTimeNever:
+			return []string{"(Never)"}
+		case FileTimeNeverLarge:
+			return []string{"(Never Expire)"}
+		}
+		return []string{FormatLDAPTime2(string(raw), opts.TimeFormat, opts.TimeOffset)}
+	})
+
+	RegisterAttributeFormatter("userAccountControl", func(raw []byte, opts FormatOptions) []string {
+		uacInt, _ := strconv.Atoi(string(raw))
+
+		uacFlagKeys := make([]int, 0)
+		for k := range UacFlags {
+			uacFlagKeys = append(uacFlagKeys, k)
+		}
+		sort.Ints(uacFlagKeys)
+
+		formattedEntries := []string{}
+		for _, flag := range uacFlagKeys {
+			curFlag := UacFlags[flag]
+			if uacInt&flag != 0 {
+				if curFlag.Present != "" {
+					formattedEntries = append(formattedEntries, curFlag.Present)
+				}
+			} else {
+				if curFlag.NotPresent != "" {
+					formattedEntries = append(formattedEntries, curFlag.NotPresent)
 				}
 			}
-		case "primaryGroupID":
-			rId, _ := strconv.Atoi(val)
+		}
 
-			groupName, ok := RidMap[rId]
+		return formattedEntries
+	})
+
+	// Attributes whose values are looked up in a table. Formatters
+	// registered elsewhere for them (such as the groupType flags)
+	// are kept, whatever order the files are initialized in.
+	lookups := map[string]map[int]string{
+		"primaryGroupID": RidMap,
+		"sAMAccountType": SAMAccountTypeMap,
+		"instanceType":   InstanceTypeMap,
+		"groupType":      GroupTypeMap,
+	}
 
-			if ok {
-				formattedEntries = []string{groupName}
+	for name, table := range lookups {
+		if _, ok := attributeFormatters[strings.ToLower(name)]; ok {
+			continue
+		}
+
+		table := table
+		RegisterAttributeFormatter(name, func(raw []byte, opts FormatOptions) []string {
+			id, _ := strconv.Atoi(string(raw))
+			if text, ok := table[id]; ok {
+				return []string{text}
 			}
-		case "sAMAccountType":
-			sAMAccountTypeId, _ := strconv.Atoi(val)
+			return []string{string(raw)}
+		})
+	}
 
-			accountType, ok := SAMAccountTypeMap[sAMAccountTypeId]
+	RegisterAttributeFormatter("logonHours", func(raw []byte, opts FormatOptions) []string {
+		return FormatLogonHours(raw, opts.TimeOffset)
+	})
+}
 
-			if ok {
-				formattedEntries = []string{accountType}
-			}
-		case "groupType":
-			groupTypeId, _ := strconv.Atoi(val)
-			groupType, ok := GroupTypeMap[groupTypeId]
+// FormatLDAPAttribute formats the values of an attribute with the formatter
+// registered for its name or its syntax, or returns them as they are
+func (lc *LDAPConn) FormatLDAPAttribute(attr *ldap.EntryAttribute, timeFormat string, timeOffset int) []string {
+	if len(attr.Values) == 0 {
+		return []string{"(Empty)"}
+	}
 
-			if ok {
-				formattedEntries = []string{groupType}
-			}
-		case "instanceType":
-			instanceTypeId, _ := strconv.Atoi(val)
-			instanceType, ok := InstanceTypeMap[instanceTypeId]
+	formatter := lc.FormatterOf(attr.Name)
+	if formatter == nil {
+		return attr.Values
+	}
 
-			if ok {
-				formattedEntries = []string{instanceType}
-			}
-		case "logonHours":
-			if len(attr.ByteValues[idx]) != 21 {
-				formattedEntries = []string{"(Invalid logonHours length)"}
-			} else {
-				formattedEntries = []string{"HEX{" + hex.EncodeToString(attr.ByteValues[idx]) + "}"}
-			}
-		default:
-			formattedEntries = attr.Values
-		}
+	opts := FormatOptions{TimeFormat: timeFormat, TimeOffset: timeOffset}
+
+	var formattedEntries []string
+	for _, raw := range attr.ByteValues {
+		formattedEntries = append(formattedEntries, formatter(raw, opts)...)
 	}
 
 	return formattedEntries
 }
+
+// FormatEntry formats every attribute of an entry, for exports
+// and other outputs that show values the way the explorer does
+func (lc *LDAPConn) FormatEntry(entry *ldap.Entry, timeFormat string, timeOffset int) map[string][]string {
+	formatted := make(map[string][]string, len(entry.Attributes))
+	for _, attr := range entry.Attributes {
+		formatted[attr.Name] = lc.FormatLDAPAttribute(attr, timeFormat, timeOffset)
+	}
+
+	return formatted
+}
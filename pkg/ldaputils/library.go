@@ -0,0 +1,232 @@
+package ldaputils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// LibraryQuery is a query of the search library, which can
+// come from the predefined lists or from a library file
+type LibraryQuery struct {
+	LibQuery `yaml:",inline"`
+
+	Category    string       `yaml:"category" json:"category"`
+	Description string       `yaml:"description" json:"description"`
+	Flavor      string       `yaml:"flavor" json:"flavor"`
+	Attributes  []string     `yaml:"attributes" json:"attributes"`
+	Params      []QueryParam `yaml:"params" json:"params"`
+}
+
+// QueryParam is a {name} placeholder of a library
+// query whose value is asked before running it
+type QueryParam struct {
+	Name    string `yaml:"name" json:"name"`
+	Prompt  string `yaml:"prompt" json:"prompt"`
+	Default string `yaml:"default" json:"default"`
+
+	// How the value is inserted in the filter:
+	// "text" (escaped, the default), "raw",
+	// "filetime-days" or "gentime-days" (days ago)
+	Type string `yaml:"type" json:"type"`
+}
+
+// Layout of a library file
+type queryLibraryFile struct {
+	Name    string         `yaml:"name" json:"name"`
+	Flavor  string         `yaml:"flavor" json:"flavor"`
+	Queries []LibraryQuery `yaml:"queries" json:"queries"`
+}
+
+// LoadQueryLibrary reads the queries of a YAML or JSON library
+// file. Queries without a category are listed under the name of
+// the library, and inherit its flavor if they don't have one.
+func LoadQueryLibrary(path string) ([]LibraryQuery, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var library queryLibraryFile
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &library)
+	} else {
+		err = yaml.Unmarshal(data, &library)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if library.Name == "" {
+		library.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	for idx := range library.Queries {
+		query := &library.Queries[idx]
+		if query.Title == "" || query.Filter == "" {
+			return nil, fmt.Errorf("%s: query %d is missing its title or filter", path, idx+1)
+		}
+
+		if query.Category == "" {
+			query.Category = library.Name
+		}
+
+		if query.Flavor == "" {
+			query.Flavor = library.Flavor
+		}
+	}
+
+	return library.Queries, nil
+}
+
+// LoadQueryLibraries loads every library in the given paths, which can
+// be files or directories of .yaml, .yml and .json files. Libraries
+// that can't be loaded are skipped, and their errors returned together.
+func LoadQueryLibraries(paths []string) ([]LibraryQuery, error) {
+	var queries []LibraryQuery
+	var errs []error
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			files = nil
+			for _, entry := range entries {
+				ext := strings.ToLower(filepath.Ext(entry.Name()))
+				if !entry.IsDir() && slices.Contains([]string{".yaml", ".yml", ".json"}, ext) {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+
+		for _, file := range files {
+			libQueries, err := LoadQueryLibrary(file)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			queries = append(queries, libQueries...)
+		}
+	}
+
+	return queries, errors.Join(errs...)
+}
+
+// MatchesFlavor reports whether the query can be used with the given server flavor
+func (query LibraryQuery) MatchesFlavor(flavor LDAPFlavor) bool {
+	switch strings.ToLower(query.Flavor) {
+	case "msad":
+		return flavor == MicrosoftADFlavor
+	case "basic":
+		return flavor == BasicLDAPFlavor
+	}
+
+	return true
+}
+
+// Builtin placeholders, with the attribute they're compared with if any
+var placeholderRegexp = regexp.MustCompile(`(?:([\w;-]+)(>=|<=|~=|=))?<(timestamp|gentime)(\d+d)?>`)
+
+// Converts a time into the FILETIME format used by AD
+// (100-nanosecond intervals since January 1, 1601)
+func toFileTime(t time.Time) int64 {
+	return (t.Unix() + 11644473600) * 10000000
+}
+
+// Whole seconds, since the ".0" of the generalized
+// time layout would print tenths of a second otherwise
+func daysAgo(days int) time.Time {
+	return time.Now().Add(-time.Duration(days) * 24 * time.Hour).Truncate(time.Second)
+}
+
+// Replaces the builtin placeholders of a library filter: the example
+// root DN "DC=domain,DC=com", <timestamp> & <timestampNd> (FILETIME now
+// or N days ago), and <gentime> & <gentimeNd> (generalized time).
+// Timestamps compared with generalized time attributes of the built-in
+// table are taken as <gentime>, since older filters only had <timestamp>
+// placeholders (and they're expanded the same way on every server).
+func expandBuiltinPlaceholders(filter string, rootDN string) string {
+	filter = strings.ReplaceAll(filter, "DC=domain,DC=com", rootDN)
+
+	return placeholderRegexp.ReplaceAllStringFunc(filter, func(match string) string {
+		groups := placeholderRegexp.FindStringSubmatch(match)
+		attr, op, kind := groups[1], groups[2], groups[3]
+
+		days, _ := strconv.Atoi(strings.TrimSuffix(groups[4], "d"))
+		moment := daysAgo(days)
+
+		if kind == "timestamp" && (attr == "" || guessAttributeSchema(attr).Syntax != SyntaxGeneralizedTime) {
+			return attr + op + strconv.FormatInt(toFileTime(moment), 10)
+		}
+
+		return attr + op + moment.UTC().Format("20060102150405.0Z")
+	})
+}
+
+// Formats the value given to a parameter according to its type
+func (param QueryParam) format(value string) (string, error) {
+	switch param.Type {
+	case "", "text":
+		return ldap.EscapeFilter(value), nil
+	case "raw":
+		return value, nil
+	case "filetime-days", "gentime-days":
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("%s: expected a number of days", param.Name)
+		}
+
+		if param.Type == "filetime-days" {
+			return strconv.FormatInt(toFileTime(daysAgo(days)), 10), nil
+		}
+
+		return daysAgo(days).UTC().Format("20060102150405.0Z"), nil
+	}
+
+	return "", fmt.Errorf("%s: unknown parameter type '%s'", param.Name, param.Type)
+}
+
+// Expand returns the filter of the query for the given root DN,
+// with its parameters replaced by the values provided
+func (query LibraryQuery) Expand(rootDN string, values map[string]string) (string, error) {
+	filter := expandBuiltinPlaceholders(query.Filter, rootDN)
+
+	for _, param := range query.Params {
+		value, ok := values[param.Name]
+		if !ok {
+			value = param.Default
+		}
+
+		formatted, err := param.format(value)
+		if err != nil {
+			return "", err
+		}
+
+		filter = strings.ReplaceAll(filter, "{"+param.Name+"}", formatted)
+	}
+
+	return filter, nil
+}
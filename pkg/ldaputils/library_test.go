@@ -0,0 +1,169 @@
+package ldaputils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoadQueryLibraries(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"kerberos.yaml": "name: Kerberos\n" +
+			"flavor: msad\n" +
+			"queries:\n" +
+			"  - title: Roastable Users\n" +
+			"    filter: (&(objectCategory=user)(servicePrincipalName=*))\n" +
+			"    attributes: [sAMAccountName, servicePrincipalName]\n" +
+			"  - title: Users by Name\n" +
+			"    category: Users\n" +
+			"    flavor: basic\n" +
+			"    filter: (cn={name})\n" +
+			"    params:\n" +
+			"      - name: name\n" +
+			"        default: admin\n",
+		"computers.json": `{"queries": [{"title": "Stale Computers", "filter": "(&(objectCategory=computer)(lastLogonTimestamp<=<timestamp90d>))"}]}`,
+		"broken.yml":     "queries:\n  - title: [unterminated\n",
+		"untitled.yaml":  "queries:\n  - filter: (cn=*)\n",
+		"notes.txt":      "not a library",
+	}
+
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queries, err := LoadQueryLibraries([]string{dir, filepath.Join(dir, "missing.yaml")})
+
+	// The broken and untitled libraries and the missing path
+	if err == nil {
+		t.Errorf("invalid libraries not reported")
+	}
+
+	byTitle := make(map[string]LibraryQuery)
+	for _, query := range queries {
+		byTitle[query.Title] = query
+	}
+
+	if len(queries) != 3 {
+		t.Fatalf("got %d queries, expected 3: %+v", len(queries), queries)
+	}
+
+	tests := []struct {
+		title    string
+		category string
+		flavor   string
+	}{
+		{"Roastable Users", "Kerberos", "msad"},
+		{"Users by Name", "Users", "basic"},
+		{"Stale Computers", "computers", ""},
+	}
+
+	for _, test := range tests {
+		query, ok := byTitle[test.title]
+		if !ok {
+			t.Errorf("%s: not loaded", test.title)
+			continue
+		}
+
+		if query.Category != test.category || query.Flavor != test.flavor {
+			t.Errorf("%s: got category %q and flavor %q", test.title, query.Category, query.Flavor)
+		}
+	}
+
+	if attrs := byTitle["Roastable Users"].Attributes; len(attrs) != 2 {
+		t.Errorf("got attributes %v", attrs)
+	}
+
+	if !byTitle["Roastable Users"].MatchesFlavor(MicrosoftADFlavor) || byTitle["Roastable Users"].MatchesFlavor(BasicLDAPFlavor) {
+		t.Errorf("msad query matched the wrong flavors")
+	}
+
+	if !byTitle["Stale Computers"].MatchesFlavor(BasicLDAPFlavor) {
+		t.Errorf("query without a flavor didn't match")
+	}
+}
+
+// Reads the time of an expanded FILETIME or generalized time value
+func parseExpandedTime(value string) (time.Time, error) {
+	if fileTime, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(fileTime/10000000-11644473600, 0), nil
+	}
+
+	return parseGeneralizedTime(value)
+}
+
+func TestExpandBuiltinPlaceholders(t *testing.T) {
+	fileTime := `^\d{18}$`
+	genTime := `^\d{14}\.0Z$`
+
+	tests := []struct {
+		filter string
+		prefix string
+		layout string
+		days   int
+	}{
+		{"(accountExpires<=<timestamp>)", "(accountExpires<=", fileTime, 0},
+		{"(lastLogonTimestamp<=<timestamp30d>)", "(lastLogonTimestamp<=", fileTime, 30},
+		{"(whenCreated>=<timestamp1d>)", "(whenCreated>=", genTime, 1},
+		{"(whenChanged>=<gentime>)", "(whenChanged>=", genTime, 0},
+		{"(modifyTimestamp>=<gentime7d>)", "(modifyTimestamp>=", genTime, 7},
+	}
+
+	for _, test := range tests {
+		expanded := expandBuiltinPlaceholders(test.filter, "DC=corp,DC=local")
+
+		value := expanded[len(test.prefix) : len(expanded)-1]
+		if expanded[:len(test.prefix)] != test.prefix || !regexp.MustCompile(test.layout).MatchString(value) {
+			t.Errorf("%s: got %s", test.filter, expanded)
+			continue
+		}
+
+		moment, err := parseExpandedTime(value)
+		expected := time.Now().Add(-time.Duration(test.days) * 24 * time.Hour)
+		if err != nil || moment.Sub(expected).Abs() > time.Minute {
+			t.Errorf("%s: got %s (%v), expected about %s", test.filter, expanded, err, expected)
+		}
+	}
+
+	if expanded := expandBuiltinPlaceholders("(memberOf=CN=Admins,DC=domain,DC=com)", "DC=corp,DC=local"); expanded != "(memberOf=CN=Admins,DC=corp,DC=local)" {
+		t.Errorf("got root DN %s", expanded)
+	}
+}
+
+func TestLibraryQueryExpand(t *testing.T) {
+	query := LibraryQuery{
+		LibQuery: LibQuery{Title: "Test", Filter: "(&(cn={name})(description={raw})(pwdLastSet<={age}))"},
+		Params: []QueryParam{
+			{Name: "name", Default: "a*b"},
+			{Name: "raw", Type: "raw", Default: "*"},
+			{Name: "age", Type: "filetime-days", Default: "90"},
+		},
+	}
+
+	filter, err := query.Expand("DC=corp,DC=local", map[string]string{"name": "j(ohn)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !regexp.MustCompile(`^\(&\(cn=j\\28ohn\\29\)\(description=\*\)\(pwdLastSet<=\d{18}\)\)$`).MatchString(filter) {
+		t.Errorf("got filter %s", filter)
+	}
+
+	invalid := map[string]QueryParam{
+		"not a number": {Name: "age", Type: "filetime-days"},
+		"7":            {Name: "age", Type: "weeks"},
+	}
+
+	for value, param := range invalid {
+		query.Params = []QueryParam{param}
+		if _, err := query.Expand("DC=corp,DC=local", map[string]string{"age": value}); err == nil {
+			t.Errorf("%s parameter accepted %q", param.Type, value)
+		}
+	}
+}
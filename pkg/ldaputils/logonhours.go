@@ -0,0 +1,104 @@
+package ldaputils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// logonHours holds one bit per hour of the week (21 bytes), starting
+// on Sunday 00:00 UTC, with the lowest bit of each byte first
+const LogonHoursSize = 21
+
+var WeekDays = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// LogonHours is a week of hours in the time zone it was read in,
+// indexed by day (from Sunday) and hour
+type LogonHours [7][24]bool
+
+// Index of the bit of an hour of the week in local time,
+// rotated by the offset of the time zone to UTC
+func logonHoursBit(day int, hour int, offset int) int {
+	return ((day*24+hour-offset)%168 + 168) % 168
+}
+
+// DecodeLogonHours reads a logonHours value into a week shifted
+// by offset hours from UTC
+func DecodeLogonHours(raw []byte, offset int) (LogonHours, error) {
+	var hours LogonHours
+	if len(raw) != LogonHoursSize {
+		return hours, fmt.Errorf("Invalid logonHours length (%d bytes)", len(raw))
+	}
+
+	for day := 0; day < 7; day++ {
+		for hour := 0; hour < 24; hour++ {
+			bit := logonHoursBit(day, hour, offset)
+			hours[day][hour] = raw[bit/8]&(1<<(bit%8)) != 0
+		}
+	}
+
+	return hours, nil
+}
+
+// Encode returns the logonHours value of a week shifted
+// by offset hours from UTC
+func (hours LogonHours) Encode(offset int) []byte {
+	raw := make([]byte, LogonHoursSize)
+	for day := 0; day < 7; day++ {
+		for hour := 0; hour < 24; hour++ {
+			if hours[day][hour] {
+				bit := logonHoursBit(day, hour, offset)
+				raw[bit/8] |= 1 << (bit % 8)
+			}
+		}
+	}
+
+	return raw
+}
+
+// Count returns the number of allowed hours
+func (hours LogonHours) Count() int {
+	count := 0
+	for day := range hours {
+		for hour := range hours[day] {
+			if hours[day][hour] {
+				count += 1
+			}
+		}
+	}
+
+	return count
+}
+
+// FormatLogonHours shows a logonHours value as a grid with
+// one line per day and one character per hour
+func FormatLogonHours(raw []byte, offset int) []string {
+	hours, err := DecodeLogonHours(raw, offset)
+	if err != nil {
+		return []string{"(" + err.Error() + ")"}
+	}
+
+	switch hours.Count() {
+	case 0:
+		return []string{"(Never allowed)"}
+	case 168:
+		return []string{"(Always allowed)"}
+	}
+
+	lines := []string{fmt.Sprintf("    0     6     12    18    (UTC%+d)", offset)}
+	for day, dayHours := range hours {
+		var line strings.Builder
+		line.WriteString(WeekDays[day] + " ")
+
+		for _, allowed := range dayHours {
+			if allowed {
+				line.WriteString("█")
+			} else {
+				line.WriteString("·")
+			}
+		}
+
+		lines = append(lines, line.String())
+	}
+
+	return lines
+}
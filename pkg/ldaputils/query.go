@@ -0,0 +1,219 @@
+package ldaputils
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// QueryProgress reports how much of a paged query was received so far
+type QueryProgress struct {
+	Pages   int
+	Entries int
+}
+
+// QueryOptions select what the entries returned by a query contain
+type QueryOptions struct {
+	// Attributes to request (all user attributes if empty)
+	Attributes  []string
+	ShowDeleted bool
+
+	// Maximum number of entries and seconds the server
+	// should spend on the search (0 for no limit)
+	SizeLimit int
+	TimeLimit int
+}
+
+// Partial reports whether entries returned with these
+// options may be missing some of their user attributes
+func (opts QueryOptions) Partial() bool {
+	return len(opts.Attributes) > 0 && !slices.Contains(opts.Attributes, "*")
+}
+
+// Operational and constructed attributes, which
+// are only returned when requested explicitly
+var OperationalAttributesAD = []string{
+	"createTimestamp",
+	"modifyTimestamp",
+	"canonicalName",
+	"allowedAttributesEffective",
+	"allowedChildClassesEffective",
+	"sDRightsEffective",
+	"msDS-User-Account-Control-Computed",
+	"msDS-PrincipalName",
+	"tokenGroups",
+}
+
+var OperationalAttributesBasic = []string{
+	"createTimestamp",
+	"modifyTimestamp",
+	"creatorsName",
+	"modifiersName",
+	"entryUUID",
+	"entryDN",
+	"hasSubordinates",
+	"structuralObjectClass",
+	"subschemaSubentry",
+}
+
+// Constructed attributes that AD only computes for base searches
+var BaseScopeOnlyAttributes = []string{
+	"tokenGroups",
+	"tokenGroupsGlobalAndUniversal",
+	"tokenGroupsNoGCAcceptable",
+}
+
+// IsLimitExceeded reports whether a search stopped early
+// because it reached its size or time limit
+func IsLimitExceeded(err error) bool {
+	return ldap.IsErrorAnyOf(err, ldap.LDAPResultSizeLimitExceeded, ldap.LDAPResultTimeLimitExceeded)
+}
+
+// ParseAttributeList splits a list of attributes separated by commas or spaces
+func ParseAttributeList(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// PageHandler is called with each page of results as soon as it arrives
+type PageHandler func(entries []*ldap.Entry, progress QueryProgress)
+
+// Runs a single page of a search, stopping as soon as ctx is done.
+// The library only stops reading the results at that point, so
+// the search is also abandoned for the server to stop sending them.
+func searchPage(ctx context.Context, conn *ldap.Conn, tracker *messageTracker, req *ldap.SearchRequest) ([]*ldap.Entry, []ldap.Control, error) {
+	var entries []*ldap.Entry
+	var controls []ldap.Control
+
+	search := tracker.track(req)
+	defer tracker.untrack(search)
+
+	response := conn.SearchAsync(ctx, req, 64)
+	for response.Next() {
+		if entry := response.Entry(); entry != nil {
+			entries = append(entries, entry)
+		}
+
+		if respControls := response.Controls(); len(respControls) > 0 {
+			controls = respControls
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		tracker.abandon(search)
+		return entries, controls, err
+	}
+
+	return entries, controls, response.Err()
+}
+
+// streamPages runs a paged search, delivering each page to onPage as it
+// arrives without keeping the results. If ctx is cancelled the server-side
+// result set is abandoned with a zero-sized paging request (RFC 2696).
+func (lc *LDAPConn) streamPages(ctx context.Context, req *ldap.SearchRequest, onPage PageHandler) (QueryProgress, error) {
+	pagingControl := ldap.NewControlPaging(lc.PagingSize)
+
+	pageReq := *req
+	pageReq.Controls = append(append([]ldap.Control{}, req.Controls...), pagingControl)
+
+	var progress QueryProgress
+
+	conn, tracker := lc.connTracker()
+	for {
+		entries, controls, err := searchPage(ctx, conn, tracker, &pageReq)
+
+		// The first page is retried after reconnecting, but
+		// later ones can't be since the cookie is per-connection
+		if IsNetworkError(err) && progress.Pages == 0 && lc.params != nil {
+			if reconnErr := lc.reconnectFrom(conn); reconnErr != nil {
+				return progress, reconnErr
+			}
+
+			conn, tracker = lc.connTracker()
+			entries, controls, err = searchPage(ctx, conn, tracker, &pageReq)
+		}
+
+		if len(entries) > 0 || err == nil {
+			progress.Pages += 1
+			progress.Entries += len(entries)
+			onPage(entries, progress)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil && len(pagingControl.Cookie) > 0 {
+				abandonPaging(conn, &pageReq, pagingControl)
+			}
+
+			return progress, err
+		}
+
+		pagingResult, ok := ldap.FindControl(controls, ldap.ControlTypePaging).(*ldap.ControlPaging)
+		if !ok || len(pagingResult.Cookie) == 0 {
+			return progress, nil
+		}
+
+		pagingControl.SetCookie(pagingResult.Cookie)
+
+		if err := ctx.Err(); err != nil {
+			abandonPaging(conn, &pageReq, pagingControl)
+			return progress, err
+		}
+	}
+}
+
+// searchPages is like streamPages, but also collects every entry
+// received, returning them even if the search was interrupted
+func (lc *LDAPConn) searchPages(ctx context.Context, req *ldap.SearchRequest, onPage PageHandler) ([]*ldap.Entry, error) {
+	var allEntries []*ldap.Entry
+
+	_, err := lc.streamPages(ctx, req, func(entries []*ldap.Entry, progress QueryProgress) {
+		allEntries = append(allEntries, entries...)
+		if onPage != nil {
+			onPage(entries, progress)
+		}
+	})
+
+	return allEntries, err
+}
+
+// Releases the server-side state of an unfinished paged search
+func abandonPaging(conn *ldap.Conn, req *ldap.SearchRequest, pagingControl *ldap.ControlPaging) {
+	pagingControl.PagingSize = 0
+	conn.Search(req)
+}
+
+// Builds the request shared by Query and its variants
+func queryRequest(baseDN string, searchFilter string, scope int, opts QueryOptions) *ldap.SearchRequest {
+	var controls []ldap.Control = nil
+	if opts.ShowDeleted {
+		controls = []ldap.Control{ldap.NewControlMicrosoftShowDeleted()}
+	}
+
+	return ldap.NewSearchRequest(
+		baseDN,
+		scope, ldap.NeverDerefAliases, opts.SizeLimit, opts.TimeLimit, false,
+		searchFilter,
+		append([]string{}, opts.Attributes...),
+		controls,
+	)
+}
+
+// QueryContext is like Query, but stops when ctx is done and
+// reports each page of results to onPage (which may be nil)
+func (lc *LDAPConn) QueryContext(ctx context.Context, baseDN string, searchFilter string, scope int, showDeleted bool, onPage PageHandler) ([]*ldap.Entry, error) {
+	return lc.searchPages(ctx, queryRequest(baseDN, searchFilter, scope, QueryOptions{ShowDeleted: showDeleted}), onPage)
+}
+
+// QueryWithOptions is like Query, but requests only the attributes in opts
+func (lc *LDAPConn) QueryWithOptions(baseDN string, searchFilter string, scope int, opts QueryOptions) ([]*ldap.Entry, error) {
+	return lc.searchPages(context.Background(), queryRequest(baseDN, searchFilter, scope, opts), nil)
+}
+
+// QueryPages streams the results of a query to onPage as each page
+// arrives, without keeping them in memory, and returns the final progress
+func (lc *LDAPConn) QueryPages(ctx context.Context, baseDN string, searchFilter string, scope int, opts QueryOptions, onPage PageHandler) (QueryProgress, error) {
+	return lc.streamPages(ctx, queryRequest(baseDN, searchFilter, scope, opts), onPage)
+}
@@ -0,0 +1,213 @@
+package ldaputils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Control that also returns the objects already recycled when
+// the Recycle Bin is enabled (LDAP_SERVER_SHOW_RECYCLED_OID)
+const ControlTypeShowRecycled = "1.2.840.113556.1.4.2064"
+
+// Well-known GUID of the Deleted Objects container of a naming context
+const deletedObjectsGUID = "18e2ea80684f11d2b9aa00c04f79f805"
+
+// Parent of the Recycle Bin optional feature in the configuration partition
+const optionalFeaturesRDNs = "CN=Optional Features,CN=Directory Service,CN=Windows NT,CN=Services"
+
+// Marker that AD adds to the RDN of deleted objects
+const deletedRDNMarker = "\\0ADEL:"
+
+// DeletedObject is a deleted (tombstoned or recycled)
+// object kept in a Deleted Objects container
+type DeletedObject struct {
+	DN              string
+	Name            string
+	RDNType         string
+	ObjectClass     string
+	LastKnownParent string
+	WhenDeleted     time.Time
+	Recycled        bool
+}
+
+// DeletedObjectFromEntry reads a deleted object from an entry
+// returned with the show deleted control. Its deletion time is the
+// last change of isDeleted in msDS-ReplAttributeMetaData, since later
+// changes (such as the object being recycled) also update whenChanged,
+// which is only used when the metadata wasn't returned.
+func DeletedObjectFromEntry(entry *ldap.Entry) DeletedObject {
+	obj := DeletedObject{
+		DN:              entry.DN,
+		Name:            entry.GetEqualFoldAttributeValue("msDS-LastKnownRDN"),
+		RDNType:         "CN",
+		LastKnownParent: entry.GetEqualFoldAttributeValue("lastKnownParent"),
+		Recycled:        strings.EqualFold(entry.GetEqualFoldAttributeValue("isRecycled"), "TRUE"),
+	}
+
+	if parsedDN, err := ldap.ParseDN(entry.DN); err == nil && len(parsedDN.RDNs) > 0 {
+		rdn := parsedDN.RDNs[0].Attributes[0]
+		obj.RDNType = rdn.Type
+
+		// Tombstones of older servers don't keep msDS-LastKnownRDN
+		if obj.Name == "" {
+			obj.Name, _, _ = strings.Cut(rdn.Value, "\nDEL:")
+		}
+	}
+
+	if classes := entry.GetEqualFoldAttributeValues("objectClass"); len(classes) > 0 {
+		obj.ObjectClass = classes[len(classes)-1]
+	}
+
+	for _, value := range entry.GetEqualFoldAttributeValues("msDS-ReplAttributeMetaData") {
+		change, err := ParseReplMetaDataXML(value)
+		if err == nil && strings.EqualFold(change.Attribute, "isDeleted") {
+			obj.WhenDeleted = change.Time
+			break
+		}
+	}
+
+	if obj.WhenDeleted.IsZero() {
+		if whenChanged, err := parseGeneralizedTime(entry.GetEqualFoldAttributeValue("whenChanged")); err == nil {
+			obj.WhenDeleted = whenChanged
+		}
+	}
+
+	return obj
+}
+
+// OriginalDN returns the DN the object had before it was deleted
+func (obj DeletedObject) OriginalDN() string {
+	if obj.LastKnownParent == "" {
+		return ""
+	}
+
+	return obj.RDNType + "=" + ldap.EscapeDN(obj.Name) + "," + obj.LastKnownParent
+}
+
+// ParentDeleted reports whether the last known parent of the object
+// was deleted as well, in which case it must be restored first
+func (obj DeletedObject) ParentDeleted() bool {
+	return strings.Contains(strings.ToUpper(obj.LastKnownParent), deletedRDNMarker)
+}
+
+// DeletedObjectsContainers returns the Deleted Objects container
+// of each naming context that has one
+func (lc *LDAPConn) DeletedObjectsContainers() ([]string, error) {
+	namingContexts, err := lc.FindNamingContexts()
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []string
+	for _, namingContext := range namingContexts {
+		entries, err := lc.QueryWithOptions(
+			"<WKGUID="+deletedObjectsGUID+","+namingContext+">",
+			"(objectClass=*)", ldap.ScopeBaseObject,
+			QueryOptions{Attributes: []string{"distinguishedName"}, ShowDeleted: true},
+		)
+		if err == nil && len(entries) == 1 {
+			containers = append(containers, entries[0].DN)
+		}
+	}
+
+	return containers, nil
+}
+
+// QueryDeletedObjects returns the objects in the Deleted Objects container
+// of every naming context, from the most recently deleted to the oldest
+func (lc *LDAPConn) QueryDeletedObjects(ctx context.Context, onPage PageHandler) ([]DeletedObject, error) {
+	containers, err := lc.DeletedObjectsContainers()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("No Deleted Objects containers found")
+	}
+
+	controls := []ldap.Control{ldap.NewControlMicrosoftShowDeleted()}
+	if lc.SupportsControl(ControlTypeShowRecycled) {
+		controls = append(controls, ldap.NewControlString(ControlTypeShowRecycled, false, ""))
+	}
+
+	var objects []DeletedObject
+	for _, container := range containers {
+		req := ldap.NewSearchRequest(
+			container,
+			ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 0, 0, false,
+			"(isDeleted=TRUE)",
+			[]string{"msDS-LastKnownRDN", "lastKnownParent", "msDS-ReplAttributeMetaData", "whenChanged", "isRecycled", "objectClass"},
+			controls,
+		)
+
+		entries, err := lc.searchPages(ctx, req, onPage)
+		for _, entry := range entries {
+			objects = append(objects, DeletedObjectFromEntry(entry))
+		}
+
+		if err != nil {
+			return objects, err
+		}
+	}
+
+	sort.SliceStable(objects, func(i int, j int) bool {
+		return objects[i].WhenDeleted.After(objects[j].WhenDeleted)
+	})
+
+	return objects, nil
+}
+
+// RecycleBinEnabled reports whether the Recycle Bin optional
+// feature was enabled in the forest
+func (lc *LDAPConn) RecycleBinEnabled() (bool, error) {
+	rootDSE, err := lc.QueryWithOptions("", "(objectClass=*)", ldap.ScopeBaseObject, QueryOptions{
+		Attributes: []string{"configurationNamingContext"},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(rootDSE) != 1 {
+		return false, fmt.Errorf("RootDSE not found")
+	}
+
+	configDN := rootDSE[0].GetAttributeValue("configurationNamingContext")
+	if configDN == "" {
+		return false, fmt.Errorf("Configuration partition not found")
+	}
+
+	features, err := lc.QueryWithOptions(
+		optionalFeaturesRDNs+","+configDN,
+		"(&(objectClass=msDS-OptionalFeature)(cn=Recycle Bin Feature))",
+		ldap.ScopeSingleLevel,
+		QueryOptions{Attributes: []string{"msDS-EnabledFeatureBL"}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	for _, feature := range features {
+		if len(feature.GetAttributeValues("msDS-EnabledFeatureBL")) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// RestoreDeletedObject brings a deleted object back by removing its
+// isDeleted attribute and moving it to newDN in a single request,
+// which requires the show deleted control. Objects restored from the
+// Recycle Bin keep their attributes, but tombstones only keep a few.
+func (lc *LDAPConn) RestoreDeletedObject(deletedDN string, newDN string) error {
+	modifyRequest := ldap.NewModifyRequest(deletedDN, []ldap.Control{ldap.NewControlMicrosoftShowDeleted()})
+	modifyRequest.Delete("isDeleted", nil)
+	modifyRequest.Replace("distinguishedName", []string{newDN})
+
+	return lc.modify(modifyRequest)
+}
@@ -0,0 +1,57 @@
+package ldaputils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestDeletedObjectFromEntry(t *testing.T) {
+	entry := ldap.NewEntry(
+		"CN=Smith\\, John\\0ADEL:5d2b0c4e-6f3a-4b1c-9d8e-7a6b5c4d3e2f,CN=Deleted Objects,DC=corp,DC=local",
+		map[string][]string{
+			"objectClass":       {"top", "person", "organizationalPerson", "user"},
+			"msDS-LastKnownRDN": {"Smith, John"},
+			"lastKnownParent":   {"OU=Sales,DC=corp,DC=local"},
+			"msDS-ReplAttributeMetaData": {
+				"<DS_REPL_ATTR_META_DATA>\n" +
+					"\t<pszAttributeName>isDeleted</pszAttributeName>\n" +
+					"\t<dwVersion>1</dwVersion>\n" +
+					"\t<ftimeLastOriginatingChange>2024-01-15T08:30:00Z</ftimeLastOriginatingChange>\n" +
+					"</DS_REPL_ATTR_META_DATA>\n\x00",
+			},
+			// Updated again when the object was recycled
+			"whenChanged": {"20240201120000.0Z"},
+			"isRecycled":  {"TRUE"},
+		},
+	)
+
+	obj := DeletedObjectFromEntry(entry)
+	if obj.Name != "Smith, John" || obj.ObjectClass != "user" || !obj.Recycled ||
+		!obj.WhenDeleted.Equal(time.Date(2024, 1, 15, 8, 30, 0, 0, time.UTC)) {
+		t.Errorf("unexpected deleted object: %+v", obj)
+	}
+
+	if dn := obj.OriginalDN(); dn != "CN=Smith\\, John,OU=Sales,DC=corp,DC=local" {
+		t.Errorf("got original DN %q", dn)
+	}
+
+	if obj.ParentDeleted() {
+		t.Errorf("parent reported as deleted")
+	}
+
+	// Tombstones without msDS-LastKnownRDN, under a deleted OU
+	tombstone := DeletedObjectFromEntry(ldap.NewEntry(
+		"OU=Temp\\0ADEL:0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0,CN=Deleted Objects,DC=corp,DC=local",
+		map[string][]string{
+			"lastKnownParent": {"OU=Old\\0ADEL:1a2b3c4d-5e6f-7081-92a3-b4c5d6e7f809,CN=Deleted Objects,DC=corp,DC=local"},
+			"whenChanged":     {"20240201120000.0Z"},
+		},
+	))
+
+	if tombstone.Name != "Temp" || tombstone.RDNType != "OU" || tombstone.Recycled || !tombstone.ParentDeleted() ||
+		!tombstone.WhenDeleted.Equal(time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected tombstone: %+v", tombstone)
+	}
+}
@@ -0,0 +1,181 @@
+package ldaputils
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Syntax of DN-valued attributes outside of AD
+const ldapSyntaxDN = "1.3.6.1.4.1.1466.115.121.1.12"
+
+// Number of attributes searched at once by QueryReferencesPages,
+// to keep the filters within the limits of the servers
+const referenceFilterSize = 64
+
+// DNAttributes returns the DN-valued attributes that can be searched,
+// read from the schema. On AD, constructed attributes and back links
+// (such as memberOf) are left out, since their values are computed
+// from the forward links of the object itself.
+func (lc *LDAPConn) DNAttributes() ([]string, error) {
+	lc.cacheLock.Lock()
+	dnAttributes := lc.dnAttributes
+	lc.cacheLock.Unlock()
+
+	if dnAttributes != nil {
+		return dnAttributes, nil
+	}
+
+	rootDSE, err := lc.QueryWithOptions("", "(objectClass=*)", ldap.ScopeBaseObject, QueryOptions{
+		Attributes: []string{"schemaNamingContext", "subschemaSubentry"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rootDSE) != 1 {
+		return nil, fmt.Errorf("RootDSE not found")
+	}
+
+	var attributes []string
+	if schemaDN := rootDSE[0].GetAttributeValue("schemaNamingContext"); schemaDN != "" {
+		entries, err := lc.QueryWithOptions(
+			schemaDN,
+			"(&(objectClass=attributeSchema)(attributeSyntax=2.5.5.1))",
+			ldap.ScopeSingleLevel,
+			QueryOptions{Attributes: []string{"lDAPDisplayName", "linkID", "systemFlags"}},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			systemFlags, _ := strconv.Atoi(entry.GetAttributeValue("systemFlags"))
+			linkID, _ := strconv.Atoi(entry.GetAttributeValue("linkID"))
+			if systemFlags&0x4 != 0 || linkID%2 != 0 {
+				continue
+			}
+
+			attributes = append(attributes, entry.GetAttributeValue("lDAPDisplayName"))
+		}
+	} else if subschemaDN := rootDSE[0].GetAttributeValue("subschemaSubentry"); subschemaDN != "" {
+		entries, err := lc.QueryWithOptions(
+			subschemaDN, "(objectClass=*)", ldap.ScopeBaseObject,
+			QueryOptions{Attributes: []string{"attributeTypes"}},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			for _, definition := range entry.GetAttributeValues("attributeTypes") {
+				syntaxMatch := schemaSyntaxRegexp.FindStringSubmatch(definition)
+				nameMatch := schemaNameRegexp.FindStringSubmatch(definition)
+				if syntaxMatch == nil || nameMatch == nil || syntaxMatch[1] != ldapSyntaxDN {
+					continue
+				}
+
+				names := strings.Fields(strings.ReplaceAll(nameMatch[1]+" "+nameMatch[2], "'", ""))
+				attributes = append(attributes, names[0])
+			}
+		}
+	}
+
+	// The DN of an object always references itself
+	attributes = slices.DeleteFunc(attributes, func(name string) bool {
+		return strings.EqualFold(name, "distinguishedName") || name == ""
+	})
+
+	if len(attributes) == 0 {
+		return nil, fmt.Errorf("No DN attributes found in the schema")
+	}
+
+	slices.Sort(attributes)
+
+	lc.cacheLock.Lock()
+	lc.dnAttributes = attributes
+	lc.cacheLock.Unlock()
+
+	return attributes, nil
+}
+
+// QueryReferencesPages streams the objects under baseDN that reference
+// objectDN in any DN-valued attribute ("where used"), searching a few
+// attributes at a time and reporting each object once
+func (lc *LDAPConn) QueryReferencesPages(ctx context.Context, baseDN string, objectDN string, opts QueryOptions, onPage PageHandler) (QueryProgress, error) {
+	attributes, err := lc.DNAttributes()
+	if err != nil {
+		return QueryProgress{}, err
+	}
+
+	seen := make(map[string]bool)
+	progress := QueryProgress{}
+
+	for start := 0; start < len(attributes); start += referenceFilterSize {
+		chunk := attributes[start:min(start+referenceFilterSize, len(attributes))]
+
+		var filter strings.Builder
+		filter.WriteString("(|")
+		for _, attribute := range chunk {
+			filter.WriteString("(" + attribute + "=" + ldap.EscapeFilter(objectDN) + ")")
+		}
+		filter.WriteString(")")
+
+		_, err := lc.QueryPages(ctx, baseDN, filter.String(), ldap.ScopeWholeSubtree, opts, func(entries []*ldap.Entry, _ QueryProgress) {
+			var newEntries []*ldap.Entry
+			for _, entry := range entries {
+				if !seen[strings.ToLower(entry.DN)] {
+					seen[strings.ToLower(entry.DN)] = true
+					newEntries = append(newEntries, entry)
+				}
+			}
+
+			progress.Pages += 1
+			progress.Entries += len(newEntries)
+
+			if onPage != nil && len(newEntries) > 0 {
+				onPage(newEntries, progress)
+			}
+		})
+		if err != nil {
+			return progress, err
+		}
+	}
+
+	return progress, nil
+}
+
+// ResolveReference returns the DN of the object referenced by a value
+// of a link attribute. SPNs are resolved to the account that holds them.
+func (lc *LDAPConn) ResolveReference(attribute string, value string) (string, error) {
+	if !slices.Contains(spnLinkAttributes, strings.ToLower(attribute)) {
+		return value, nil
+	}
+
+	entries, err := lc.QueryWithOptions(
+		lc.SearchBase(), spnTargetsFilter([]string{value}), ldap.ScopeWholeSubtree,
+		QueryOptions{Attributes: []string{"servicePrincipalName"}},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if len(entries) == 0 {
+		return "", fmt.Errorf("No object holds the SPN '%s'", value)
+	}
+
+	// The account holding the SPN comes before its host
+	for _, entry := range entries {
+		for _, spn := range entry.GetAttributeValues("servicePrincipalName") {
+			if strings.EqualFold(spn, value) {
+				return entry.DN, nil
+			}
+		}
+	}
+
+	return entries[0].DN, nil
+}
@@ -0,0 +1,403 @@
+package ldaputils
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Makes the server return link values that were removed
+// (LDAP_SERVER_SHOW_DEACTIVATED_LINK_OID)
+const ControlTypeShowDeactivatedLink = "1.2.840.113556.1.4.2065"
+
+// ReplChange is the last change of an attribute, or of one value of
+// a linked attribute, recorded in the replication metadata of an object
+type ReplChange struct {
+	Attribute string
+
+	// DN of the linked value, for changes of linked attributes.
+	// Removed values are kept by the server as absent values.
+	Value   string
+	Created time.Time
+	Removed bool
+
+	Time           time.Time
+	Version        int
+	InvocationID   string
+	OriginatingDC  string
+	OriginatingUSN int64
+	LocalUSN       int64
+}
+
+// Fields of the DS_REPL_ATTR_META_DATA and DS_REPL_VALUE_META_DATA
+// XML documents returned by msDS-ReplAttributeMetaData and
+// msDS-ReplValueMetaData
+type replMetaDataXML struct {
+	AttributeName  string `xml:"pszAttributeName"`
+	ObjectDN       string `xml:"pszObjectDn"`
+	TimeCreated    string `xml:"ftimeCreated"`
+	TimeDeleted    string `xml:"ftimeDeleted"`
+	Version        int    `xml:"dwVersion"`
+	LastChange     string `xml:"ftimeLastOriginatingChange"`
+	InvocationID   string `xml:"uuidLastOriginatingDsaInvocationID"`
+	OriginatingUSN int64  `xml:"usnOriginatingChange"`
+	LocalUSN       int64  `xml:"usnLocalChange"`
+	DsaDN          string `xml:"pszLastOriginatingDsaDN"`
+}
+
+// Times before 1601-01-02 mean "not set" in the metadata
+var replTimeUnset = time.Date(1601, 1, 2, 0, 0, 0, 0, time.UTC)
+
+func parseReplTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil || t.Before(replTimeUnset) {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// Returns the name of the server whose NTDS Settings object
+// is at dsaDN (CN=NTDS Settings,CN=<server>,CN=Servers,...)
+func serverNameOfDSA(dsaDN string) string {
+	parts := strings.Split(dsaDN, ",")
+	if len(parts) < 2 || !strings.HasPrefix(strings.ToUpper(parts[1]), "CN=") {
+		return dsaDN
+	}
+
+	// Servers that were removed leave a mangled deleted DN
+	name := parts[1][3:]
+	if idx := strings.Index(name, "\\0ADEL:"); idx >= 0 {
+		name = name[:idx] + " (deleted)"
+	}
+
+	return name
+}
+
+// ParseReplMetaDataXML decodes a value of msDS-ReplAttributeMetaData
+// or msDS-ReplValueMetaData
+func ParseReplMetaDataXML(value string) (ReplChange, error) {
+	var metadata replMetaDataXML
+
+	err := xml.Unmarshal([]byte(strings.TrimRight(value, "\x00")), &metadata)
+	if err != nil {
+		return ReplChange{}, err
+	}
+
+	change := ReplChange{
+		Attribute:      metadata.AttributeName,
+		Value:          metadata.ObjectDN,
+		Created:        parseReplTime(metadata.TimeCreated),
+		Removed:        !parseReplTime(metadata.TimeDeleted).IsZero(),
+		Time:           parseReplTime(metadata.LastChange),
+		Version:        metadata.Version,
+		InvocationID:   strings.ToLower(metadata.InvocationID),
+		OriginatingUSN: metadata.OriginatingUSN,
+		LocalUSN:       metadata.LocalUSN,
+	}
+
+	if metadata.DsaDN != "" {
+		change.OriginatingDC = serverNameOfDSA(metadata.DsaDN)
+	}
+
+	return change, nil
+}
+
+// Size of a PROPERTY_META_DATA_EXT entry of replPropertyMetaData
+// and of the header that precedes them
+const (
+	replPropertyHeaderSize = 16
+	replPropertyEntrySize  = 48
+)
+
+// ParseReplPropertyMetaData decodes the binary replPropertyMetaData
+// attribute. Attributes are identified by their ATTRTYP, which is
+// returned as the attribute name in hex until it's resolved.
+func ParseReplPropertyMetaData(raw []byte) ([]ReplChange, []uint32, error) {
+	if len(raw) < replPropertyHeaderSize || binary.LittleEndian.Uint32(raw[0:4]) != 1 {
+		return nil, nil, fmt.Errorf("Invalid replPropertyMetaData header")
+	}
+
+	count := int(binary.LittleEndian.Uint32(raw[8:12]))
+	if len(raw) < replPropertyHeaderSize+count*replPropertyEntrySize {
+		return nil, nil, fmt.Errorf("Truncated replPropertyMetaData")
+	}
+
+	changes := make([]ReplChange, count)
+	attrTypes := make([]uint32, count)
+
+	for idx := 0; idx < count; idx++ {
+		entry := raw[replPropertyHeaderSize+idx*replPropertyEntrySize:]
+
+		attrTypes[idx] = binary.LittleEndian.Uint32(entry[0:4])
+
+		// DSTIME counts seconds since 1601
+		var changeTime time.Time
+		if seconds := int64(binary.LittleEndian.Uint64(entry[8:16])); seconds > 0 {
+			changeTime = FileTimeToTime(seconds * 10000000)
+		}
+
+		changes[idx] = ReplChange{
+			Attribute:      fmt.Sprintf("0x%x", attrTypes[idx]),
+			Version:        int(binary.LittleEndian.Uint32(entry[4:8])),
+			Time:           changeTime,
+			InvocationID:   ConvertGUID(hex.EncodeToString(entry[16:32])),
+			OriginatingUSN: int64(binary.LittleEndian.Uint64(entry[32:40])),
+			LocalUSN:       int64(binary.LittleEndian.Uint64(entry[40:48])),
+		}
+	}
+
+	return changes, attrTypes, nil
+}
+
+// Default prefix table of Active Directory (MS-DRSR 5.16.4), which
+// maps the high word of an ATTRTYP to the OID prefix of the attribute
+var attrTypePrefixes = []string{
+	"2.5.4", "2.5.6", "1.2.840.113556.1.2", "1.2.840.113556.1.3",
+	"2.16.840.1.101.2.2.1", "2.16.840.1.101.2.2.3", "2.16.840.1.101.2.1.5",
+	"2.16.840.1.101.2.1.4", "2.5.5", "1.2.840.113556.1.4", "1.2.840.113556.1.5",
+	"1.2.840.113556.1.4.260", "1.2.840.113556.1.5.56", "1.2.840.113556.1.4.262",
+	"1.2.840.113556.1.5.57", "1.2.840.113556.1.4.263", "1.2.840.113556.1.5.58",
+	"1.2.840.113556.1.5.73", "1.2.840.113556.1.4.305", "0.9.2342.19200300.100",
+	"2.16.840.1.113730.3", "0.9.2342.19200300.100.1", "2.16.840.1.113730.3.1",
+	"1.2.840.113556.1.5.7000", "2.5.21", "2.5.18", "2.5.20",
+	"1.3.6.1.4.1.1466.101.119", "2.16.840.1.113730.3.2", "1.3.6.1.4.1.250.1",
+	"1.2.840.113549.1.9", "0.9.2342.19200300.100.4", "1.2.840.113556.1.6.23",
+	"1.2.840.113556.1.6.18.1", "1.2.840.113556.1.6.18.2", "1.2.840.113556.1.6.13.3",
+	"1.2.840.113556.1.6.13.4", "1.3.6.1.1.1.1", "1.3.6.1.1.1.2",
+}
+
+// AttrTypeOfOID returns the ATTRTYP of an attribute of the base schema
+// from its attributeID, using the default prefix table
+func AttrTypeOfOID(oid string) (uint32, bool) {
+	idx := strings.LastIndex(oid, ".")
+	if idx < 0 {
+		return 0, false
+	}
+
+	lastArc, err := strconv.ParseUint(oid[idx+1:], 10, 32)
+	if err != nil || lastArc >= 0x4000 {
+		return 0, false
+	}
+
+	for prefixIdx, prefix := range attrTypePrefixes {
+		if prefix == oid[:idx] {
+			return uint32(prefixIdx)<<16 | uint32(lastArc), true
+		}
+	}
+
+	return 0, false
+}
+
+// Reads the names of the attributes by their ATTRTYP from the schema.
+// Attributes added to the schema later are identified by msDS-IntId.
+func (lc *LDAPConn) attrTypeNames() (map[uint32]string, error) {
+	lc.cacheLock.Lock()
+	attrTypes := lc.attrTypes
+	lc.cacheLock.Unlock()
+
+	if attrTypes != nil {
+		return attrTypes, nil
+	}
+
+	rootDSE, err := lc.QueryWithOptions("", "(objectClass=*)", ldap.ScopeBaseObject, QueryOptions{
+		Attributes: []string{"schemaNamingContext"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rootDSE) != 1 || rootDSE[0].GetAttributeValue("schemaNamingContext") == "" {
+		return nil, fmt.Errorf("Schema partition not found")
+	}
+
+	entries, err := lc.QueryWithOptions(
+		rootDSE[0].GetAttributeValue("schemaNamingContext"),
+		"(objectClass=attributeSchema)",
+		ldap.ScopeSingleLevel,
+		QueryOptions{Attributes: []string{"lDAPDisplayName", "attributeID", "msDS-IntId"}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uint32]string)
+	for _, entry := range entries {
+		name := entry.GetAttributeValue("lDAPDisplayName")
+
+		if intId, err := strconv.ParseInt(entry.GetAttributeValue("msDS-IntId"), 10, 64); err == nil {
+			names[uint32(intId)] = name
+		}
+
+		if attrType, ok := AttrTypeOfOID(entry.GetAttributeValue("attributeID")); ok {
+			names[attrType] = name
+		}
+	}
+
+	lc.cacheLock.Lock()
+	lc.attrTypes = names
+	lc.cacheLock.Unlock()
+
+	return names, nil
+}
+
+// ServersByInvocationID maps the invocation IDs of the domain
+// controllers of the forest to their names
+func (lc *LDAPConn) ServersByInvocationID() (map[string]string, error) {
+	rootDSE, err := lc.QueryWithOptions("", "(objectClass=*)", ldap.ScopeBaseObject, QueryOptions{
+		Attributes: []string{"configurationNamingContext"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rootDSE) != 1 || rootDSE[0].GetAttributeValue("configurationNamingContext") == "" {
+		return nil, fmt.Errorf("Configuration partition not found")
+	}
+
+	entries, err := lc.QueryWithOptions(
+		rootDSE[0].GetAttributeValue("configurationNamingContext"),
+		"(objectClass=nTDSDSA)",
+		ldap.ScopeWholeSubtree,
+		QueryOptions{Attributes: []string{"invocationId"}, ShowDeleted: true},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make(map[string]string)
+	for _, entry := range entries {
+		invocationId := entry.GetRawAttributeValue("invocationId")
+		if len(invocationId) == 16 {
+			servers[ConvertGUID(hex.EncodeToString(invocationId))] = serverNameOfDSA(entry.DN)
+		}
+	}
+
+	return servers, nil
+}
+
+// Reads all values of the replication metadata attributes of an
+// object, following the ranged retrieval of msDS-ReplValueMetaData
+func (lc *LDAPConn) queryReplMetaData(objectDN string) (map[string][]string, [][]byte, error) {
+	controls := []ldap.Control{ldap.NewControlMicrosoftShowDeleted()}
+	if lc.SupportsControl(ControlTypeShowDeactivatedLink) {
+		controls = append(controls, ldap.NewControlString(ControlTypeShowDeactivatedLink, false, ""))
+	}
+
+	values := make(map[string][]string)
+	var binaryMetaData [][]byte
+
+	attributes := []string{"msDS-ReplAttributeMetaData", "msDS-ReplValueMetaData", "replPropertyMetaData"}
+	for len(attributes) > 0 {
+		req := ldap.NewSearchRequest(
+			objectDN,
+			ldap.ScopeBaseObject, ldap.NeverDerefAliases, 0, 0, false,
+			"(objectClass=*)",
+			attributes,
+			controls,
+		)
+
+		result, err := lc.search(req)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(result.Entries) != 1 {
+			return nil, nil, fmt.Errorf("Object '%s' not found", objectDN)
+		}
+
+		attributes = nil
+		for _, attr := range result.Entries[0].Attributes {
+			name, attrRange, ranged := strings.Cut(attr.Name, ";range=")
+
+			if strings.EqualFold(name, "replPropertyMetaData") {
+				binaryMetaData = attr.ByteValues
+				continue
+			}
+
+			values[strings.ToLower(name)] = append(values[strings.ToLower(name)], attr.Values...)
+
+			// Ranges end with "*" once the last value was returned
+			_, end, _ := strings.Cut(attrRange, "-")
+			if lastValue, err := strconv.Atoi(end); ranged && err == nil {
+				attributes = append(attributes, fmt.Sprintf("%s;range=%d-*", name, lastValue+1))
+			}
+		}
+	}
+
+	return values, binaryMetaData, nil
+}
+
+// ReplicationTimeline returns the last change of each attribute and linked
+// value of an object, from the most recent to the oldest. The XML metadata
+// is preferred and the binary replPropertyMetaData is used when it's missing.
+func (lc *LDAPConn) ReplicationTimeline(objectDN string) ([]ReplChange, error) {
+	values, binaryMetaData, err := lc.queryReplMetaData(objectDN)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []ReplChange
+	for _, attribute := range []string{"msds-replattributemetadata", "msds-replvaluemetadata"} {
+		for _, value := range values[attribute] {
+			change, err := ParseReplMetaDataXML(value)
+			if err != nil {
+				return nil, err
+			}
+
+			changes = append(changes, change)
+		}
+	}
+
+	if len(values["msds-replattributemetadata"]) == 0 && len(binaryMetaData) > 0 {
+		binaryChanges, attrTypes, err := ParseReplPropertyMetaData(binaryMetaData[0])
+		if err != nil {
+			return nil, err
+		}
+
+		// Without the schema, attributes keep their ATTRTYP
+		names, _ := lc.attrTypeNames()
+		for idx := range binaryChanges {
+			if name, ok := names[attrTypes[idx]]; ok {
+				binaryChanges[idx].Attribute = name
+			}
+		}
+
+		changes = append(changes, binaryChanges...)
+	}
+
+	// Changes made by servers whose DN wasn't given are
+	// attributed from their invocation IDs
+	var servers map[string]string
+	for idx := range changes {
+		if changes[idx].OriginatingDC != "" || changes[idx].InvocationID == "" {
+			continue
+		}
+
+		if servers == nil {
+			servers, err = lc.ServersByInvocationID()
+			if err != nil {
+				servers = make(map[string]string)
+			}
+		}
+
+		if server, ok := servers[changes[idx].InvocationID]; ok {
+			changes[idx].OriginatingDC = server
+		} else {
+			changes[idx].OriginatingDC = changes[idx].InvocationID
+		}
+	}
+
+	sort.SliceStable(changes, func(i int, j int) bool {
+		return changes[i].Time.After(changes[j].Time)
+	})
+
+	return changes, nil
+}
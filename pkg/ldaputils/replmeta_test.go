@@ -0,0 +1,63 @@
+package ldaputils
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestParseReplMetaData(t *testing.T) {
+	valueXML := "<DS_REPL_VALUE_META_DATA>\n" +
+		"\t<pszAttributeName>member</pszAttributeName>\n" +
+		"\t<pszObjectDn>CN=john,CN=Users,DC=corp,DC=local</pszObjectDn>\n" +
+		"\t<cbData>0</cbData>\n" +
+		"\t<pbData></pbData>\n" +
+		"\t<ftimeDeleted>2024-03-02T10:00:00Z</ftimeDeleted>\n" +
+		"\t<ftimeCreated>2024-03-01T09:00:00Z</ftimeCreated>\n" +
+		"\t<dwVersion>2</dwVersion>\n" +
+		"\t<ftimeLastOriginatingChange>2024-03-02T10:00:00Z</ftimeLastOriginatingChange>\n" +
+		"\t<uuidLastOriginatingDsaInvocationID>6A5C2E5B-0C1D-4E2F-9A8B-7C6D5E4F3A2B</uuidLastOriginatingDsaInvocationID>\n" +
+		"\t<usnOriginatingChange>41234</usnOriginatingChange>\n" +
+		"\t<usnLocalChange>41234</usnLocalChange>\n" +
+		"\t<pszLastOriginatingDsaDN>CN=NTDS Settings,CN=DC01,CN=Servers,CN=Default-First-Site-Name,CN=Sites,CN=Configuration,DC=corp,DC=local</pszLastOriginatingDsaDN>\n" +
+		"</DS_REPL_VALUE_META_DATA>\n\x00"
+
+	change, err := ParseReplMetaDataXML(valueXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if change.Attribute != "member" || !change.Removed || change.Version != 2 ||
+		change.OriginatingDC != "DC01" || change.LocalUSN != 41234 ||
+		!change.Time.Equal(time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected value metadata: %+v", change)
+	}
+
+	// One entry for description (ATTRTYP 0x0000000d)
+	raw := make([]byte, replPropertyHeaderSize+replPropertyEntrySize)
+	binary.LittleEndian.PutUint32(raw[0:4], 1)
+	binary.LittleEndian.PutUint32(raw[8:12], 1)
+
+	entry := raw[replPropertyHeaderSize:]
+	binary.LittleEndian.PutUint32(entry[0:4], 0xd)
+	binary.LittleEndian.PutUint32(entry[4:8], 3)
+	binary.LittleEndian.PutUint64(entry[8:16], 13351262400) // 2024-02-01 12:00:00 UTC
+	entry[16] = 0xff
+	binary.LittleEndian.PutUint64(entry[32:40], 12345)
+	binary.LittleEndian.PutUint64(entry[40:48], 12346)
+
+	changes, attrTypes, err := ParseReplPropertyMetaData(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if attrType, _ := AttrTypeOfOID("2.5.4.13"); len(changes) != 1 || attrTypes[0] != attrType {
+		t.Fatalf("unexpected attribute types: %v", attrTypes)
+	}
+
+	if changes[0].Version != 3 || changes[0].OriginatingUSN != 12345 ||
+		changes[0].InvocationID != "000000ff-0000-0000-0000-000000000000" ||
+		!changes[0].Time.Equal(time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected property metadata: %+v", changes[0])
+	}
+}
@@ -0,0 +1,482 @@
+package ldaputils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// AttributeSyntax is how the values of an attribute are
+// encoded, which decides the editor used to change them
+type AttributeSyntax int
+
+const (
+	SyntaxString AttributeSyntax = iota
+	SyntaxInteger
+	SyntaxBoolean
+	SyntaxDN
+	SyntaxGeneralizedTime
+	SyntaxFileTime
+	SyntaxSID
+	SyntaxGUID
+	SyntaxBinary
+)
+
+var SyntaxNames = map[AttributeSyntax]string{
+	SyntaxString:          "String",
+	SyntaxInteger:         "Integer",
+	SyntaxBoolean:         "Boolean",
+	SyntaxDN:              "DN",
+	SyntaxGeneralizedTime: "Generalized Time",
+	SyntaxFileTime:        "FILETIME",
+	SyntaxSID:             "SID",
+	SyntaxGUID:            "GUID",
+	SyntaxBinary:          "Binary",
+}
+
+func (syntax AttributeSyntax) String() string {
+	return SyntaxNames[syntax]
+}
+
+// AttributeSchema is what the schema says about an attribute
+type AttributeSchema struct {
+	Name         string
+	Syntax       AttributeSyntax
+	SingleValued bool
+
+	// Whether the schema of the server was found,
+	// or the syntax was guessed from the name
+	Known bool
+}
+
+// Large integers that hold FILETIME timestamps
+var FileTimeAttributes = []string{
+	"lastlogontimestamp", "accountexpires", "badpasswordtime", "lastlogoff",
+	"lastlogon", "pwdlastset", "creationtime", "lockouttime",
+	"ms-mcs-admpwdexpirationtime", "mslaps-passwordexpirationtime",
+	"msds-lastsuccessfulinteractivelogontime", "msds-lastfailedinteractivelogontime",
+	"msds-userpasswordexpirytimecomputed",
+}
+
+// AD attribute syntaxes (attributeSyntax) and LDAP syntaxes (RFC 4517)
+var adSyntaxes = map[string]AttributeSyntax{
+	"2.5.5.1":  SyntaxDN,
+	"2.5.5.8":  SyntaxBoolean,
+	"2.5.5.9":  SyntaxInteger,
+	"2.5.5.11": SyntaxGeneralizedTime,
+	"2.5.5.16": SyntaxInteger,
+	"2.5.5.17": SyntaxSID,
+	"2.5.5.10": SyntaxBinary,
+	"2.5.5.15": SyntaxBinary,
+}
+
+var ldapSyntaxes = map[string]AttributeSyntax{
+	"1.3.6.1.4.1.1466.115.121.1.12": SyntaxDN,
+	"1.3.6.1.4.1.1466.115.121.1.34": SyntaxDN,
+	"1.3.6.1.4.1.1466.115.121.1.7":  SyntaxBoolean,
+	"1.3.6.1.4.1.1466.115.121.1.27": SyntaxInteger,
+	"1.3.6.1.4.1.1466.115.121.1.24": SyntaxGeneralizedTime,
+	"1.3.6.1.4.1.1466.115.121.1.53": SyntaxGeneralizedTime,
+	"1.3.6.1.4.1.1466.115.121.1.40": SyntaxBinary,
+	"1.3.6.1.4.1.1466.115.121.1.5":  SyntaxBinary,
+	"1.3.6.1.4.1.1466.115.121.1.8":  SyntaxBinary,
+	"1.3.6.1.4.1.1466.115.121.1.28": SyntaxBinary,
+}
+
+// Syntaxes of common attributes, used when the schema isn't loaded
+var builtinSyntaxes = map[string]AttributeSyntax{
+	"objectsid":          SyntaxSID,
+	"sidhistory":         SyntaxSID,
+	"securityidentifier": SyntaxSID,
+	"tokengroups":        SyntaxSID,
+	"creatorsid":         SyntaxSID,
+
+	"objectguid":            SyntaxGUID,
+	"schemaidguid":          SyntaxGUID,
+	"attributesecurityguid": SyntaxGUID,
+	"ms-ds-consistencyguid": SyntaxGUID,
+	"msds-generationid":     SyntaxBinary,
+
+	"whencreated":           SyntaxGeneralizedTime,
+	"whenchanged":           SyntaxGeneralizedTime,
+	"createtimestamp":       SyntaxGeneralizedTime,
+	"modifytimestamp":       SyntaxGeneralizedTime,
+	"dscorepropagationdata": SyntaxGeneralizedTime,
+
+	"member":            SyntaxDN,
+	"memberof":          SyntaxDN,
+	"manager":           SyntaxDN,
+	"managedby":         SyntaxDN,
+	"directreports":     SyntaxDN,
+	"distinguishedname": SyntaxDN,
+	"objectcategory":    SyntaxDN,
+	"msds-allowedtoactonbehalfofotheridentity": SyntaxBinary,
+
+	"useraccountcontrol": SyntaxInteger,
+	"admincount":         SyntaxInteger,
+	"primarygroupid":     SyntaxInteger,
+	"grouptype":          SyntaxInteger,
+	"samaccounttype":     SyntaxInteger,
+	"instancetype":       SyntaxInteger,
+
+	"ntsecuritydescriptor": SyntaxBinary,
+	"usercertificate":      SyntaxBinary,
+	"cacertificate":        SyntaxBinary,
+	"logonhours":           SyntaxBinary,
+	"thumbnailphoto":       SyntaxBinary,
+	"jpegphoto":            SyntaxBinary,
+}
+
+func init() {
+	for _, name := range FileTimeAttributes {
+		builtinSyntaxes[name] = SyntaxFileTime
+	}
+}
+
+// Refines the syntax of the schema with what is known about
+// some attributes, since AD stores timestamps and GUIDs as
+// plain large integers and octet strings
+func refineSyntax(name string, syntax AttributeSyntax) AttributeSyntax {
+	lowerName := strings.ToLower(name)
+
+	switch builtin := builtinSyntaxes[lowerName]; {
+	case builtin == SyntaxFileTime || builtin == SyntaxGUID || builtin == SyntaxSID:
+		return builtin
+	case syntax == SyntaxBinary && strings.HasSuffix(lowerName, "guid"):
+		return SyntaxGUID
+	case syntax == SyntaxBinary && strings.HasSuffix(lowerName, "sid"):
+		return SyntaxSID
+	}
+
+	return syntax
+}
+
+// Large integers whose names mention a time are FILETIME timestamps,
+// unlike intervals (e.g. msDS-LogonTimeSyncInterval) and durations
+func isFileTimeName(name string) bool {
+	lowerName := strings.ToLower(name)
+	return strings.Contains(lowerName, "time") &&
+		!strings.Contains(lowerName, "interval") &&
+		!strings.Contains(lowerName, "duration")
+}
+
+// Guesses the syntax of an attribute missing from the schema by its name
+func guessAttributeSchema(name string) AttributeSchema {
+	syntax, ok := builtinSyntaxes[strings.ToLower(name)]
+	if !ok {
+		syntax = SyntaxString
+	}
+
+	return AttributeSchema{Name: name, Syntax: syntax}
+}
+
+// AttributeSyntaxOf returns the syntax of an attribute from the schema
+// of the server, if it was loaded or the attribute was looked up,
+// or from a built-in table otherwise. Nothing is read from the server.
+func (lc *LDAPConn) AttributeSyntaxOf(name string) AttributeSyntax {
+	lc.cacheLock.Lock()
+	schema, ok := lc.attributeSchemas[strings.ToLower(name)]
+	lc.cacheLock.Unlock()
+
+	if ok {
+		return schema.Syntax
+	}
+
+	return guessAttributeSchema(name).Syntax
+}
+
+// Builds the schema of an attribute from its attributeSchema object
+func adAttributeSchema(entry *ldap.Entry) AttributeSchema {
+	name := entry.GetAttributeValue("lDAPDisplayName")
+	attributeSyntax := entry.GetAttributeValue("attributeSyntax")
+
+	syntax := adSyntaxes[attributeSyntax]
+	if attributeSyntax == "2.5.5.16" && isFileTimeName(name) {
+		syntax = SyntaxFileTime
+	}
+
+	return AttributeSchema{
+		Name:         name,
+		Syntax:       refineSyntax(name, syntax),
+		SingleValued: strings.EqualFold(entry.GetAttributeValue("isSingleValued"), "TRUE"),
+		Known:        true,
+	}
+}
+
+// LoadAttributeSchemas reads the syntax of every attribute in the
+// AD schema partition, which is then used by the formatters and
+// by AttributeSchema instead of querying attributes one by one
+func (lc *LDAPConn) LoadAttributeSchemas() error {
+	rootDSE, err := lc.QueryWithOptions("", "(objectClass=*)", ldap.ScopeBaseObject, QueryOptions{
+		Attributes: []string{"schemaNamingContext"},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(rootDSE) != 1 || rootDSE[0].GetAttributeValue("schemaNamingContext") == "" {
+		return fmt.Errorf("Schema partition not found")
+	}
+
+	entries, err := lc.QueryWithOptions(
+		rootDSE[0].GetAttributeValue("schemaNamingContext"),
+		"(objectClass=attributeSchema)",
+		ldap.ScopeSingleLevel,
+		QueryOptions{Attributes: []string{"lDAPDisplayName", "attributeSyntax", "isSingleValued"}},
+	)
+	if err != nil {
+		return err
+	}
+
+	schemas := make(map[string]AttributeSchema)
+	for _, entry := range entries {
+		schema := adAttributeSchema(entry)
+		schemas[strings.ToLower(schema.Name)] = schema
+	}
+
+	lc.cacheLock.Lock()
+	lc.attributeSchemas = schemas
+	lc.cacheLock.Unlock()
+
+	return nil
+}
+
+var (
+	schemaNameRegexp         = regexp.MustCompile(`NAME\s+(?:'([^']+)'|\(\s*((?:'[^']+'\s*)+)\))`)
+	schemaSyntaxRegexp       = regexp.MustCompile(`SYNTAX\s+'?([0-9.]+)`)
+	schemaSingleValuedRegexp = regexp.MustCompile(`\bSINGLE-VALUE\b`)
+)
+
+// Reads the schema of an attribute from the AD schema
+// partition or from the subschema subentry of other servers
+func (lc *LDAPConn) queryAttributeSchema(name string) (AttributeSchema, error) {
+	rootDSE, err := lc.QueryWithOptions("", "(objectClass=*)", ldap.ScopeBaseObject, QueryOptions{
+		Attributes: []string{"schemaNamingContext", "subschemaSubentry"},
+	})
+	if err != nil || len(rootDSE) != 1 {
+		return AttributeSchema{}, err
+	}
+
+	if schemaDN := rootDSE[0].GetAttributeValue("schemaNamingContext"); schemaDN != "" {
+		entries, err := lc.QueryWithOptions(
+			schemaDN,
+			fmt.Sprintf("(&(objectClass=attributeSchema)(lDAPDisplayName=%s))", ldap.EscapeFilter(name)),
+			ldap.ScopeSingleLevel,
+			QueryOptions{Attributes: []string{"lDAPDisplayName", "attributeSyntax", "isSingleValued"}},
+		)
+		if err != nil || len(entries) != 1 {
+			return AttributeSchema{}, err
+		}
+
+		return adAttributeSchema(entries[0]), nil
+	}
+
+	subschemaDN := rootDSE[0].GetAttributeValue("subschemaSubentry")
+	if subschemaDN == "" {
+		return AttributeSchema{}, nil
+	}
+
+	entries, err := lc.QueryWithOptions(
+		subschemaDN, "(objectClass=*)", ldap.ScopeBaseObject,
+		QueryOptions{Attributes: []string{"attributeTypes"}},
+	)
+	if err != nil || len(entries) != 1 {
+		return AttributeSchema{}, err
+	}
+
+	for _, definition := range entries[0].GetAttributeValues("attributeTypes") {
+		nameMatch := schemaNameRegexp.FindStringSubmatch(definition)
+		if nameMatch == nil {
+			continue
+		}
+
+		names := strings.Fields(strings.ReplaceAll(nameMatch[1]+" "+nameMatch[2], "'", ""))
+		if !slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) }) {
+			continue
+		}
+
+		schema := AttributeSchema{
+			Name:         name,
+			SingleValued: schemaSingleValuedRegexp.MatchString(definition),
+			Known:        true,
+		}
+
+		if syntaxMatch := schemaSyntaxRegexp.FindStringSubmatch(definition); syntaxMatch != nil {
+			schema.Syntax = ldapSyntaxes[syntaxMatch[1]]
+		}
+
+		schema.Syntax = refineSyntax(name, schema.Syntax)
+		return schema, nil
+	}
+
+	return AttributeSchema{}, nil
+}
+
+// AttributeSchema returns the syntax of an attribute and whether it's
+// single-valued, guessing them from the name when the schema can't
+// be read. Results are cached for the lifetime of the connection.
+func (lc *LDAPConn) AttributeSchema(name string) AttributeSchema {
+	key := strings.ToLower(name)
+
+	lc.cacheLock.Lock()
+	schema, ok := lc.attributeSchemas[key]
+	lc.cacheLock.Unlock()
+
+	if ok {
+		return schema
+	}
+
+	schema, err := lc.queryAttributeSchema(name)
+	if err != nil || !schema.Known {
+		schema = guessAttributeSchema(name)
+	}
+
+	lc.cacheLock.Lock()
+	if lc.attributeSchemas == nil {
+		lc.attributeSchemas = make(map[string]AttributeSchema)
+	}
+	lc.attributeSchemas[key] = schema
+	lc.cacheLock.Unlock()
+
+	return schema
+}
+
+// Layout of the timestamps typed in the attribute editors (UTC)
+const EditorTimeLayout = "2006-01-02 15:04:05"
+
+// FILETIME values that mean "never"
+const (
+	FileTimeNever      = "0"
+	FileTimeNeverLarge = "9223372036854775807"
+)
+
+// TimeToFileTime converts a time into the 100ns intervals since 1601
+func TimeToFileTime(t time.Time) int64 {
+	return t.UnixNano()/100 + 116444736000000000
+}
+
+// FileTimeToTime converts the 100ns intervals since 1601 into a time
+func FileTimeToTime(filetime int64) time.Time {
+	return time.Unix(0, (filetime-116444736000000000)*100).UTC()
+}
+
+// DecodeAttributeValue turns a raw value into the text shown in its editor
+func DecodeAttributeValue(syntax AttributeSyntax, raw []byte) string {
+	switch syntax {
+	case SyntaxSID:
+		if len(raw) < 8 {
+			return hex.EncodeToString(raw)
+		}
+		return ConvertSID(hex.EncodeToString(raw))
+	case SyntaxGUID:
+		if len(raw) != 16 {
+			return hex.EncodeToString(raw)
+		}
+		return ConvertGUID(hex.EncodeToString(raw))
+	case SyntaxGeneralizedTime:
+		t, err := time.Parse("20060102150405.0Z", string(raw))
+		if err != nil {
+			return string(raw)
+		}
+		return t.UTC().Format(EditorTimeLayout)
+	case SyntaxFileTime:
+		filetime, err := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || filetime <= 0 || filetime == math.MaxInt64 {
+			return string(raw)
+		}
+		return FileTimeToTime(filetime).Format(EditorTimeLayout)
+	case SyntaxBinary:
+		return hex.EncodeToString(raw)
+	}
+
+	return string(raw)
+}
+
+// EncodeAttributeValue validates the text typed in an editor
+// and turns it into the raw value sent to the server
+func EncodeAttributeValue(syntax AttributeSyntax, text string) (string, error) {
+	if syntax != SyntaxString {
+		text = strings.TrimSpace(text)
+	}
+
+	switch syntax {
+	case SyntaxInteger:
+		if _, err := strconv.ParseInt(text, 10, 64); err != nil {
+			return "", fmt.Errorf("Invalid integer '%s'", text)
+		}
+	case SyntaxBoolean:
+		switch strings.ToUpper(text) {
+		case "TRUE", "FALSE":
+			return strings.ToUpper(text), nil
+		}
+		return "", fmt.Errorf("Invalid boolean '%s' (TRUE or FALSE)", text)
+	case SyntaxDN:
+		if _, err := ldap.ParseDN(text); err != nil || text == "" {
+			return "", fmt.Errorf("Invalid DN '%s'", text)
+		}
+	case SyntaxGeneralizedTime:
+		t, err := time.Parse(EditorTimeLayout, text)
+		if err != nil {
+			return "", fmt.Errorf("Invalid time '%s' (expected %s)", text, EditorTimeLayout)
+		}
+		return t.Format("20060102150405.0Z"), nil
+	case SyntaxFileTime:
+		if text == FileTimeNever || text == FileTimeNeverLarge {
+			return text, nil
+		}
+
+		t, err := time.Parse(EditorTimeLayout, text)
+		if err != nil {
+			return "", fmt.Errorf("Invalid time '%s' (expected %s)", text, EditorTimeLayout)
+		}
+		return strconv.FormatInt(TimeToFileTime(t), 10), nil
+	case SyntaxSID:
+		if !IsSID(text) {
+			return "", fmt.Errorf("Invalid SID '%s'", text)
+		}
+		return decodeHexValue(EncodeSID(text))
+	case SyntaxGUID:
+		return decodeHexValue(EncodeGUID(text))
+	case SyntaxBinary:
+		return decodeHexValue(strings.Join(strings.Fields(text), ""), nil)
+	}
+
+	return text, nil
+}
+
+func decodeHexValue(hexValue string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := hex.DecodeString(hexValue)
+	if err != nil {
+		return "", fmt.Errorf("Invalid value: %s", err)
+	}
+
+	return string(raw), nil
+}
+
+// HexDump formats binary data as lines of 16 space-separated bytes
+func HexDump(raw []byte) string {
+	var lines []string
+	for start := 0; start < len(raw); start += 16 {
+		chunk := raw[start:min(start+16, len(raw))]
+
+		bytes := make([]string, len(chunk))
+		for idx, b := range chunk {
+			bytes[idx] = fmt.Sprintf("%02x", b)
+		}
+
+		lines = append(lines, strings.Join(bytes, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
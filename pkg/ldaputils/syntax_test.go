@@ -0,0 +1,165 @@
+package ldaputils
+
+import (
+	"encoding/hex"
+	"slices"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestAttributeValueRoundTrip(t *testing.T) {
+	cases := []struct {
+		syntax AttributeSyntax
+		text   string
+		rawHex string
+	}{
+		{SyntaxSID, "S-1-5-21-1004336348-1177238915-682003330-512", "010500000000000515000000dcf4dc3b833d2b46828ba62800020000"},
+		{SyntaxGUID, "bf967aba-0de6-11d0-a285-00aa003049e2", "ba7a96bfe60dd011a28500aa003049e2"},
+		{SyntaxGeneralizedTime, "2024-01-02 03:04:05", hex.EncodeToString([]byte("20240102030405.0Z"))},
+		{SyntaxFileTime, "2024-01-02 03:04:05", hex.EncodeToString([]byte("133486382450000000"))},
+		{SyntaxBinary, "00ff10", "00ff10"},
+	}
+
+	for _, c := range cases {
+		encoded, err := EncodeAttributeValue(c.syntax, c.text)
+		if err != nil {
+			t.Errorf("EncodeAttributeValue(%s, %q) failed: %v", c.syntax, c.text, err)
+			continue
+		}
+
+		if hex.EncodeToString([]byte(encoded)) != c.rawHex {
+			t.Errorf("EncodeAttributeValue(%s, %q) = %x, expected %s", c.syntax, c.text, encoded, c.rawHex)
+		}
+
+		if decoded := DecodeAttributeValue(c.syntax, []byte(encoded)); decoded != c.text {
+			t.Errorf("DecodeAttributeValue(%s, %x) = %q, expected %q", c.syntax, encoded, decoded, c.text)
+		}
+	}
+
+	invalid := map[AttributeSyntax]string{
+		SyntaxInteger:         "12a",
+		SyntaxBoolean:         "yes",
+		SyntaxDN:              "not a dn",
+		SyntaxGeneralizedTime: "yesterday",
+		SyntaxSID:             "S-1",
+		SyntaxGUID:            "bf967aba",
+	}
+
+	for syntax, text := range invalid {
+		if _, err := EncodeAttributeValue(syntax, text); err == nil {
+			t.Errorf("EncodeAttributeValue(%s, %q) should fail", syntax, text)
+		}
+	}
+}
+
+func TestFormatLDAPAttribute(t *testing.T) {
+	sid, _ := EncodeAttributeValue(SyntaxSID, "S-1-5-21-1004336348-1177238915-682003330-1105")
+	guid, _ := EncodeAttributeValue(SyntaxGUID, "bf967aba-0de6-11d0-a285-00aa003049e2")
+
+	cases := []struct {
+		attr     *ldap.EntryAttribute
+		expected []string
+	}{
+		{
+			ldap.NewEntryAttribute("sIDHistory", []string{sid, sid}),
+			[]string{"SID{S-1-5-21-1004336348-1177238915-682003330-1105}", "SID{S-1-5-21-1004336348-1177238915-682003330-1105}"},
+		},
+		{
+			ldap.NewEntryAttribute("attributeSecurityGUID", []string{guid}),
+			[]string{"GUID{bf967aba-0de6-11d0-a285-00aa003049e2}"},
+		},
+		{
+			ldap.NewEntryAttribute("msDS-LastSuccessfulInteractiveLogonTime", []string{"0"}),
+			[]string{"(Never)"},
+		},
+		{
+			ldap.NewEntryAttribute("groupType", []string{"-2147483646"}),
+			[]string{GroupTypeMap[-2147483646]},
+		},
+		{
+			ldap.NewEntryAttribute("description", []string{"a", "b"}),
+			[]string{"a", "b"},
+		},
+	}
+
+	lc := &LDAPConn{}
+	for _, c := range cases {
+		formatted := lc.FormatLDAPAttribute(c.attr, "2006-01-02", 0)
+		if !slices.Equal(formatted, c.expected) {
+			t.Errorf("FormatLDAPAttribute(%s) = %q, expected %q", c.attr.Name, formatted, c.expected)
+		}
+	}
+
+	// Syntaxes read from the schema only apply to their own connection
+	schemaConn := &LDAPConn{attributeSchemas: map[string]AttributeSchema{
+		"msexch-mailboxid": {Name: "msExch-MailboxID", Syntax: SyntaxGUID, Known: true},
+	}}
+
+	attr := ldap.NewEntryAttribute("msExch-MailboxID", []string{guid})
+	if formatted := schemaConn.FormatLDAPAttribute(attr, "", 0); !slices.Equal(formatted, []string{"GUID{bf967aba-0de6-11d0-a285-00aa003049e2}"}) {
+		t.Errorf("got %q with the schema of the connection", formatted)
+	}
+
+	if formatted := lc.FormatLDAPAttribute(attr, "", 0); !slices.Equal(formatted, []string{guid}) {
+		t.Errorf("got %q without the schema", formatted)
+	}
+}
+
+func TestFormatFlagAttributes(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want []string
+	}{
+		{"msDS-SupportedEncryptionTypes", "24", []string{"AES128-CTS-HMAC-SHA1-96", "AES256-CTS-HMAC-SHA1-96"}},
+		{"trustDirection", "3", []string{"Bidirectional"}},
+		{"groupType", "-2147483646", []string{"Global Security Group"}},
+		{"groupType", "-2147483647", []string{"BuiltinLocal", "Security"}},
+		{"searchFlags", "0", []string{"(None)"}},
+		{"pwdProperties", "65", []string{"PasswordComplex", "Unknown(0x40)"}},
+		{"wellKnownObjects", "B:32:A9D1CA15768811D1ADED00C04FD8D5CD:CN=Users,DC=corp,DC=local", []string{"Users -> CN=Users,DC=corp,DC=local"}},
+	}
+
+	for _, c := range cases {
+		attr := &ldap.EntryAttribute{
+			Name:       c.name,
+			Values:     []string{c.raw},
+			ByteValues: [][]byte{[]byte(c.raw)},
+		}
+
+		got := (&LDAPConn{}).FormatLDAPAttribute(attr, "", 0)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s=%s: got %v, want %v", c.name, c.raw, got, c.want)
+		}
+	}
+}
+
+func TestLogonHours(t *testing.T) {
+	// Monday to Friday, 08:00 to 18:00 at UTC-3
+	var hours LogonHours
+	for day := 1; day <= 5; day++ {
+		for hour := 8; hour < 18; hour++ {
+			hours[day][hour] = true
+		}
+	}
+
+	raw := hours.Encode(-3)
+
+	// Monday 08:00 at UTC-3 is Monday 11:00 UTC (bit 35)
+	if raw[4]&(1<<3) == 0 || raw[4]&(1<<2) != 0 {
+		t.Errorf("unexpected rotation: %x", raw)
+	}
+
+	decoded, err := DecodeLogonHours(raw, -3)
+	if err != nil || decoded != hours {
+		t.Errorf("round trip failed: %v", err)
+	}
+
+	// Saturday 22:00 UTC is Sunday 01:00 at UTC+3
+	var utcHours LogonHours
+	utcHours[6][22] = true
+	if shifted, _ := DecodeLogonHours(utcHours.Encode(0), 3); !shifted[0][1] || shifted.Count() != 1 {
+		t.Errorf("week wrap failed")
+	}
+}
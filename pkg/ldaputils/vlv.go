@@ -0,0 +1,236 @@
+package ldaputils
+
+import (
+	"fmt"
+	"slices"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	ControlTypeVLVRequest  = "2.16.840.1.113730.3.4.9"
+	ControlTypeVLVResponse = "2.16.840.1.113730.3.4.10"
+)
+
+// ControlSortRequest is a critical Server Side Sort request (RFC 2891)
+// by a single attribute. The sort control of the library always sends
+// an ordering rule, which servers reject when it's empty.
+type ControlSortRequest struct {
+	AttributeType string
+	Reverse       bool
+}
+
+func (c *ControlSortRequest) GetControlType() string {
+	return ldap.ControlTypeServerSideSorting
+}
+
+func (c *ControlSortRequest) Encode() *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, ldap.ControlTypeServerSideSorting, "Control Type"))
+	packet.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, true, "Criticality"))
+
+	value := ber.Encode(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, nil, "Control Value(SortKeyList)")
+	keys := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "SortKeyList")
+	key := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "SortKey")
+	key.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, c.AttributeType, "attributeType"))
+	if c.Reverse {
+		key.AppendChild(ber.NewBoolean(ber.ClassContext, ber.TypePrimitive, 1, true, "reverseOrder"))
+	}
+	keys.AppendChild(key)
+	value.AppendChild(keys)
+
+	packet.AppendChild(value)
+	return packet
+}
+
+func (c *ControlSortRequest) String() string {
+	return fmt.Sprintf("Control Type: %s (%q)  Criticality: %t  Sort Key: %s", "Server Side Sorting",
+		ldap.ControlTypeServerSideSorting, true, c.AttributeType)
+}
+
+// ControlVLVRequest asks for a window of a sorted result set
+// (draft-ietf-ldapext-ldapv3-vlv), either around an offset or
+// around the first entry greater than or equal to a value
+type ControlVLVRequest struct {
+	BeforeCount int
+	AfterCount  int
+
+	// 1-based position of the target entry and estimated size of the
+	// result set (0 to let the server use its own), used when
+	// GreaterThanOrEqual is empty
+	Offset       int
+	ContentCount int
+
+	GreaterThanOrEqual string
+
+	ContextID []byte
+}
+
+func (c *ControlVLVRequest) GetControlType() string {
+	return ControlTypeVLVRequest
+}
+
+func (c *ControlVLVRequest) Encode() *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Control")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, ControlTypeVLVRequest, "Control Type"))
+	packet.AppendChild(ber.NewBoolean(ber.ClassUniversal, ber.TypePrimitive, ber.TagBoolean, true, "Criticality"))
+
+	value := ber.Encode(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, nil, "Control Value(VLV)")
+	seq := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "VirtualListViewRequest")
+	seq.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(c.BeforeCount), "beforeCount"))
+	seq.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(c.AfterCount), "afterCount"))
+
+	if c.GreaterThanOrEqual != "" {
+		seq.AppendChild(ber.NewString(ber.ClassContext, ber.TypePrimitive, 1, c.GreaterThanOrEqual, "greaterThanOrEqual"))
+	} else {
+		byOffset := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "byOffset")
+		byOffset.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(c.Offset), "offset"))
+		byOffset.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, int64(c.ContentCount), "contentCount"))
+		seq.AppendChild(byOffset)
+	}
+
+	if len(c.ContextID) > 0 {
+		seq.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, string(c.ContextID), "contextID"))
+	}
+
+	value.AppendChild(seq)
+	packet.AppendChild(value)
+	return packet
+}
+
+func (c *ControlVLVRequest) String() string {
+	return fmt.Sprintf("Control Type: %s (%q)  Criticality: %t  Offset: %d  Count: %d", "Virtual List View",
+		ControlTypeVLVRequest, true, c.Offset, c.AfterCount+1)
+}
+
+// Reads the VLV response control, which the library
+// returns undecoded since it doesn't know its type
+func decodeVLVResponse(controls []ldap.Control) (targetPosition int, contentCount int, contextID []byte, err error) {
+	control, ok := ldap.FindControl(controls, ControlTypeVLVResponse).(*ldap.ControlString)
+	if !ok {
+		return 0, 0, nil, fmt.Errorf("the server didn't return a VLV response")
+	}
+
+	packet, err := ber.DecodePacketErr([]byte(control.ControlValue))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	if len(packet.Children) < 3 {
+		return 0, 0, nil, fmt.Errorf("malformed VLV response")
+	}
+
+	values := make([]int64, 3)
+	for idx := range values {
+		values[idx], ok = packet.Children[idx].Value.(int64)
+		if !ok {
+			return 0, 0, nil, fmt.Errorf("malformed VLV response")
+		}
+	}
+
+	if values[2] != 0 {
+		return 0, 0, nil, fmt.Errorf("VLV request failed: %s", ldap.LDAPResultCodeMap[uint16(values[2])])
+	}
+
+	if len(packet.Children) > 3 {
+		contextID = packet.Children[3].ByteValue
+	}
+
+	return int(values[0]), int(values[1]), contextID, nil
+}
+
+// SupportsControl reports whether the server advertises a control
+// in its RootDSE. The list of controls is read only once.
+func (lc *LDAPConn) SupportsControl(oid string) bool {
+	lc.cacheLock.Lock()
+	controls := lc.supportedControls
+	lc.cacheLock.Unlock()
+
+	if controls == nil {
+		err := lc.retryRead(func(conn *ldap.Conn) (err error) {
+			controls, err = getSupportedControl(conn)
+			return err
+		})
+		if err != nil {
+			return false
+		}
+
+		lc.cacheLock.Lock()
+		lc.supportedControls = controls
+		lc.cacheLock.Unlock()
+	}
+
+	return slices.Contains(controls, oid)
+}
+
+// SupportsVLV reports whether the server supports both the
+// Server Side Sort and the Virtual List View controls
+func (lc *LDAPConn) SupportsVLV() bool {
+	return lc.SupportsControl(ldap.ControlTypeServerSideSorting) &&
+		lc.SupportsControl(ControlTypeVLVRequest)
+}
+
+// VLVSortAttribute is the attribute children windows are sorted by
+func (lc *LDAPConn) VLVSortAttribute() string {
+	if lc.Flavor == MicrosoftADFlavor {
+		return "name"
+	}
+
+	return "cn"
+}
+
+// ChildrenWindow is a range of the children of an object sorted by name
+type ChildrenWindow struct {
+	Entries []*ldap.Entry
+
+	// 1-based position of the first entry among all
+	// children, and how many children the server counted
+	Offset int
+	Total  int
+
+	ContextID []byte
+}
+
+// QueryChildrenWindow reads up to count children of an object sorted by
+// name, starting at offset or, if startsWith is not empty, at the
+// first child whose name is greater than or equal to it
+func (lc *LDAPConn) QueryChildrenWindow(baseDN string, filter string, offset int, startsWith string, count int, contextID []byte, showDeleted bool) (*ChildrenWindow, error) {
+	controls := []ldap.Control{
+		&ControlSortRequest{AttributeType: lc.VLVSortAttribute()},
+		&ControlVLVRequest{
+			AfterCount:         count - 1,
+			Offset:             offset,
+			GreaterThanOrEqual: startsWith,
+			ContextID:          contextID,
+		},
+	}
+
+	if showDeleted {
+		controls = append(controls, ldap.NewControlMicrosoftShowDeleted())
+	}
+
+	req := ldap.NewSearchRequest(
+		baseDN,
+		ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 0, 0, false,
+		filter, []string{}, controls,
+	)
+
+	result, err := lc.search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	position, total, newContextID, err := decodeVLVResponse(result.Controls)
+	if err != nil {
+		return nil, err
+	}
+
+	// The target is the first entry of the window, since none come before it
+	return &ChildrenWindow{
+		Entries:   result.Entries,
+		Offset:    position,
+		Total:     total,
+		ContextID: newContextID,
+	}, nil
+}
@@ -0,0 +1,74 @@
+package ldaputils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Hex of an OCTET STRING holding a short string
+func octetString(s string) string {
+	return hex.EncodeToString([]byte{0x04, byte(len(s))}) + hex.EncodeToString([]byte(s))
+}
+
+func TestControlEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		control ldap.Control
+		want    string
+	}{
+		{
+			"sort",
+			&ControlSortRequest{AttributeType: "cn", Reverse: true},
+			"3028" + octetString(ldap.ControlTypeServerSideSorting) + "010101" +
+				"040b" + "3009" + "3007" + octetString("cn") + "810101",
+		},
+		{
+			"vlv by offset",
+			&ControlVLVRequest{AfterCount: 49, Offset: 1, ContextID: []byte("ab")},
+			"3032" + octetString(ControlTypeVLVRequest) + "010101" +
+				"0414" + "3012" + "020100" + "020131" + "a006020101020100" + octetString("ab"),
+		},
+		{
+			"vlv by value",
+			&ControlVLVRequest{AfterCount: 49, GreaterThanOrEqual: "m"},
+			"3029" + octetString(ControlTypeVLVRequest) + "010101" +
+				"040b" + "3009" + "020100" + "020131" + "81016d",
+		},
+	}
+
+	for _, test := range tests {
+		got := hex.EncodeToString(test.control.Encode().Bytes())
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecodeVLVResponse(t *testing.T) {
+	// targetPosition 5, contentCount 120, success, contextID "ctx"
+	value, _ := hex.DecodeString("300e" + "020105" + "020178" + "0a0100" + octetString("ctx"))
+	response := &ldap.ControlString{ControlType: ControlTypeVLVResponse, ControlValue: string(value)}
+
+	position, count, contextID, err := decodeVLVResponse([]ldap.Control{response})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if position != 5 || count != 120 || !bytes.Equal(contextID, []byte("ctx")) {
+		t.Errorf("got position %d, count %d, context %q", position, count, contextID)
+	}
+
+	// Errors reported by the server in the response
+	value, _ = hex.DecodeString("3009" + "020100" + "020100" + "0a014c")
+	response.ControlValue = string(value)
+	if _, _, _, err := decodeVLVResponse([]ldap.Control{response}); err == nil {
+		t.Errorf("VLV error not reported")
+	}
+
+	if _, _, _, err := decodeVLVResponse(nil); err == nil {
+		t.Errorf("missing VLV response not reported")
+	}
+}
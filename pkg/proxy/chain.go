@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// Dialer is implemented by every hop of a chain
+// (and by net.Dialer, used for the first hop)
+type Dialer interface {
+	Dial(network, address string) (net.Conn, error)
+}
+
+// Chain dials addresses through a sequence of proxies, where each
+// hop is reached through the hops specified before it. Supported schemes:
+//
+//	socks4://host:port
+//	socks4a://host:port
+//	socks5://[user:pass@]host:port
+//	http://[user:pass@]host:port (HTTP CONNECT)
+//	ssh://user@host:port[?key=/path/to/key][&insecure=1]
+type Chain struct {
+	Hops []*url.URL
+
+	dialer  Dialer
+	closers []func() error
+}
+
+// ParseChain parses a comma-separated list of proxy URIs,
+// such as "socks5://a:1080,http://b:3128"
+func ParseChain(spec string, timeout time.Duration) (*Chain, error) {
+	chain := &Chain{}
+
+	var dialer Dialer = &net.Dialer{Timeout: timeout}
+
+	for _, hopSpec := range strings.Split(spec, ",") {
+		hopSpec = strings.TrimSpace(hopSpec)
+		if hopSpec == "" {
+			continue
+		}
+
+		hop, err := url.Parse(hopSpec)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid proxy '%s': %w", hopSpec, err)
+		}
+
+		if hop.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy '%s': missing host", hopSpec)
+		}
+
+		switch strings.ToLower(hop.Scheme) {
+		case "socks4":
+			dialer = &socks4Dialer{proxyAddr: hop.Host, userID: hop.User.Username(), forward: dialer}
+		case "socks4a":
+			dialer = &socks4Dialer{proxyAddr: hop.Host, userID: hop.User.Username(), forward: dialer, remoteDNS: true}
+		case "socks5", "socks5h":
+			var auth *xproxy.Auth
+			if hop.User != nil {
+				password, _ := hop.User.Password()
+				auth = &xproxy.Auth{User: hop.User.Username(), Password: password}
+			}
+
+			dialer, err = xproxy.SOCKS5("tcp", hop.Host, auth, dialer)
+			if err != nil {
+				return nil, err
+			}
+		case "http":
+			dialer = &httpConnectDialer{proxyAddr: hop.Host, user: hop.User, forward: dialer}
+		case "ssh":
+			sshHop, err := newSSHDialer(hop, dialer, timeout)
+			if err != nil {
+				return nil, err
+			}
+
+			chain.closers = append(chain.closers, sshHop.Close)
+			dialer = sshHop
+		default:
+			return nil, fmt.Errorf("Unsupported proxy scheme '%s'", hop.Scheme)
+		}
+
+		chain.Hops = append(chain.Hops, hop)
+	}
+
+	if len(chain.Hops) == 0 {
+		return nil, fmt.Errorf("Empty proxy chain")
+	}
+
+	chain.dialer = dialer
+	return chain, nil
+}
+
+func (c *Chain) Dial(network, address string) (net.Conn, error) {
+	return c.dialer.Dial(network, address)
+}
+
+// Close tears down the persistent connections of the chain (SSH hops)
+func (c *Chain) Close() error {
+	var firstErr error
+	for _, closer := range c.closers {
+		if err := closer(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (c *Chain) String() string {
+	hops := make([]string, 0, len(c.Hops))
+	for _, hop := range c.Hops {
+		hops = append(hops, hop.Scheme+"://"+hop.Host)
+	}
+
+	return strings.Join(hops, " -> ")
+}
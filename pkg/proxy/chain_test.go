@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T, handle func(net.Conn)) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func echoServer(t *testing.T) string {
+	return listen(t, func(conn net.Conn) {
+		defer conn.Close()
+		io.Copy(conn, conn)
+	})
+}
+
+func pipe(a, b net.Conn) {
+	go io.Copy(a, b)
+	io.Copy(b, a)
+}
+
+// Minimal HTTP CONNECT proxy requiring basic auth
+func httpProxy(t *testing.T, user, password string) string {
+	expectedAuth := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+
+	return listen(t, func(conn net.Conn) {
+		defer conn.Close()
+
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil || req.Method != http.MethodConnect {
+			return
+		}
+
+		if req.Header.Get("Proxy-Authorization") != expectedAuth {
+			conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n"))
+			return
+		}
+
+		target, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			conn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+			return
+		}
+		defer target.Close()
+
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		pipe(conn, target)
+	})
+}
+
+// Minimal SOCKS4a proxy that records the requested hostnames
+func socks4aProxy(t *testing.T, hosts chan<- string) string {
+	return listen(t, func(conn net.Conn) {
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return
+		}
+
+		if _, err := reader.ReadString(0); err != nil { // user ID
+			return
+		}
+
+		port := int(header[2])<<8 | int(header[3])
+		host := net.IP(header[4:8]).String()
+		if header[4] == 0 && header[5] == 0 && header[6] == 0 {
+			name, err := reader.ReadString(0)
+			if err != nil {
+				return
+			}
+			host = name[:len(name)-1]
+		}
+		hosts <- host
+
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			conn.Write([]byte{0, 0x5b, 0, 0, 0, 0, 0, 0})
+			return
+		}
+		defer target.Close()
+
+		conn.Write([]byte{0, 0x5a, 0, 0, 0, 0, 0, 0})
+		pipe(conn, target)
+	})
+}
+
+func roundTrip(t *testing.T, dial func(string, string) (net.Conn, error), address string) {
+	conn, err := dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(buf) != "ping" {
+		t.Errorf("got %q, want %q", buf, "ping")
+	}
+}
+
+func TestChainHTTPThenSOCKS4a(t *testing.T) {
+	_, echoPort, _ := net.SplitHostPort(echoServer(t))
+
+	hosts := make(chan string, 1)
+	socksAddr := socks4aProxy(t, hosts)
+	httpAddr := httpProxy(t, "user", "p@ss")
+
+	chain, err := ParseChain("http://user:p%40ss@"+httpAddr+",socks4a://"+socksAddr, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Close()
+
+	if len(chain.Hops) != 2 {
+		t.Fatalf("got %d hops, want 2", len(chain.Hops))
+	}
+
+	roundTrip(t, chain.Dial, net.JoinHostPort("localhost", echoPort))
+
+	if host := <-hosts; host != "localhost" {
+		t.Errorf("SOCKS4a proxy got host %q, want the unresolved hostname", host)
+	}
+}
+
+func TestHTTPProxyAuthFailure(t *testing.T) {
+	chain, err := ParseChain("http://user:wrong@"+httpProxy(t, "user", "right"), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := chain.Dial("tcp", echoServer(t)); err == nil {
+		t.Error("expected the proxy to reject invalid credentials")
+	}
+}
+
+func TestForwarder(t *testing.T) {
+	chain, err := ParseChain("http://u:p@"+httpProxy(t, "u", "p"), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forwarder, err := NewForwarder(chain.Dial, echoServer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer forwarder.Close()
+
+	roundTrip(t, net.Dial, forwarder.Addr().String())
+}
+
+func TestParseChainErrors(t *testing.T) {
+	for _, spec := range []string{"", "ftp://host:21", "socks5://", "ssh://host:22"} {
+		if _, err := ParseChain(spec, time.Second); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"io"
+	"net"
+)
+
+// Forwarder is a local TCP listener that relays every accepted
+// connection to a fixed target through a dialer. It allows libraries
+// that only dial plain TCP (e.g. Kerberos clients) to use a proxy chain.
+type Forwarder struct {
+	listener net.Listener
+	dial     func(network, address string) (net.Conn, error)
+	target   string
+}
+
+func NewForwarder(dial func(network, address string) (net.Conn, error), target string) (*Forwarder, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Forwarder{listener: listener, dial: dial, target: target}
+	go f.serve()
+
+	return f, nil
+}
+
+func (f *Forwarder) Addr() *net.TCPAddr {
+	return f.listener.Addr().(*net.TCPAddr)
+}
+
+func (f *Forwarder) serve() {
+	for {
+		local, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer local.Close()
+
+			remote, err := f.dial("tcp", f.target)
+			if err != nil {
+				return
+			}
+			defer remote.Close()
+
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(remote, local)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(local, remote)
+				done <- struct{}{}
+			}()
+
+			<-done
+		}()
+	}
+}
+
+func (f *Forwarder) Close() error {
+	return f.listener.Close()
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// HTTP CONNECT client with optional basic auth
+type httpConnectDialer struct {
+	proxyAddr string
+	user      *url.Userinfo
+	forward   Dialer
+}
+
+// Keeps the bytes the server may have sent right after
+// the CONNECT response, which were buffered by the reader
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (d *httpConnectDialer) Dial(network, address string) (net.Conn, error) {
+	conn, err := d.forward.Dial("tcp", d.proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+
+	if d.user != nil {
+		password, _ := d.user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("HTTP proxy %s refused the connection to %s: %s", d.proxyAddr, address, resp.Status)
+	}
+
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
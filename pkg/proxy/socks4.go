@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// SOCKS4/4a client that can be chained after other hops
+type socks4Dialer struct {
+	proxyAddr string
+	userID    string
+	remoteDNS bool
+	forward   Dialer
+}
+
+func (d *socks4Dialer) Dial(network, address string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid port '%s'", portStr)
+	}
+
+	// SOCKS4 only accepts IPv4 addresses, while SOCKS4a
+	// lets the proxy resolve the hostname
+	ip := net.ParseIP(host).To4()
+	if ip == nil && !d.remoteDNS {
+		addrs, err := net.LookupIP(host)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, addr := range addrs {
+			if ip = addr.To4(); ip != nil {
+				break
+			}
+		}
+
+		if ip == nil {
+			return nil, fmt.Errorf("No IPv4 address found for '%s'", host)
+		}
+	}
+
+	req := []byte{4, 1, 0, 0}
+	binary.BigEndian.PutUint16(req[2:], uint16(port))
+
+	if ip != nil {
+		req = append(req, ip...)
+		req = append(req, d.userID...)
+		req = append(req, 0)
+	} else {
+		req = append(req, 0, 0, 0, 1)
+		req = append(req, d.userID...)
+		req = append(req, 0)
+		req = append(req, host...)
+		req = append(req, 0)
+	}
+
+	conn, err := d.forward.Dial("tcp", d.proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(req); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp := make([]byte, 8)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if resp[1] != 0x5a {
+		conn.Close()
+		return nil, fmt.Errorf("SOCKS4 proxy %s rejected the connection to %s (code 0x%02x)", d.proxyAddr, address, resp[1])
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSH jump host that forwards connections like `ssh -J` / `ssh -D`.
+// The SSH connection is kept open and reused by every dial.
+type sshDialer struct {
+	hostAddr string
+	config   *ssh.ClientConfig
+	forward  Dialer
+
+	client *ssh.Client
+	lock   sync.Mutex
+}
+
+var defaultKeyFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+func newSSHDialer(hop *url.URL, forward Dialer, timeout time.Duration) (*sshDialer, error) {
+	if hop.User == nil || hop.User.Username() == "" {
+		return nil, fmt.Errorf("SSH proxy '%s' requires a username", hop.Host)
+	}
+
+	hostAddr := hop.Host
+	if hop.Port() == "" {
+		hostAddr = net.JoinHostPort(hop.Hostname(), "22")
+	}
+
+	query := hop.Query()
+	homeDir, _ := os.UserHomeDir()
+
+	var authMethods []ssh.AuthMethod
+
+	keyFiles := []string{query.Get("key")}
+	if keyFiles[0] == "" {
+		keyFiles = nil
+		for _, keyFile := range defaultKeyFiles {
+			keyFiles = append(keyFiles, filepath.Join(homeDir, ".ssh", keyFile))
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, keyFile := range keyFiles {
+		keyData, err := os.ReadFile(keyFile)
+		if err != nil {
+			if query.Get("key") != "" {
+				return nil, err
+			}
+			continue
+		}
+
+		signer, err := ssh.ParsePrivateKey(keyData)
+		if err != nil {
+			return nil, fmt.Errorf("SSH key '%s': %w", keyFile, err)
+		}
+
+		signers = append(signers, signer)
+	}
+
+	if len(signers) > 0 {
+		authMethods = append(authMethods, ssh.PublicKeys(signers...))
+	}
+
+	if password, ok := hop.User.Password(); ok {
+		authMethods = append(authMethods, ssh.Password(password))
+	}
+
+	if len(authMethods) == 0 {
+		return nil, fmt.Errorf("No SSH key or password available for '%s'", hop.Host)
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if query.Get("insecure") == "" {
+		knownHostsFile := query.Get("known_hosts")
+		if knownHostsFile == "" {
+			knownHostsFile = filepath.Join(homeDir, ".ssh", "known_hosts")
+		}
+
+		var err error
+		hostKeyCallback, err = knownhosts.New(knownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load known hosts for SSH proxy (use ?insecure=1 to skip host key checks): %w", err)
+		}
+	}
+
+	return &sshDialer{
+		hostAddr: hostAddr,
+		forward:  forward,
+		config: &ssh.ClientConfig{
+			User:            hop.User.Username(),
+			Auth:            authMethods,
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         timeout,
+		},
+	}, nil
+}
+
+func (d *sshDialer) connect() (*ssh.Client, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.client != nil {
+		return d.client, nil
+	}
+
+	conn, err := d.forward.Dial("tcp", d.hostAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, d.hostAddr, d.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	d.client = ssh.NewClient(clientConn, chans, reqs)
+	return d.client, nil
+}
+
+func (d *sshDialer) Dial(network, address string) (net.Conn, error) {
+	client, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := client.Dial(network, address)
+	if err == nil {
+		return conn, nil
+	}
+
+	// The SSH connection may have been dropped,
+	// so reconnect once before giving up
+	d.Close()
+
+	client, err = d.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Dial(network, address)
+}
+
+func (d *sshDialer) Close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.client == nil {
+		return nil
+	}
+
+	err := d.client.Close()
+	d.client = nil
+	return err
+}
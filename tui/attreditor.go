@@ -0,0 +1,358 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+// Syntaxes offered by the editor, in the order of its dropdown
+var editorSyntaxes = []ldaputils.AttributeSyntax{
+	ldaputils.SyntaxString,
+	ldaputils.SyntaxInteger,
+	ldaputils.SyntaxBoolean,
+	ldaputils.SyntaxDN,
+	ldaputils.SyntaxGeneralizedTime,
+	ldaputils.SyntaxFileTime,
+	ldaputils.SyntaxSID,
+	ldaputils.SyntaxGUID,
+	ldaputils.SyntaxBinary,
+}
+
+// Opens the editor of an attribute matching its syntax. Multi-valued
+// attributes are edited as a list, one value per line, and saved
+// by adding and deleting only the values that changed.
+func openAttributeEditor(baseDN string, entry *ldap.Entry, attrName string, done func()) {
+	if flags, ok := ldaputils.FlagAttributeOf(attrName); ok {
+		openFlagsEditor(baseDN, attrName, entry.GetEqualFoldAttributeValue(attrName), flags, done)
+		return
+	}
+
+	if strings.EqualFold(attrName, "logonHours") {
+		openLogonHoursEditor(baseDN, entry.GetEqualFoldRawAttributeValue(attrName), done)
+		return
+	}
+
+	schema := lc.AttributeSchema(attrName)
+	rawValues := entry.GetEqualFoldRawAttributeValues(attrName)
+
+	multiValued := !schema.SingleValued && (schema.Known || len(rawValues) > 1)
+	openTypedAttributeEditor(baseDN, attrName, rawValues, schema.Syntax, multiValued, done)
+}
+
+func openTypedAttributeEditor(baseDN string, attrName string, rawValues [][]byte, syntax ldaputils.AttributeSyntax, multiValued bool, done func()) {
+	currentFocus := app.GetFocus()
+
+	var currentValues []string
+	for _, raw := range rawValues {
+		currentValues = append(currentValues, string(raw))
+	}
+
+	syntaxNames := make([]string, len(editorSyntaxes))
+	for idx, option := range editorSyntaxes {
+		syntaxNames[idx] = option.String()
+	}
+
+	editorForm := NewXForm()
+	editorForm.SetItemPadding(0)
+	editorForm.
+		AddTextView("Object DN", baseDN, 0, 1, false, true).
+		AddTextView("Attribute", attrName, 0, 1, false, true).
+		AddDropDown("Syntax", syntaxNames, slices.Index(editorSyntaxes, syntax), nil)
+
+	// Reads the new values from the editor, already encoded
+	var readValues func() ([]string, error)
+
+	if multiValued {
+		readValues = addListEditor(editorForm, rawValues, syntax)
+	} else {
+		var raw []byte
+		if len(rawValues) > 0 {
+			raw = rawValues[0]
+		}
+
+		readValues = addValueEditor(editorForm, raw, syntax)
+	}
+
+	editorForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Update", func() {
+			newValues, err := readValues()
+			if err != nil {
+				updateLog(fmt.Sprint(err), "red")
+				return
+			}
+
+			if multiValued {
+				var toDelete, toAdd []string
+				for _, value := range currentValues {
+					if !slices.Contains(newValues, value) {
+						toDelete = append(toDelete, value)
+					}
+				}
+
+				for _, value := range newValues {
+					if !slices.Contains(currentValues, value) {
+						toAdd = append(toAdd, value)
+					}
+				}
+
+				err = lc.ModifyAttributeValues(baseDN, attrName, toDelete, toAdd)
+			} else {
+				err = lc.ModifyAttribute(baseDN, attrName, newValues)
+			}
+
+			if err != nil {
+				updateLog(fmt.Sprint(err), "red")
+				return
+			}
+
+			updateLog("Attribute updated: '"+attrName+"' from '"+baseDN+"'", "green")
+			if done != nil {
+				done()
+			}
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	// Changing the syntax opens the values with the other editor
+	syntaxInput := editorForm.GetFormItemByLabel("Syntax").(*tview.DropDown)
+	syntaxInput.SetSelectedFunc(func(text string, index int) {
+		if index >= 0 && editorSyntaxes[index] != syntax {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+			openTypedAttributeEditor(baseDN, attrName, rawValues, editorSyntaxes[index], multiValued, done)
+		}
+	})
+
+	title := "Attribute Editor"
+	if multiValued {
+		title += " (one value per line)"
+	}
+
+	editorForm.SetInputCapture(handleEscape(currentFocus))
+	editorForm.SetTitle(title).SetBorder(true)
+	app.SetRoot(editorForm, true).SetFocus(editorForm)
+}
+
+// Adds the field that edits a single value and returns
+// the function that reads it back encoded
+func addValueEditor(editorForm *XForm, raw []byte, syntax ldaputils.AttributeSyntax) func() ([]string, error) {
+	text := ldaputils.DecodeAttributeValue(syntax, raw)
+
+	switch syntax {
+	case ldaputils.SyntaxBoolean:
+		editorForm.AddCheckbox("Value", strings.EqualFold(text, "TRUE"), nil)
+		return func() ([]string, error) {
+			if editorForm.GetFormItemByLabel("Value").(*tview.Checkbox).IsChecked() {
+				return []string{"TRUE"}, nil
+			}
+			return []string{"FALSE"}, nil
+		}
+	case ldaputils.SyntaxBinary:
+		editorForm.AddTextArea("Value (HEX)", ldaputils.HexDump(raw), 0, 8, 0, nil)
+		return func() ([]string, error) {
+			hexText := editorForm.GetFormItemByLabel("Value (HEX)").(*tview.TextArea).GetText()
+			value, err := ldaputils.EncodeAttributeValue(syntax, hexText)
+			if err != nil {
+				return nil, err
+			}
+
+			return []string{value}, nil
+		}
+	}
+
+	label := "Value"
+	switch syntax {
+	case ldaputils.SyntaxGeneralizedTime, ldaputils.SyntaxFileTime:
+		label = "Value (UTC)"
+	case ldaputils.SyntaxSID:
+		label = "Value (S-1-...)"
+	}
+
+	editorForm.AddInputField(label, text, 0, nil, nil)
+	valueInput := editorForm.GetFormItemByLabel(label).(*tview.InputField)
+	addValueHelpers(editorForm, valueInput, syntax, func(value string) {
+		valueInput.SetText(value)
+	})
+
+	valueInput.SetChangedFunc(func(text string) {
+		_, err := ldaputils.EncodeAttributeValue(syntax, text)
+		if err != nil {
+			valueInput.SetFieldTextColor(tcell.ColorRed)
+		} else {
+			valueInput.SetFieldTextColor(tview.Styles.PrimaryTextColor)
+		}
+	})
+
+	return func() ([]string, error) {
+		value, err := ldaputils.EncodeAttributeValue(syntax, valueInput.GetText())
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{value}, nil
+	}
+}
+
+// Adds a text area with one value per line and returns
+// the function that reads them back encoded
+func addListEditor(editorForm *XForm, rawValues [][]byte, syntax ldaputils.AttributeSyntax) func() ([]string, error) {
+	var lines []string
+	for _, raw := range rawValues {
+		lines = append(lines, ldaputils.DecodeAttributeValue(syntax, raw))
+	}
+
+	editorForm.AddTextArea("Values", strings.Join(lines, "\n"), 0, 12, 0, nil)
+	valuesInput := editorForm.GetFormItemByLabel("Values").(*tview.TextArea)
+
+	addValueHelpers(editorForm, nil, syntax, func(value string) {
+		text := strings.TrimRight(valuesInput.GetText(), "\n")
+		if text != "" {
+			text += "\n"
+		}
+		valuesInput.SetText(text+value, true)
+	})
+
+	return func() ([]string, error) {
+		return encodeEditorValues(syntax, valuesInput.GetText())
+	}
+}
+
+// Encodes the values of a text area, one per line
+func encodeEditorValues(syntax ldaputils.AttributeSyntax, text string) ([]string, error) {
+	var values []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		value, err := ldaputils.EncodeAttributeValue(syntax, line)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// Adds the buttons that fill in common values of a syntax:
+// the current time, "never" and DNs picked from the tree
+func addValueHelpers(editorForm *XForm, input *tview.InputField, syntax ldaputils.AttributeSyntax, setValue func(string)) {
+	switch syntax {
+	case ldaputils.SyntaxGeneralizedTime, ldaputils.SyntaxFileTime:
+		editorForm.AddButton("Now", func() {
+			setValue(time.Now().UTC().Format(ldaputils.EditorTimeLayout))
+		})
+
+		if syntax == ldaputils.SyntaxFileTime {
+			editorForm.AddButton("Never", func() {
+				setValue(ldaputils.FileTimeNever)
+			})
+		}
+	case ldaputils.SyntaxDN:
+		if input != nil {
+			input.SetAutocompleteFunc(dnCompletions)
+		}
+
+		editorForm.AddButton("Browse", func() {
+			openDNPicker(editorForm, func(dn string) {
+				setValue(dn)
+			})
+		})
+	}
+}
+
+// Suggests the DNs loaded in the explorer that contain the text
+func dnCompletions(currentText string) []string {
+	if currentText == "" {
+		return nil
+	}
+
+	lowerText := strings.ToLower(currentText)
+
+	var matches []string
+	for _, dn := range explorerCache.Keys() {
+		if strings.Contains(strings.ToLower(dn), lowerText) {
+			matches = append(matches, dn)
+		}
+	}
+
+	slices.Sort(matches)
+	return matches[:min(len(matches), 20)]
+}
+
+// Shows a tree of the directory to pick a DN from, returning
+// to the form it was opened from when done or cancelled
+func openDNPicker(returnTo tview.Primitive, done func(dn string)) {
+	pickerRoot := tview.NewTreeNode(lc.RootDN).
+		SetReference(lc.RootDN).
+		SetSelectable(true)
+
+	loadPickerChildren := func(node *tview.TreeNode) {
+		if len(node.GetChildren()) > 0 {
+			node.SetExpanded(!node.IsExpanded())
+			return
+		}
+
+		entries, err := lc.Query(node.GetReference().(string), "(objectClass=*)", ldap.ScopeSingleLevel, false)
+		if err != nil {
+			updateLog(fmt.Sprint(err), "red")
+			return
+		}
+
+		sort.Slice(entries, func(i int, j int) bool {
+			return getName(entries[i]) < getName(entries[j])
+		})
+
+		for _, entry := range entries {
+			node.AddChild(tview.NewTreeNode(getNodeName(entry)).
+				SetReference(entry.DN).
+				SetSelectable(true))
+		}
+
+		node.SetExpanded(true)
+	}
+
+	pickerTree := tview.NewTreeView().
+		SetRoot(pickerRoot).
+		SetCurrentNode(pickerRoot)
+
+	pickerTree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		currentNode := pickerTree.GetCurrentNode()
+		if currentNode == nil {
+			return event
+		}
+
+		switch event.Key() {
+		case tcell.KeyRight:
+			loadPickerChildren(currentNode)
+			return nil
+		case tcell.KeyLeft:
+			currentNode.SetExpanded(false)
+			return nil
+		case tcell.KeyEnter:
+			done(currentNode.GetReference().(string))
+			app.SetRoot(returnTo, true).SetFocus(returnTo)
+			return nil
+		case tcell.KeyEscape:
+			app.SetRoot(returnTo, true).SetFocus(returnTo)
+			return nil
+		}
+
+		return event
+	})
+
+	pickerTree.SetTitle("Pick an Object (Right to expand, Enter to select)").SetBorder(true)
+	app.SetRoot(pickerTree, true).SetFocus(pickerTree)
+}
@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+// Maximum number of objects kept in the back history
+const maxNavigationHistory = 100
+
+// Width of the bookmarks list beside the tree
+const bookmarksWidth = 30
+
+var (
+	bookmarksPanel    *tview.List
+	explorerBookmarks []string
+
+	// Objects left by jumps in the explorer, to go back and forward
+	navigationBack    []string
+	navigationForward []string
+)
+
+// Selects an object in the explorer, recording the
+// object selected before it in the back history
+func jumpToDN(dn string) error {
+	var from string
+	if currentNode := treePanel.GetCurrentNode(); currentNode != nil {
+		from, _ = currentNode.GetReference().(string)
+	}
+
+	if err := navigateToDN(dn); err != nil {
+		return err
+	}
+
+	if from != "" && !strings.EqualFold(from, dn) {
+		navigationBack = append(navigationBack, from)
+		if len(navigationBack) > maxNavigationHistory {
+			navigationBack = navigationBack[1:]
+		}
+		navigationForward = nil
+	}
+
+	return nil
+}
+
+// Moves one object back or forward in the history, moving
+// the current object to the opposite history
+func navigateHistory(back bool) {
+	from, to := &navigationForward, &navigationBack
+	direction := "back"
+	if !back {
+		from, to = &navigationBack, &navigationForward
+		direction = "forward"
+	}
+
+	if len(*to) == 0 {
+		updateLog("No objects to go "+direction+" to", "yellow")
+		return
+	}
+
+	var current string
+	if currentNode := treePanel.GetCurrentNode(); currentNode != nil {
+		current, _ = currentNode.GetReference().(string)
+	}
+
+	dn := (*to)[len(*to)-1]
+	if err := navigateToDN(dn); err != nil {
+		updateLog(fmt.Sprint(err), "red")
+		return
+	}
+
+	*to = (*to)[:len(*to)-1]
+	if current != "" {
+		*from = append(*from, current)
+	}
+}
+
+// Asks for a DN and expands the explorer up to it
+func openGoToDNForm() {
+	currentFocus := app.GetFocus()
+
+	goToForm := NewXForm()
+	goToForm.
+		AddInputField("DN", "", 0, nil, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Go", func() {
+			dn := strings.TrimSpace(goToForm.GetFormItemByLabel("DN").(*tview.InputField).GetText())
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+			if err := jumpToDN(dn); err != nil {
+				updateLog(fmt.Sprint(err), "red")
+			}
+		})
+
+	goToForm.GetFormItemByLabel("DN").(*tview.InputField).SetAutocompleteFunc(dnCompletions)
+
+	goToForm.SetInputCapture(handleEscape(currentFocus))
+	goToForm.SetTitle("Go to DN").SetBorder(true)
+	app.SetRoot(goToForm, true).SetFocus(goToForm)
+}
+
+// Path of the file keeping the bookmarks of the current domain
+func bookmarksPath() (string, error) {
+	return domainConfigPath("bookmarks")
+}
+
+// Replaces the bookmarks in memory by the ones stored for the current domain
+func loadBookmarks() {
+	explorerBookmarks = nil
+
+	path, err := bookmarksPath()
+	if err == nil {
+		data, readErr := os.ReadFile(path)
+		if readErr == nil {
+			err = json.Unmarshal(data, &explorerBookmarks)
+		} else if !errors.Is(readErr, fs.ErrNotExist) {
+			err = readErr
+		}
+	}
+
+	if err != nil {
+		updateLog(fmt.Sprintf("Error loading bookmarks: %s", err), "red")
+	}
+
+	navigationBack = nil
+	navigationForward = nil
+
+	updateBookmarksPanel()
+}
+
+func saveBookmarks() {
+	path, err := bookmarksPath()
+	if err != nil {
+		return
+	}
+
+	data, _ := json.MarshalIndent(explorerBookmarks, "", " ")
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err == nil {
+		err = os.WriteFile(path, data, 0600)
+	}
+
+	if err != nil {
+		updateLog(fmt.Sprintf("Error saving bookmarks: %s", err), "red")
+	}
+}
+
+// Adds the object to the bookmarks, or removes it if it's there
+func toggleBookmark(dn string) {
+	idx := slices.IndexFunc(explorerBookmarks, func(bookmark string) bool {
+		return strings.EqualFold(bookmark, dn)
+	})
+
+	if idx >= 0 {
+		explorerBookmarks = slices.Delete(explorerBookmarks, idx, idx+1)
+		updateLog("Bookmark removed: '"+dn+"'", "green")
+	} else {
+		explorerBookmarks = append(explorerBookmarks, dn)
+		updateLog("Bookmark added: '"+dn+"'", "green")
+	}
+
+	saveBookmarks()
+	updateBookmarksPanel()
+}
+
+// Name of a bookmark in the list (the value of its first RDN)
+func bookmarkName(dn string) string {
+	parsedDN, err := ldap.ParseDN(dn)
+	if err != nil || len(parsedDN.RDNs) == 0 || len(parsedDN.RDNs[0].Attributes) == 0 {
+		return dn
+	}
+
+	return parsedDN.RDNs[0].Attributes[0].Value
+}
+
+// Lists the bookmarks beside the tree, hiding the list when empty
+func updateBookmarksPanel() {
+	bookmarksPanel.Clear()
+	for _, dn := range explorerBookmarks {
+		bookmarksPanel.AddItem(bookmarkName(dn), dn, 0, nil)
+	}
+
+	width := 0
+	if len(explorerBookmarks) > 0 {
+		width = bookmarksWidth
+	} else if app.GetFocus() == bookmarksPanel {
+		app.SetFocus(treePanel)
+	}
+
+	explorerPage.ResizeItem(bookmarksPanel, width, 0)
+}
+
+func initBookmarksPanel() {
+	bookmarksPanel = tview.NewList().ShowSecondaryText(false)
+	bookmarksPanel.SetTitle("Bookmarks").SetBorder(true)
+
+	bookmarksPanel.SetSelectedFunc(func(idx int, name string, dn string, shortcut rune) {
+		if err := jumpToDN(dn); err != nil {
+			updateLog(fmt.Sprint(err), "red")
+		}
+	})
+
+	bookmarksPanel.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyDelete && bookmarksPanel.GetItemCount() > 0 {
+			_, dn := bookmarksPanel.GetItemText(bookmarksPanel.GetCurrentItem())
+			toggleBookmark(dn)
+			return nil
+		}
+
+		return event
+	})
+}
@@ -0,0 +1,766 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+// Prefix of the nodes of marked objects
+const markPrefix = "✓ "
+
+// Marks holds the DNs of the objects marked for
+// bulk actions, in the order they were marked
+type Marks struct {
+	dns []string
+}
+
+func (m *Marks) index(dn string) int {
+	return slices.IndexFunc(m.dns, func(marked string) bool {
+		return strings.EqualFold(marked, dn)
+	})
+}
+
+func (m *Marks) Has(dn string) bool {
+	return m.index(dn) >= 0
+}
+
+func (m *Marks) Add(dn string) {
+	if !m.Has(dn) {
+		m.dns = append(m.dns, dn)
+	}
+}
+
+func (m *Marks) Remove(dn string) {
+	if idx := m.index(dn); idx >= 0 {
+		m.dns = slices.Delete(m.dns, idx, idx+1)
+	}
+}
+
+func (m *Marks) Clear() {
+	m.dns = nil
+}
+
+func (m *Marks) Len() int {
+	return len(m.dns)
+}
+
+func (m *Marks) DNs() []string {
+	return slices.Clone(m.dns)
+}
+
+var (
+	explorerMarks Marks
+	searchMarks   Marks
+)
+
+// Name of the node of an object, prefixed when it's marked
+func markedNodeName(entry *ldap.Entry, marks *Marks) string {
+	if marks.Has(entry.DN) {
+		return markPrefix + getNodeName(entry)
+	}
+
+	return getNodeName(entry)
+}
+
+// Updates the names of the nodes of a tree after the marks changed
+func renderMarks(tree *tview.TreeView, cache *EntryCache, marks *Marks) {
+	root := tree.GetRoot()
+	if root == nil {
+		return
+	}
+
+	root.Walk(func(node, parent *tview.TreeNode) bool {
+		if dn, ok := node.GetReference().(string); ok {
+			if entry, ok := cache.Get(dn); ok {
+				node.SetText(markedNodeName(entry, marks))
+			}
+		}
+		return true
+	})
+}
+
+// The page whose marked objects a bulk action acts on
+type bulkTarget struct {
+	marks *Marks
+	cache *EntryCache
+
+	// Shows the marks of the page again
+	render func()
+
+	// Updates the page after an action, called from
+	// the goroutine of the action with the objects changed
+	refresh func(action bulkAction, changed []string)
+}
+
+func explorerBulkTarget() bulkTarget {
+	return bulkTarget{
+		marks: &explorerMarks,
+		cache: &explorerCache,
+		render: func() {
+			renderMarks(treePanel, &explorerCache, &explorerMarks)
+		},
+		refresh: refreshExplorerAfterBulk,
+	}
+}
+
+func searchBulkTarget() bulkTarget {
+	return bulkTarget{
+		marks: &searchMarks,
+		cache: &searchCache,
+		render: func() {
+			renderMarks(searchTreePanel, &searchCache, &searchMarks)
+			refreshSearchTable()
+		},
+		refresh: refreshSearchAfterBulk,
+	}
+}
+
+// Handles the keys that mark objects and open the bulk actions.
+// Space marks the selected object, * marks the objects under it
+// (or unmarks them if they're all marked already), u unmarks
+// every object and x opens the actions for the marked objects.
+func handleMarksKey(event *tcell.EventKey, target bulkTarget, selectedDN string, objectsUnder func() []string) bool {
+	switch event.Rune() {
+	case ' ':
+		if selectedDN == "" {
+			return false
+		}
+
+		if target.marks.Has(selectedDN) {
+			target.marks.Remove(selectedDN)
+		} else {
+			target.marks.Add(selectedDN)
+		}
+	case '*':
+		dns := objectsUnder()
+		if len(dns) == 0 {
+			updateLog("No loaded objects to mark", "yellow")
+			return true
+		}
+
+		allMarked := !slices.ContainsFunc(dns, func(dn string) bool {
+			return !target.marks.Has(dn)
+		})
+
+		for _, dn := range dns {
+			if allMarked {
+				target.marks.Remove(dn)
+			} else {
+				target.marks.Add(dn)
+			}
+		}
+	case 'u', 'U':
+		target.marks.Clear()
+	case 'x', 'X':
+		openBulkActions(target)
+		return true
+	default:
+		return false
+	}
+
+	target.render()
+	updateLog(strconv.Itoa(target.marks.Len())+" objects marked", "green")
+	return true
+}
+
+// DNs of the loaded children of a node
+func childDNs(node *tview.TreeNode) []string {
+	var dns []string
+	for _, child := range node.GetChildren() {
+		if dn, ok := child.GetReference().(string); ok {
+			dns = append(dns, dn)
+		}
+	}
+
+	return dns
+}
+
+// DNs of the search results under a node (the node included)
+func resultDNs(node *tview.TreeNode) []string {
+	var dns []string
+	node.Walk(func(child, parent *tview.TreeNode) bool {
+		if dn, ok := child.GetReference().(string); ok {
+			if _, ok := searchCache.Get(dn); ok {
+				dns = append(dns, dn)
+			}
+		}
+		return true
+	})
+
+	return dns
+}
+
+// An action applied to each marked object
+type bulkAction struct {
+	Name string
+
+	// Whether the objects leave their DNs (deleted or moved)
+	Removes bool
+
+	// Container receiving the objects, when they're moved
+	Target string
+
+	// Whether the objects are only read
+	ReadOnly bool
+
+	Apply func(dn string) error
+
+	// Called once every object was processed
+	Finish func()
+}
+
+// Lists the actions that can be applied to the marked objects
+func openBulkActions(target bulkTarget) {
+	currentFocus := app.GetFocus()
+
+	dns := target.marks.DNs()
+	if len(dns) == 0 {
+		updateLog("No objects marked (Space marks the selected object)", "yellow")
+		return
+	}
+
+	back := func() {
+		app.SetRoot(appPanel, true).SetFocus(currentFocus)
+	}
+
+	confirm := func(action bulkAction) {
+		openBulkConfirmation(target, action, dns, currentFocus)
+	}
+
+	actionsList := tview.NewList().ShowSecondaryText(false).
+		AddItem("Delete", "", 0, func() {
+			confirm(bulkAction{
+				Name:    "Delete",
+				Removes: true,
+				Apply:   lc.DeleteObject,
+			})
+		}).
+		AddItem("Move to a container", "", 0, func() {
+			openBulkInputForm("Move Objects", "Container DN", currentFocus, func(containerDN string) {
+				confirm(bulkAction{
+					Name:    "Move to '" + containerDN + "'",
+					Removes: true,
+					Target:  containerDN,
+					Apply: func(dn string) error {
+						parsedDN, err := ldap.ParseDN(dn)
+						if err != nil || len(parsedDN.RDNs) == 0 {
+							return fmt.Errorf("Invalid DN")
+						}
+
+						return lc.MoveObject(dn, parsedDN.RDNs[0].String()+","+containerDN)
+					},
+				})
+			})
+		}).
+		AddItem("Add to a group", "", 0, func() {
+			openBulkInputForm("Add to Group", "Group DN", currentFocus, func(groupDN string) {
+				confirm(bulkAction{
+					Name: "Add to group '" + groupDN + "'",
+					Apply: func(dn string) error {
+						return lc.AddMemberToGroup(dn, groupDN)
+					},
+				})
+			})
+		}).
+		AddItem("Remove from a group", "", 0, func() {
+			openBulkInputForm("Remove from Group", "Group DN", currentFocus, func(groupDN string) {
+				confirm(bulkAction{
+					Name: "Remove from group '" + groupDN + "'",
+					Apply: func(dn string) error {
+						return lc.RemoveMemberFromGroup(dn, groupDN)
+					},
+				})
+			})
+		}).
+		AddItem("Set an attribute", "", 0, func() {
+			openBulkAttributeForm(currentFocus, func(attrName string, values []string) {
+				confirm(bulkAction{
+					Name: "Set '" + attrName + "' to '" + strings.Join(values, "; ") + "'",
+					Apply: func(dn string) error {
+						return lc.ModifyAttribute(dn, attrName, values)
+					},
+				})
+			})
+		}).
+		AddItem("Clear an attribute", "", 0, func() {
+			openBulkInputForm("Clear Attribute", "Attribute", currentFocus, func(attrName string) {
+				confirm(bulkAction{
+					Name: "Clear '" + attrName + "'",
+					Apply: func(dn string) error {
+						return lc.DeleteAttribute(dn, attrName)
+					},
+				})
+			})
+		}).
+		AddItem("Set/clear userAccountControl flags", "", 0, func() {
+			openBulkUacForm(currentFocus, confirm)
+		}).
+		AddItem("Export", "", 0, func() {
+			back()
+			runBulkAction(target, bulkExportAction(target.cache), dns)
+		}).
+		AddItem("Unmark all", "", 0, func() {
+			back()
+			target.marks.Clear()
+			target.render()
+			updateLog("0 objects marked", "green")
+		})
+
+	actionsList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			back()
+			return nil
+		}
+		return event
+	})
+
+	actionsList.SetTitle(fmt.Sprintf("Bulk Actions (%d objects)", len(dns))).SetBorder(true)
+	app.SetRoot(actionsList, true).SetFocus(actionsList)
+}
+
+// Asks for the single parameter of an action, such as a DN
+func openBulkInputForm(title string, label string, returnTo tview.Primitive, done func(string)) {
+	inputForm := NewXForm()
+	inputForm.
+		AddInputField(label, "", 0, nil, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+		}).
+		AddButton("Next", func() {
+			value := strings.TrimSpace(inputForm.GetFormItemByLabel(label).(*tview.InputField).GetText())
+			if value == "" {
+				updateLog(label+" is required", "red")
+				return
+			}
+
+			done(value)
+		})
+
+	if strings.HasSuffix(label, "DN") {
+		inputForm.GetFormItemByLabel(label).(*tview.InputField).SetAutocompleteFunc(dnCompletions)
+	}
+
+	inputForm.SetInputCapture(handleEscape(returnTo))
+	inputForm.SetTitle(title).SetBorder(true)
+	app.SetRoot(inputForm, true).SetFocus(inputForm)
+}
+
+func openBulkAttributeForm(returnTo tview.Primitive, done func(attrName string, values []string)) {
+	attrForm := NewXForm()
+	attrForm.
+		AddInputField("Attribute", "", 0, nil, nil).
+		AddTextArea("Values", "", 0, 6, 0, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+		}).
+		AddButton("Next", func() {
+			attrName := strings.TrimSpace(attrForm.GetFormItemByLabel("Attribute").(*tview.InputField).GetText())
+			text := attrForm.GetFormItemByLabel("Values").(*tview.TextArea).GetText()
+
+			var values []string
+			for _, line := range strings.Split(text, "\n") {
+				if strings.TrimSpace(line) != "" {
+					values = append(values, line)
+				}
+			}
+
+			if attrName == "" || len(values) == 0 {
+				updateLog("The attribute and at least one value are required", "red")
+				return
+			}
+
+			done(attrName, values)
+		})
+
+	attrForm.SetInputCapture(handleEscape(returnTo))
+	attrForm.SetTitle("Set Attribute (one value per line)").SetBorder(true)
+	app.SetRoot(attrForm, true).SetFocus(attrForm)
+}
+
+// Asks for the userAccountControl flags to set or clear in every object
+func openBulkUacForm(returnTo tview.Primitive, done func(bulkAction)) {
+	uacForm := NewXForm()
+	uacForm.SetItemPadding(0)
+	uacForm.AddDropDown("Operation", []string{"Set", "Clear", "Toggle"}, 0, nil)
+
+	uacValues := make([]int, 0)
+	for key := range ldaputils.UacFlags {
+		uacValues = append(uacValues, key)
+	}
+	sort.Ints(uacValues)
+
+	selectedFlags := 0
+	for _, val := range uacValues {
+		uacValue := val
+		uacForm.AddCheckbox(ldaputils.UacFlags[uacValue].Present, false, func(checked bool) {
+			if checked {
+				selectedFlags |= uacValue
+			} else {
+				selectedFlags &^= uacValue
+			}
+		})
+	}
+
+	uacForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+		}).
+		AddButton("Next", func() {
+			if selectedFlags == 0 {
+				updateLog("No flags selected", "red")
+				return
+			}
+
+			_, operation := uacForm.GetFormItemByLabel("Operation").(*tview.DropDown).GetCurrentOption()
+			flags := selectedFlags
+
+			var flagNames []string
+			for _, uacValue := range uacValues {
+				if flags&uacValue != 0 {
+					flagNames = append(flagNames, ldaputils.UacFlags[uacValue].Present)
+				}
+			}
+
+			done(bulkAction{
+				Name: operation + " userAccountControl flags " + strings.Join(flagNames, ", "),
+				Apply: func(dn string) error {
+					entries, err := lc.QueryWithOptions(dn, "(objectClass=*)", ldap.ScopeBaseObject, ldaputils.QueryOptions{
+						Attributes: []string{"userAccountControl"},
+					})
+					if err != nil {
+						return err
+					}
+
+					if len(entries) != 1 {
+						return fmt.Errorf("Entry not found")
+					}
+
+					uac, err := strconv.Atoi(entries[0].GetAttributeValue("userAccountControl"))
+					if err != nil {
+						return fmt.Errorf("No userAccountControl")
+					}
+
+					switch operation {
+					case "Set":
+						uac |= flags
+					case "Clear":
+						uac &^= flags
+					case "Toggle":
+						uac ^= flags
+					}
+
+					return lc.ModifyAttribute(dn, "userAccountControl", []string{strconv.Itoa(uac)})
+				},
+			})
+		})
+
+	uacForm.SetInputCapture(handleEscape(returnTo))
+	uacForm.SetTitle("userAccountControl Flags").SetBorder(true)
+	app.SetRoot(uacForm, true).SetFocus(uacForm)
+}
+
+// Collects the marked objects (read again when not
+// cached) and saves them into a JSON file at the end
+func bulkExportAction(cache *EntryCache) bulkAction {
+	exportMap := make(map[string]any)
+
+	return bulkAction{
+		Name:     "Export",
+		ReadOnly: true,
+		Apply: func(dn string) error {
+			if entry, ok := cache.Get(dn); ok {
+				exportMap[dn] = exportedEntry(entry)
+				return nil
+			}
+
+			entries, err := lc.Query(dn, "(objectClass=*)", ldap.ScopeBaseObject, Deleted)
+			if err != nil {
+				return err
+			}
+
+			if len(entries) != 1 {
+				return fmt.Errorf("Entry not found")
+			}
+
+			exportMap[dn] = exportedEntry(entries[0])
+			return nil
+		},
+		Finish: func() {
+			if len(exportMap) > 0 {
+				writeDataExport(exportMap, "marked", "tree_objects")
+			}
+		},
+	}
+}
+
+// Lists every object an action will change and runs it once confirmed
+func openBulkConfirmation(target bulkTarget, action bulkAction, dns []string, returnTo tview.Primitive) {
+	dnsTable := tview.NewTable().SetSelectable(true, false)
+	for idx, dn := range dns {
+		dnsTable.SetCell(idx, 0, tview.NewTableCell(dn))
+	}
+	dnsTable.SetTitle(fmt.Sprintf("Objects (%d)", len(dns))).SetBorder(true)
+
+	confirmForm := NewXForm().
+		AddButton("Cancel", func() {
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+		}).
+		AddButton("Confirm", func() {
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+			runBulkAction(target, action, dns)
+		})
+
+	dnsTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab:
+			app.SetFocus(confirmForm)
+			return nil
+		}
+		return event
+	})
+
+	confirmForm.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+			return nil
+		case tcell.KeyBacktab:
+			app.SetFocus(dnsTable)
+			return nil
+		}
+		return event
+	})
+
+	confirmPanel := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().SetText(fmt.Sprintf(
+			"Do you really want to apply this action to %d objects?\n%s\nTab: buttons", len(dns), action.Name,
+		)), 3, 0, false).
+		AddItem(dnsTable, 0, 1, false).
+		AddItem(confirmForm, 3, 0, true)
+
+	app.SetRoot(confirmPanel, true).SetFocus(confirmForm)
+}
+
+// Applies an action to each object in the background (Esc cancels
+// the objects not processed yet) and reports the result of each one
+func runBulkAction(target bulkTarget, action bulkAction, dns []string) {
+	job := startJob("bulk")
+	if job == nil {
+		return
+	}
+
+	returnTo := app.GetFocus()
+
+	go func() {
+		defer job.Finish()
+
+		results := make([]error, len(dns))
+		var changed []string
+
+		for idx, dn := range dns {
+			if job.Context().Err() != nil {
+				results[idx] = fmt.Errorf("Cancelled")
+				continue
+			}
+
+			app.QueueUpdateDraw(func() {
+				updateLog(fmt.Sprintf("%s... (%d/%d - Esc to cancel)", action.Name, idx+1, len(dns)), "yellow")
+			})
+
+			results[idx] = action.Apply(dn)
+			if results[idx] == nil {
+				changed = append(changed, dn)
+			}
+		}
+
+		if action.Finish != nil {
+			app.QueueUpdateDraw(action.Finish)
+		}
+
+		if len(changed) > 0 && !action.ReadOnly {
+			target.refresh(action, changed)
+		}
+
+		app.QueueUpdateDraw(func() {
+			color := "green"
+			if len(changed) < len(dns) {
+				color = "red"
+			}
+
+			updateLog(fmt.Sprintf("%s: %d of %d objects done", action.Name, len(changed), len(dns)), color)
+			openBulkReport(action, dns, results, returnTo)
+		})
+	}()
+}
+
+// Shows the result of an action for each object
+func openBulkReport(action bulkAction, dns []string, results []error, returnTo tview.Primitive) {
+	reportTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	reportTable.SetCell(0, 0, tview.NewTableCell("Object").SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	reportTable.SetCell(0, 1, tview.NewTableCell("Result").SetTextColor(tcell.ColorYellow).SetSelectable(false))
+
+	failed := 0
+	for idx, dn := range dns {
+		result := tview.NewTableCell("OK").SetTextColor(tcell.ColorGreen)
+		if results[idx] != nil {
+			result = tview.NewTableCell(fmt.Sprint(results[idx])).SetTextColor(tcell.ColorRed)
+			failed += 1
+		}
+
+		reportTable.SetCell(idx+1, 0, tview.NewTableCell(dn).SetMaxWidth(80))
+		reportTable.SetCell(idx+1, 1, result)
+	}
+
+	reportTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape, tcell.KeyEnter:
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+			return nil
+		}
+		return event
+	})
+
+	reportTable.SetTitle(fmt.Sprintf(
+		"%s: %d succeeded, %d failed (Esc to go back)", action.Name, len(dns)-failed, failed,
+	)).SetBorder(true)
+
+	app.SetRoot(reportTable, true).SetFocus(reportTable)
+}
+
+// Reloads the children of the loaded containers that held
+// or received the changed objects
+func refreshExplorerAfterBulk(action bulkAction, changed []string) {
+	app.QueueUpdateDraw(func() {
+		root := treePanel.GetRoot()
+		if root == nil {
+			return
+		}
+
+		isChanged := func(dn string) bool {
+			return slices.ContainsFunc(changed, func(changedDN string) bool {
+				return strings.EqualFold(changedDN, dn)
+			})
+		}
+
+		var containers []*tview.TreeNode
+		root.Walk(func(node, parent *tview.TreeNode) bool {
+			dn, ok := node.GetReference().(string)
+			if !ok || len(node.GetChildren()) == 0 {
+				return true
+			}
+
+			if strings.EqualFold(dn, action.Target) || slices.ContainsFunc(childDNs(node), isChanged) {
+				containers = append(containers, node)
+			}
+			return true
+		})
+
+		if action.Removes {
+			for _, dn := range changed {
+				explorerMarks.Remove(dn)
+			}
+		}
+
+		for _, node := range containers {
+			// Containers under another one were reloaded along with it
+			if treePanel.GetPath(node) != nil {
+				unloadChildren(node)
+				loadChildren(node)
+			}
+		}
+
+		currentNode := treePanel.GetCurrentNode()
+		if currentNode == nil || treePanel.GetPath(currentNode) == nil {
+			currentNode = root
+			if len(containers) > 0 {
+				currentNode = containers[0]
+			}
+			treePanel.SetCurrentNode(currentNode)
+		}
+
+		renderMarks(treePanel, &explorerCache, &explorerMarks)
+		reloadExplorerAttrsPanel(currentNode, false)
+	})
+}
+
+// Drops the results that left their DNs and reads
+// the ones that changed again
+func refreshSearchAfterBulk(action bulkAction, changed []string) {
+	var entries []*ldap.Entry
+	if !action.Removes {
+		for _, dn := range changed {
+			result, err := lc.Query(dn, "(objectClass=*)", ldap.ScopeBaseObject, Deleted)
+			if err == nil && len(result) == 1 {
+				entries = append(entries, result[0])
+			}
+		}
+	}
+
+	app.QueueUpdateDraw(func() {
+		root := searchTreePanel.GetRoot()
+		if root == nil {
+			return
+		}
+
+		nodes := make(map[string]*tview.TreeNode)
+		parents := make(map[*tview.TreeNode]*tview.TreeNode)
+		root.Walk(func(node, parent *tview.TreeNode) bool {
+			if dn, ok := node.GetReference().(string); ok {
+				nodes[strings.ToLower(dn)] = node
+				parents[node] = parent
+			}
+			return true
+		})
+
+		if action.Removes {
+			for _, dn := range changed {
+				searchMarks.Remove(dn)
+				searchCache.Delete(dn)
+
+				node := nodes[strings.ToLower(dn)]
+				if parent := parents[node]; parent != nil {
+					parent.RemoveChild(node)
+				}
+			}
+		}
+
+		for _, entry := range entries {
+			searchCache.Add(entry.DN, entry)
+			delete(searchPartialDNs, entry.DN)
+
+			if node, ok := nodes[strings.ToLower(entry.DN)]; ok && Colors {
+				color, _ := GetEntryColor(entry)
+				node.SetColor(color)
+			}
+		}
+
+		currentNode := searchTreePanel.GetCurrentNode()
+		if currentNode == nil || searchTreePanel.GetPath(currentNode) == nil {
+			currentNode = root
+			searchTreePanel.SetCurrentNode(root)
+		}
+
+		renderMarks(searchTreePanel, &searchCache, &searchMarks)
+		refreshSearchTable()
+
+		searchAttrsPanel.Clear()
+		if currentNode.GetReference() != nil {
+			reloadSearchAttrsPanel(currentNode, true)
+		}
+	})
+}
@@ -37,6 +37,28 @@ func (sc *EntryCache) Get(key string) (*ldap.Entry, bool) {
 	return entry, ok
 }
 
+// Replace swaps the entries map backing the cache
+// and returns the map that was previously in use
+func (sc *EntryCache) Replace(entries map[string]*ldap.Entry) map[string]*ldap.Entry {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+	old := sc.entries
+	sc.entries = entries
+	return old
+}
+
+func (sc *EntryCache) Keys() []string {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	keys := make([]string, 0, len(sc.entries))
+	for key := range sc.entries {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
 func (sc *EntryCache) Length() int {
 	sc.lock.Lock()
 	defer sc.lock.Unlock()
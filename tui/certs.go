@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"crypto/x509"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+var unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func handleAttrsKeyCtrlS(currentNode *tview.TreeNode, attrsPanel *tview.Table, cache *EntryCache) {
+	baseDN, ok := currentNode.GetReference().(string)
+	if !ok {
+		return
+	}
+
+	attrRow, _ := attrsPanel.GetSelection()
+	attrName, ok := attrsPanel.GetCell(attrRow, 0).GetReference().(string)
+	if !ok {
+		return
+	}
+
+	entry, ok := cache.Get(baseDN)
+	if !ok || !ldaputils.IsCertificateAttribute(attrName) {
+		updateLog("The attribute '"+attrName+"' doesn't hold certificates", "yellow")
+		return
+	}
+
+	openExportCertificateForm(entry, attrName)
+}
+
+// Opens the form that exports a certificate of an
+// attribute into ExportDir as PEM or DER
+func openExportCertificateForm(entry *ldap.Entry, attrName string) {
+	currentFocus := app.GetFocus()
+
+	var certs []*x509.Certificate
+	for _, raw := range entry.GetEqualFoldRawAttributeValues(attrName) {
+		valueCerts, err := ldaputils.ParseCertificates(raw)
+		if err == nil {
+			certs = append(certs, valueCerts...)
+		}
+	}
+
+	if len(certs) == 0 {
+		updateLog("No certificates found in '"+attrName+"'", "red")
+		return
+	}
+
+	certOptions := make([]string, len(certs))
+	for idx, cert := range certs {
+		summary := ldaputils.SummarizeCertificate(cert)
+
+		certOptions[idx] = fmt.Sprintf("%s (%s)", cert.Subject.CommonName, summary.Thumbprint[:8])
+		if summary.Expired() {
+			certOptions[idx] += " [Expired]"
+		}
+	}
+
+	// The filename follows the selected certificate and format
+	// until it's edited by hand
+	certFilename := func(certIdx int, format string) string {
+		name := certs[certIdx].Subject.CommonName
+		if name == "" {
+			name = attrName
+		}
+
+		thumbprint := ldaputils.SummarizeCertificate(certs[certIdx]).Thumbprint
+		return unsafeFilenameChars.ReplaceAllString(name, "_") + "_" + thumbprint[:8] + "." + strings.ToLower(format)
+	}
+
+	exportForm := NewXForm()
+	exportForm.
+		AddTextView("Object DN", entry.DN, 0, 1, false, true).
+		AddTextView("Attribute", attrName, 0, 1, false, true).
+		AddDropDown("Certificate", certOptions, 0, nil).
+		AddDropDown("Format", []string{"PEM", "DER"}, 0, nil).
+		AddInputField("Filename", certFilename(0, "PEM"), 0, nil, nil)
+
+	certInput := exportForm.GetFormItemByLabel("Certificate").(*tview.DropDown)
+	formatInput := exportForm.GetFormItemByLabel("Format").(*tview.DropDown)
+	filenameInput := exportForm.GetFormItemByLabel("Filename").(*tview.InputField)
+
+	// SetText calls the changed func too, so the
+	// filename set here isn't taken as a manual edit
+	filenameEdited, settingFilename := false, false
+	filenameInput.SetChangedFunc(func(text string) {
+		if !settingFilename {
+			filenameEdited = true
+		}
+	})
+
+	updateFilename := func() {
+		certIdx, _ := certInput.GetCurrentOption()
+		_, format := formatInput.GetCurrentOption()
+		if !filenameEdited && certIdx >= 0 && format != "" {
+			settingFilename = true
+			filenameInput.SetText(certFilename(certIdx, format))
+			settingFilename = false
+		}
+	}
+
+	certInput.SetSelectedFunc(func(text string, index int) { updateFilename() })
+	formatInput.SetSelectedFunc(func(text string, index int) { updateFilename() })
+
+	exportForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Export", func() {
+			certIdx, _ := certInput.GetCurrentOption()
+			_, format := formatInput.GetCurrentOption()
+
+			contents := certs[certIdx].Raw
+			if format == "PEM" {
+				contents = ldaputils.EncodeCertificatePEM(certs[certIdx : certIdx+1])
+			}
+
+			writeExportFile(filenameInput.GetText(), contents)
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	exportForm.SetInputCapture(handleEscape(currentFocus))
+	exportForm.SetTitle("Export Certificate").SetBorder(true)
+	app.SetRoot(exportForm, true).SetFocus(exportForm)
+}
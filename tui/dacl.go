@@ -1,11 +1,11 @@
 package tui
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"sort"
 	"strconv"
-	"sync"
 
 	"github.com/Macmod/godap/v2/pkg/ldaputils"
 	"github.com/Macmod/godap/v2/pkg/sdl"
@@ -14,19 +14,22 @@ import (
 )
 
 var (
-	runControlDacl sync.Mutex
-	runningDacl    bool
-
 	sd         *sdl.SecurityDescriptor
 	parsedAces []ParsedACE
 )
 
-func parseAces(dst *[]ParsedACE, srcSD *sdl.SecurityDescriptor) {
+// Parses the ACEs of srcSD into dst, resolving their SIDs
+// until the lookups are no longer needed because ctx is done
+func parseAces(ctx context.Context, dst *[]ParsedACE, srcSD *sdl.SecurityDescriptor) {
 	var samAccountName string
 	var sidMap map[string]string = make(map[string]string)
 	var ok bool
 
 	for idx, ace := range srcSD.DACL.Aces {
+		if ctx.Err() != nil {
+			return
+		}
+
 		entry := ParsedACE{
 			Idx:            idx,
 			SamAccountName: "",
@@ -240,21 +243,14 @@ func selectDaclEntry(aceToSelect sdl.ACEInt) {
 	}
 }
 
+// Fetches the DACL of the object in the background, since
+// resolving the SID of every ACE may take a while, and
+// shows it once done. Esc cancels it while it's running.
 func updateDaclEntries() {
-	runControlDacl.Lock()
-	if runningDacl {
-		runControlDacl.Unlock()
-		updateLog("Another query is still running...", "yellow")
+	job := startJob("DACL")
+	if job == nil {
 		return
 	}
-	runningDacl = true
-	runControlDacl.Unlock()
-
-	defer func() {
-		runControlDacl.Lock()
-		runningDacl = false
-		runControlDacl.Unlock()
-	}()
 
 	daclEntriesPanel.Clear()
 	daclOwnerTextView.SetText("")
@@ -269,103 +265,127 @@ func updateDaclEntries() {
 	daclEntriesPanel.SetCell(0, 4, tview.NewTableCell("Scope").SetSelectable(false).SetAlign(tview.AlignCenter))
 	daclEntriesPanel.SetCell(0, 5, tview.NewTableCell("No Propagate").SetSelectable(false).SetAlign(tview.AlignCenter))
 
-	var hexSD string
-	var readableMask string
-	var aceType string
-	var aceInheritance string
-	var aceNoPropagate string
-
 	object = objectNameInputDacl.GetText()
-	hexSD, err = lc.GetSecurityDescriptor(object)
 
 	sd = nil
 	parsedAces = nil
 
-	if err == nil {
-		sd = sdl.NewSD(hexSD)
+	go func() {
+		defer job.Finish()
 
-		numAces := strconv.Itoa(len(sd.DACL.Aces))
+		hexSD, err := lc.GetSecurityDescriptor(object)
+		if err != nil {
+			job.LogError(err)
+			return
+		}
 
-		updateLog("DACL obtained for '"+object+"' ("+numAces+" ACEs)", "green")
-		app.SetFocus(daclEntriesPanel)
-		daclEntriesPanel.ScrollToBeginning()
+		newSD := sdl.NewSD(hexSD)
 
-		controlFlags := sd.GetControl()
-		controlFlagsTextView.SetText(strconv.Itoa(controlFlags))
+		// Parse the ACEs from the DACL in newSD into newAces
+		var newAces []ParsedACE
+		parseAces(job.Context(), &newAces, newSD)
+
+		ownerSID := ldaputils.ConvertSID(newSD.Owner)
+		newOwner, ownerErr := lc.FindSamForSID(ownerSID)
 
-		ownerSID := ldaputils.ConvertSID(sd.Owner)
-		ownerPrincipal, err = lc.FindSamForSID(ownerSID)
-		if err == nil {
-			daclOwnerTextView.SetText(ownerPrincipal)
-		} else {
-			daclOwnerTextView.SetText("[red]" + ownerSID)
-		}
-		groupPrincipal, err = lc.FindSamForSID(ldaputils.ConvertSID(sd.Group))
 		// For AD, groupPrincipal is not relevant,
 		// so there's no need to show it in the UI
+		newGroup, _ := lc.FindSamForSID(ldaputils.ConvertSID(newSD.Group))
 
-		// Parse the ACEs from the DACL in sd into parsedAces
-		parseAces(&parsedAces, sd)
+		if job.Cancelled() {
+			job.LogError(job.Context().Err())
+			return
+		}
 
-		for idx, entry := range parsedAces {
-			if len(entry.Mask) == 1 {
-				readableMask = entry.Mask[0]
-			} else {
-				readableMask = "Special"
-			}
+		app.QueueUpdateDraw(func() {
+			sd = newSD
+			parsedAces = newAces
+			ownerPrincipal = newOwner
+			groupPrincipal = newGroup
 
-			if entry.Severity == 1 {
-				readableMask = "[purple]" + readableMask
-			} else if entry.Severity == 2 {
-				readableMask = "[blue]" + readableMask
-			} else if entry.Severity == 3 {
-				readableMask = "[red]" + readableMask
-			}
+			showDaclEntries(ownerSID, ownerErr == nil)
+		})
+	}()
+}
 
-			if entry.Type == "Allow" {
-				aceType = "[green]" + entry.Type
-			} else {
-				aceType = "[red]" + entry.Type
-			}
+// Shows the DACL fetched by updateDaclEntries
+func showDaclEntries(ownerSID string, ownerFound bool) {
+	var readableMask string
+	var aceType string
+	var aceInheritance string
+	var aceNoPropagate string
 
-			if entry.Inheritance {
-				aceInheritance = "[green]True"
-			} else {
-				aceInheritance = "[red]False"
-			}
+	numAces := strconv.Itoa(len(sd.DACL.Aces))
 
-			if entry.NoPropagate {
-				aceNoPropagate = "[green]True"
-			} else {
-				aceNoPropagate = "[red]False"
-			}
+	updateLog("DACL obtained for '"+object+"' ("+numAces+" ACEs)", "green")
+	app.SetFocus(daclEntriesPanel)
+	daclEntriesPanel.ScrollToBeginning()
 
-			principalName := entry.SamAccountName
-			if ldaputils.IsSID(principalName) {
-				principalName = "[red]" + principalName
-			}
+	controlFlags := sd.GetControl()
+	controlFlagsTextView.SetText(strconv.Itoa(controlFlags))
 
-			daclEntriesPanel.SetCell(idx+1, 0, tview.NewTableCell(aceType))
+	if ownerFound {
+		daclOwnerTextView.SetText(ownerPrincipal)
+	} else {
+		daclOwnerTextView.SetText("[red]" + ownerSID)
+	}
+
+	for idx, entry := range parsedAces {
+		if len(entry.Mask) == 1 {
+			readableMask = entry.Mask[0]
+		} else {
+			readableMask = "Special"
+		}
 
-			daclEntriesPanel.SetCell(idx+1, 1, tview.NewTableCell(principalName))
+		if entry.Severity == 1 {
+			readableMask = "[purple]" + readableMask
+		} else if entry.Severity == 2 {
+			readableMask = "[blue]" + readableMask
+		} else if entry.Severity == 3 {
+			readableMask = "[red]" + readableMask
+		}
 
-			readableMaskCell := tview.NewTableCell(readableMask).SetAlign(tview.AlignCenter)
-			daclEntriesPanel.SetCell(idx+1, 2, readableMaskCell)
+		if entry.Type == "Allow" {
+			aceType = "[green]" + entry.Type
+		} else {
+			aceType = "[red]" + entry.Type
+		}
 
-			daclEntriesPanel.SetCell(
-				idx+1, 3, tview.NewTableCell(aceInheritance).SetAlign(tview.AlignCenter))
+		if entry.Inheritance {
+			aceInheritance = "[green]True"
+		} else {
+			aceInheritance = "[red]False"
+		}
 
-			daclEntriesPanel.SetCell(
-				idx+1, 4, tview.NewTableCell(entry.Scope).SetAlign(tview.AlignCenter))
+		if entry.NoPropagate {
+			aceNoPropagate = "[green]True"
+		} else {
+			aceNoPropagate = "[red]False"
+		}
 
-			daclEntriesPanel.SetCell(
-				idx+1, 5, tview.NewTableCell(aceNoPropagate).SetAlign(tview.AlignCenter))
+		principalName := entry.SamAccountName
+		if ldaputils.IsSID(principalName) {
+			principalName = "[red]" + principalName
 		}
 
-		daclEntriesPanel.Select(1, 1)
-	} else {
-		updateLog(fmt.Sprint(err), "red")
+		daclEntriesPanel.SetCell(idx+1, 0, tview.NewTableCell(aceType))
+
+		daclEntriesPanel.SetCell(idx+1, 1, tview.NewTableCell(principalName))
+
+		readableMaskCell := tview.NewTableCell(readableMask).SetAlign(tview.AlignCenter)
+		daclEntriesPanel.SetCell(idx+1, 2, readableMaskCell)
+
+		daclEntriesPanel.SetCell(
+			idx+1, 3, tview.NewTableCell(aceInheritance).SetAlign(tview.AlignCenter))
+
+		daclEntriesPanel.SetCell(
+			idx+1, 4, tview.NewTableCell(entry.Scope).SetAlign(tview.AlignCenter))
+
+		daclEntriesPanel.SetCell(
+			idx+1, 5, tview.NewTableCell(aceNoPropagate).SetAlign(tview.AlignCenter))
 	}
+
+	daclEntriesPanel.Select(1, 1)
 }
 
 func daclRotateFocus() {
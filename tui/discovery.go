@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Macmod/godap/v2/pkg/discovery"
+)
+
+var discoveredDCs []discovery.DC
+
+// Looks up the DCs of DomainName through DNS SRV records and,
+// if no server was specified, selects the best ranked one
+func discoverDomainControllers() error {
+	proxyDial, err := getProxyDial()
+	if err != nil {
+		return err
+	}
+
+	resolver := discovery.NewResolver(DnsServer, proxyDial, time.Duration(Timeout)*time.Second)
+
+	// DCs are probed on the port that will be used to connect to them,
+	// which may differ from their SRV records (e.g. with LDAPS)
+	dcs, err := discovery.Discover(resolver, DomainName, DcSite, LdapPort)
+	if err != nil {
+		return err
+	}
+
+	discoveredDCs = dcs
+
+	if LdapServer == "" {
+		best, ok := discovery.Best(dcs, GlobalCatalog)
+		if !ok {
+			return fmt.Errorf("No matching SRV records found for '%s'", DomainName)
+		}
+
+		if !best.Reachable {
+			return fmt.Errorf("None of the discovered DCs for '%s' are reachable", DomainName)
+		}
+
+		LdapServer = best.Host
+	}
+
+	return nil
+}
+
+func discoveredDCOptions() []string {
+	options := make([]string, 0, len(discoveredDCs))
+	for _, dc := range discoveredDCs {
+		options = append(options, dc.String())
+	}
+
+	return options
+}
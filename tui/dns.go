@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/Macmod/godap/v2/pkg/adidns"
@@ -31,9 +30,6 @@ var (
 	dnsZoneFilter *tview.InputField
 
 	dnsPage *tview.Flex
-
-	dnsRunControl sync.Mutex
-	dnsRunning    bool
 )
 
 var domainZones []adidns.DNSZone
@@ -630,34 +626,38 @@ func rebuildDnsTree(baseNode *tview.TreeNode) int {
 }
 
 func queryDnsZones(targetZone string) {
-	dnsRunControl.Lock()
-	if dnsRunning {
-		dnsRunControl.Unlock()
-		updateLog("Another query is still running...", "yellow")
+	job := startJob("DNS zones")
+	if job == nil {
 		return
 	}
-	dnsRunning = true
-	dnsRunControl.Unlock()
-
-	clear(nodeCache)
-	clear(zoneCache)
-	clear(domainZones)
-	clear(forestZones)
+	defer job.Finish()
 
 	app.QueueUpdateDraw(func() {
 		updateLog("Querying ADIDNS zones...", "yellow")
+	})
+
+	newDomainZones, err := lc.GetADIDNSZonesContext(job.Context(), targetZone, false)
+	if err != nil && job.Cancelled() {
+		job.LogError(err)
+		return
+	}
 
-		domainZones, _ = lc.GetADIDNSZones(targetZone, false)
-		forestZones, _ = lc.GetADIDNSZones(targetZone, true)
+	newForestZones, err := lc.GetADIDNSZonesContext(job.Context(), targetZone, true)
+	if err != nil && job.Cancelled() {
+		job.LogError(err)
+		return
+	}
+
+	app.QueueUpdateDraw(func() {
+		clear(nodeCache)
+		clear(zoneCache)
+		domainZones = newDomainZones
+		forestZones = newForestZones
 
 		totalZones := len(domainZones) + len(forestZones)
 		if totalZones == 0 {
 			updateLog("No ADIDNS zones found", "red")
 			clearDnsTree()
-
-			dnsRunControl.Lock()
-			dnsRunning = false
-			dnsRunControl.Unlock()
 			return
 		}
 
@@ -674,10 +674,6 @@ func queryDnsZones(targetZone string) {
 		updateLog(fmt.Sprintf("Found %d ADIDNS zones and %d nodes", totalZones, totalNodes), "green")
 		app.SetFocus(dnsTreePanel)
 	})
-
-	dnsRunControl.Lock()
-	dnsRunning = false
-	dnsRunControl.Unlock()
 }
 
 func dnsQueryDoneHandler(key tcell.Key) {
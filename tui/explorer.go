@@ -75,12 +75,15 @@ func initExplorerPage() {
 
 	treeFlex.SetBorder(true)
 	treeFlex.SetTitle("Tree View")
-	explorerPage = tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(
-			tview.NewFlex().
-				AddItem(treeFlex, 0, 1, false).
-				AddItem(explorerAttrsPanel, 0, 1, false), 0, 1, false,
-		)
+
+	initBookmarksPanel()
+
+	explorerPage = tview.NewFlex().
+		AddItem(bookmarksPanel, 0, 0, false).
+		AddItem(treeFlex, 0, 1, false).
+		AddItem(explorerAttrsPanel, 0, 1, false)
+
+	loadBookmarks()
 
 	explorerPage.SetInputCapture(explorerPageKeyHandler)
 
@@ -107,11 +110,11 @@ func expandTreeNode(node *tview.TreeNode) {
 				updateLog("Loading children ("+node.GetReference().(string)+")", "yellow")
 				loadChildren(node)
 
-				n := len(node.GetChildren())
+				n := countLoadedChildren(node)
 
 				if n != 0 {
 					node.SetExpanded(true)
-					updateLog("Loaded "+strconv.Itoa(n)+" children ("+node.GetReference().(string)+")", "green")
+					updateLog("Loaded "+strconv.Itoa(n)+" of "+strconv.Itoa(countChildren(node))+" children ("+node.GetReference().(string)+")", "green")
 				} else {
 					updateLog("Node "+node.GetReference().(string)+" has no children", "green")
 				}
@@ -145,15 +148,30 @@ func reloadParentNode(node *tview.TreeNode) *tview.TreeNode {
 }
 
 func reloadExplorerAttrsPanel(node *tview.TreeNode, useCache bool) {
+	explorerAttrsPanel.SetTitle(gcAttrsMarker("Attributes"))
 	reloadAttributesPanel(node, explorerAttrsPanel, useCache, &explorerCache)
 }
 
+// Exported objects have their values formatted like
+// in the attributes panel when formatting is on
+func exportedEntry(entry *ldap.Entry) any {
+	if entry == nil || !FormatAttrs {
+		return entry
+	}
+
+	return map[string]any{
+		"DN":         entry.DN,
+		"Attributes": lc.FormatEntry(entry, TimeFormat, TimeOffset),
+	}
+}
+
 func exportCacheToFile(currentNode *tview.TreeNode, cache *EntryCache, fileSuffix string) {
 	exportMap := make(map[string]any)
 	currentNode.Walk(func(node, parent *tview.TreeNode) bool {
 		if node.GetReference() != nil {
 			nodeDN := node.GetReference().(string)
-			exportMap[nodeDN], _ = cache.Get(nodeDN)
+			entry, _ := cache.Get(nodeDN)
+			exportMap[nodeDN] = exportedEntry(entry)
 		}
 		return true
 	})
@@ -404,9 +422,20 @@ func treePanelKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 		return event
 	}
 
+	if currentNode.GetReference() == nil {
+		if pagedNode := pagedParentNode(currentNode); pagedNode != nil {
+			return moreChildrenKeyHandler(event, pagedNode)
+		}
+		return event
+	}
+
 	parentNode := getParentNode(currentNode, treePanel)
 	baseDN := currentNode.GetReference().(string)
 
+	if handleMarksKey(event, explorerBulkTarget(), baseDN, func() []string { return childDNs(currentNode) }) {
+		return nil
+	}
+
 	switch event.Rune() {
 	case 'r', 'R':
 		go app.QueueUpdateDraw(func() {
@@ -422,6 +451,35 @@ func treePanelKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 		})
 
 		return event
+	case 'y', 'Y':
+		copyObjectToClipboard(baseDN)
+		return nil
+	case 'm', 'M':
+		openReplicationTimeline(baseDN)
+		return nil
+	case 't', 'T':
+		openDeletedObjects()
+		return nil
+	case 'w', 'W':
+		openWhereUsed(baseDN)
+		return nil
+	case 'o', 'O':
+		openGoToDNForm()
+		return nil
+	case 'b', 'B':
+		toggleBookmark(baseDN)
+		return nil
+	case '<':
+		navigateHistory(true)
+		return nil
+	case '>':
+		navigateHistory(false)
+		return nil
+	case '/':
+		if _, ok := childPagers[currentNode]; ok {
+			openJumpToChildForm(currentNode)
+			return nil
+		}
 	}
 
 	switch event.Key() {
@@ -466,6 +524,12 @@ func treePanelKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 		openCreateObjectForm(currentNode, func() {
 			reloadExplorerAttrsPanel(currentNode, CacheEntries)
 
+			unloadChildren(currentNode)
+			loadChildren(currentNode)
+			treePanel.SetCurrentNode(currentNode)
+		})
+	case tcell.KeyCtrlV:
+		openPasteObjectForm(currentNode, func() {
 			unloadChildren(currentNode)
 			loadChildren(currentNode)
 			treePanel.SetCurrentNode(currentNode)
@@ -503,6 +567,71 @@ func treePanelKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 	return event
 }
 
+// Handles the "load more" node at the end of paged children
+func moreChildrenKeyHandler(event *tcell.EventKey, pagedNode *tview.TreeNode) *tcell.EventKey {
+	if event.Rune() == '/' {
+		openJumpToChildForm(pagedNode)
+		return nil
+	}
+
+	switch event.Key() {
+	case tcell.KeyEnter, tcell.KeyRight:
+		go app.QueueUpdateDraw(func() {
+			idx := countLoadedChildren(pagedNode)
+			loadChildrenPage(pagedNode, "")
+
+			if children := pagedNode.GetChildren(); idx < len(children) {
+				treePanel.SetCurrentNode(children[idx])
+			}
+
+			updateLog("Loaded "+strconv.Itoa(countLoadedChildren(pagedNode))+" of "+strconv.Itoa(countChildren(pagedNode))+" children", "green")
+		})
+		return nil
+	case tcell.KeyLeft:
+		collapseTreeNode(pagedNode)
+		treePanel.SetCurrentNode(pagedNode)
+		return nil
+	}
+
+	return event
+}
+
+// Asks for a prefix and loads the page of children starting at it
+func openJumpToChildForm(node *tview.TreeNode) {
+	currentFocus := app.GetFocus()
+
+	jumpForm := NewXForm()
+	jumpForm.
+		AddTextView("Parent DN", node.GetReference().(string), 0, 1, false, true).
+		AddInputField("Starts with", "", 0, nil, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Jump", func() {
+			prefix := jumpForm.GetFormItemByLabel("Starts with").(*tview.InputField).GetText()
+
+			if pager, ok := childPagers[node]; ok && prefix == "" {
+				// Go back to the first page
+				clearChildren(node)
+				pager.moreNode = nil
+				pager.next = 1
+			}
+
+			loadChildrenPage(node, prefix)
+			node.SetExpanded(true)
+
+			if children := node.GetChildren(); len(children) > 0 {
+				treePanel.SetCurrentNode(children[0])
+			}
+
+			app.SetRoot(appPanel, true).SetFocus(treePanel)
+		})
+
+	jumpForm.SetInputCapture(handleEscape(currentFocus))
+	jumpForm.SetTitle("Jump to Child").SetBorder(true)
+	app.SetRoot(jumpForm, true).SetFocus(jumpForm)
+}
+
 func treePanelChangeHandler(node *tview.TreeNode) {
 	go app.QueueUpdateDraw(func() {
 		// TODO: Implement cancellation
@@ -518,6 +647,12 @@ func explorerRotateFocus() {
 	case treePanel:
 		app.SetFocus(explorerAttrsPanel)
 	case explorerAttrsPanel:
+		if len(explorerBookmarks) > 0 {
+			app.SetFocus(bookmarksPanel)
+		} else {
+			app.SetFocus(treePanel)
+		}
+	case bookmarksPanel:
 		app.SetFocus(treePanel)
 	}
 }
@@ -600,6 +735,10 @@ func explorerPageKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 		return event
 	}
 
+	if currentNode.GetReference() == nil && event.Key() != tcell.KeyCtrlF {
+		return event
+	}
+
 	switch event.Key() {
 	case tcell.KeyCtrlF:
 		openFinder(&explorerCache, "LDAP Explorer")
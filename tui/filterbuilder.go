@@ -0,0 +1,386 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/Macmod/godap/v2/pkg/sdl"
+	"github.com/gdamore/tcell/v2"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+// Tokens of a pretty-printed filter: the start of a composite
+// filter, a closing parenthesis or a whole item (attr, op, value)
+var filterTokenRegexp = regexp.MustCompile(
+	`\([&|!]|\)|\(([^()=<>~]*)(~=|>=|<=|=)((?:[^()\\]|\\[0-9a-fA-F]{2})*)\)`,
+)
+
+// Colorizes a pretty-printed filter with tview color tags
+func highlightFilter(pretty string) string {
+	var sb strings.Builder
+
+	last := 0
+	for _, match := range filterTokenRegexp.FindAllStringSubmatchIndex(pretty, -1) {
+		sb.WriteString(tview.Escape(pretty[last:match[0]]))
+		last = match[1]
+
+		token := pretty[match[0]:match[1]]
+		if match[2] < 0 {
+			sb.WriteString("[violet]" + token + "[white]")
+			continue
+		}
+
+		attr := pretty[match[2]:match[3]]
+		op := pretty[match[4]:match[5]]
+		value := pretty[match[6]:match[7]]
+
+		// Extensible matches keep their rule in the attribute part
+		if strings.HasSuffix(attr, ":") {
+			attr, op = attr[:len(attr)-1], ":"+op
+		}
+
+		sb.WriteString(fmt.Sprintf(
+			"([blue]%s[yellow]%s[green]%s[white])",
+			tview.Escape(attr), op, tview.Escape(value),
+		))
+	}
+
+	sb.WriteString(tview.Escape(pretty[last:]))
+	return sb.String()
+}
+
+// Colors of each rune of a compact filter, matching the ones
+// of highlightFilter, or tcell.ColorDefault where it has none
+func filterColors(filter string) []tcell.Color {
+	byteColors := make([]tcell.Color, len(filter))
+	paint := func(start int, end int, color tcell.Color) {
+		for idx := start; idx < end; idx++ {
+			byteColors[idx] = color
+		}
+	}
+
+	for _, match := range filterTokenRegexp.FindAllStringSubmatchIndex(filter, -1) {
+		if match[2] < 0 {
+			paint(match[0], match[1], tcell.ColorViolet)
+			continue
+		}
+
+		attrEnd, opStart := match[3], match[4]
+		if attrEnd > match[2] && filter[attrEnd-1] == ':' {
+			attrEnd, opStart = attrEnd-1, opStart-1
+		}
+
+		paint(match[2], attrEnd, tcell.ColorBlue)
+		paint(opStart, match[5], tcell.ColorYellow)
+		paint(match[6], match[7], tcell.ColorGreen)
+	}
+
+	var colors []tcell.Color
+	for idx := range filter {
+		colors = append(colors, byteColors[idx])
+	}
+
+	return colors
+}
+
+// filterInputField is an input field that colors
+// the valid filters typed into it like highlightFilter
+type filterInputField struct {
+	*tview.InputField
+}
+
+func newFilterInputField() *filterInputField {
+	return &filterInputField{InputField: tview.NewInputField()}
+}
+
+// Draw draws the field and then recolors the visible part
+// of the filter, which the field doesn't keep track of
+func (f *filterInputField) Draw(screen tcell.Screen) {
+	f.InputField.Draw(screen)
+
+	text := f.GetText()
+	filter, isFilter := ldaputils.SearchInputFilter(text)
+	if !isFilter {
+		return
+	}
+
+	if _, err := ldaputils.ParseFilter(filter); err != nil {
+		return
+	}
+
+	// Items typed without parentheses are colored as if they had them
+	var colors []tcell.Color
+	if strings.HasPrefix(strings.TrimSpace(text), "(") {
+		colors = filterColors(text)
+	} else {
+		colors = filterColors("(" + text + ")")
+		colors = colors[1 : len(colors)-1]
+	}
+
+	// The field may be scrolled, so the visible
+	// text is read back to find where it starts
+	x, y, width, _ := f.GetInnerRect()
+	x += tview.TaggedStringWidth(f.GetLabel())
+
+	var visible []rune
+	var columns []int
+	for column := x; column < x+width; {
+		mainc, _, _, cellWidth := screen.GetContent(column, y)
+		visible = append(visible, mainc)
+		columns = append(columns, column)
+		column += max(cellWidth, 1)
+	}
+
+	for len(visible) > 0 && visible[len(visible)-1] == ' ' {
+		visible = visible[:len(visible)-1]
+	}
+
+	runes := []rune(text)
+	offset := -1
+	for start := 0; start+len(visible) <= len(runes); start++ {
+		if slices.Equal(runes[start:start+len(visible)], visible) {
+			offset = start
+			break
+		}
+	}
+
+	if offset < 0 {
+		return
+	}
+
+	for idx := range visible {
+		color := colors[offset+idx]
+		if color == tcell.ColorDefault {
+			continue
+		}
+
+		mainc, combc, style, _ := screen.GetContent(columns[idx], y)
+		screen.SetContent(columns[idx], y, mainc, combc, style.Foreground(color))
+	}
+}
+
+// MouseHandler gives the focus to the wrapper
+// instead of the field when it's clicked
+func (f *filterInputField) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	handler := f.InputField.MouseHandler()
+	return func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (bool, tview.Primitive) {
+		consumed, capture := handler(action, event, func(p tview.Primitive) {
+			if p == f.InputField {
+				p = f
+			}
+			setFocus(p)
+		})
+
+		if capture == f.InputField {
+			capture = f
+		}
+
+		return consumed, capture
+	}
+}
+
+// Shows whether the text of the search box is a valid filter
+func validateSearchQuery(text string) {
+	filter, isFilter := ldaputils.SearchInputFilter(text)
+	if !isFilter {
+		searchQueryPanel.SetTitle("Search Filter")
+		searchQueryPanel.SetFieldTextColor(tview.Styles.PrimaryTextColor)
+		return
+	}
+
+	if _, err := ldaputils.ParseFilter(filter); err != nil {
+		searchQueryPanel.SetTitle("Search Filter - [red]" + tview.Escape(err.Error()) + "[-]")
+		searchQueryPanel.SetFieldTextColor(tcell.ColorRed)
+		return
+	}
+
+	searchQueryPanel.SetTitle("Search Filter")
+	searchQueryPanel.SetFieldTextColor(tview.Styles.PrimaryTextColor)
+}
+
+// Suggests the names of attributes in the schema
+// and in the cached entries that start with the text
+func attributeCompletions(currentText string) []string {
+	if currentText == "" {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	for _, name := range sdl.AttributeGuids {
+		names[name] = true
+	}
+
+	for _, cache := range []*EntryCache{&explorerCache, &searchCache} {
+		for _, dn := range cache.Keys() {
+			if entry, ok := cache.Get(dn); ok {
+				for _, attr := range entry.Attributes {
+					names[attr.Name] = true
+				}
+			}
+		}
+	}
+
+	lowerText := strings.ToLower(currentText)
+
+	var matches []string
+	for name := range names {
+		if strings.HasPrefix(strings.ToLower(name), lowerText) {
+			matches = append(matches, name)
+		}
+	}
+
+	slices.Sort(matches)
+	return matches[:min(len(matches), 20)]
+}
+
+// Operators offered by the filter builder
+var builderOperators = []string{
+	"equals", "contains", "starts with", "ends with", "is present",
+	">=", "<=", "~=", "has all bits", "has any bit", "in chain",
+}
+
+// Builds a filter item from the fields of the builder
+func buildFilterClause(attr string, operator string, value string, negate bool) string {
+	escaped := ldap.EscapeFilter(value)
+
+	var clause string
+	switch operator {
+	case "equals":
+		clause = "(" + attr + "=" + escaped + ")"
+	case "contains":
+		clause = "(" + attr + "=*" + escaped + "*)"
+	case "starts with":
+		clause = "(" + attr + "=" + escaped + "*)"
+	case "ends with":
+		clause = "(" + attr + "=*" + escaped + ")"
+	case "is present":
+		clause = "(" + attr + "=*)"
+	case ">=", "<=", "~=":
+		clause = "(" + attr + operator + escaped + ")"
+	case "has all bits":
+		clause = "(" + attr + ":" + ldaputils.MatchingRuleBitAnd + ":=" + escaped + ")"
+	case "has any bit":
+		clause = "(" + attr + ":" + ldaputils.MatchingRuleBitOr + ":=" + escaped + ")"
+	case "in chain":
+		clause = "(" + attr + ":" + ldaputils.MatchingRuleInChain + ":=" + escaped + ")"
+	}
+
+	if negate {
+		clause = "(!" + clause + ")"
+	}
+
+	return clause
+}
+
+// Adds a clause to a filter, appending it to the top-level
+// & or | when it matches the combinator, or wrapping both
+func combineFilter(current string, clause string, combinator string) string {
+	current = strings.TrimSpace(current)
+	if current == "" {
+		return clause
+	}
+
+	if parsed, err := ldaputils.ParseFilter(current); err == nil {
+		if combinator == "&" && parsed.Type == ldaputils.FilterAnd ||
+			combinator == "|" && parsed.Type == ldaputils.FilterOr {
+			return current[:len(current)-1] + clause + ")"
+		}
+	}
+
+	return "(" + combinator + current + clause + ")"
+}
+
+// Shows the current filter pretty-printed and
+// lets the user add AND/OR/NOT clauses to it
+func openFilterBuilder() {
+	currentFocus := app.GetFocus()
+
+	preview := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true)
+	preview.
+		SetTitle("Filter Preview").
+		SetBorder(true)
+
+	// Object names are turned into the filter they are searched with
+	currentFilter, isFilter := ldaputils.SearchInputFilter(searchQueryPanel.GetText())
+	if !isFilter && currentFilter != "" {
+		currentFilter = nameSearchFilter(currentFilter)
+	}
+
+	updatePreview := func() {
+		if strings.TrimSpace(currentFilter) == "" {
+			preview.SetText("[gray](empty filter)")
+			return
+		}
+
+		filter, err := ldaputils.ParseFilter(currentFilter)
+		if err != nil {
+			preview.SetText(tview.Escape(currentFilter) + "\n\n[red]" + tview.Escape(err.Error()))
+			return
+		}
+
+		preview.SetText(highlightFilter(filter.Pretty(60)))
+	}
+	updatePreview()
+
+	builderForm := NewXForm()
+	builderForm.SetItemPadding(0)
+
+	builderForm.
+		AddDropDown("Combine with", []string{"AND", "OR"}, 0, nil).
+		AddCheckbox("NOT", false, nil).
+		AddInputField("Attribute", "", 0, nil, nil).
+		AddDropDown("Operator", builderOperators, 0, nil).
+		AddInputField("Value", "", 0, nil, nil)
+
+	attrInput := builderForm.GetFormItemByLabel("Attribute").(*tview.InputField)
+	attrInput.SetAutocompleteFunc(attributeCompletions)
+
+	builderPanel := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(preview, 0, 1, false).
+		AddItem(builderForm, 9, 0, true)
+
+	builderForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Add Clause", func() {
+			attr := strings.TrimSpace(attrInput.GetText())
+			if attr == "" {
+				updateLog("The clause needs an attribute", "red")
+				return
+			}
+
+			_, combinator := builderForm.GetFormItemByLabel("Combine with").(*tview.DropDown).GetCurrentOption()
+			_, operator := builderForm.GetFormItemByLabel("Operator").(*tview.DropDown).GetCurrentOption()
+			value := builderForm.GetFormItemByLabel("Value").(*tview.InputField).GetText()
+			negate := builderForm.GetFormItemByLabel("NOT").(*tview.Checkbox).IsChecked()
+
+			clause := buildFilterClause(attr, operator, value, negate)
+			if combinator == "AND" {
+				currentFilter = combineFilter(currentFilter, clause, "&")
+			} else {
+				currentFilter = combineFilter(currentFilter, clause, "|")
+			}
+
+			updatePreview()
+		}).
+		AddButton("Clear", func() {
+			currentFilter = ""
+			updatePreview()
+		}).
+		AddButton("Use Filter", func() {
+			searchQueryPanel.SetText(currentFilter)
+			app.SetRoot(appPanel, true).SetFocus(searchQueryPanel)
+		})
+
+	builderPanel.SetInputCapture(handleEscape(currentFocus))
+	builderPanel.SetTitle("Filter Builder").SetBorder(true)
+
+	app.SetRoot(builderPanel, true).SetFocus(builderForm)
+}
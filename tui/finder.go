@@ -1,9 +1,13 @@
 package tui
 
 import (
+	"fmt"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -28,7 +32,7 @@ func openFinder(cache *EntryCache, titleLabel string) {
 
 	inputField := tview.NewInputField()
 	inputField.
-		SetPlaceholder("Enter a regexp to search here").
+		SetPlaceholder("Enter a regexp or an LDAP filter to search here").
 		SetTitle("Search Query").
 		SetBorder(true)
 	assignInputFieldTheme(inputField)
@@ -44,6 +48,12 @@ func openFinder(cache *EntryCache, titleLabel string) {
 	inputField.SetDoneFunc(func(tcell.Key) {
 		table.Clear()
 
+		// Filters are evaluated against the cached entries
+		if strings.HasPrefix(strings.TrimSpace(inputField.GetText()), "(") {
+			showFilterMatches(cache, inputField.GetText(), table, numResults)
+			return
+		}
+
 		queryRegexp, err := regexp.Compile(inputField.GetText())
 		if err == nil {
 			results := cache.FindWithRegexp(queryRegexp)
@@ -124,3 +134,37 @@ func openFinder(cache *EntryCache, titleLabel string) {
 
 	app.SetRoot(finderPanel, true).SetFocus(inputField)
 }
+
+// Lists the cached objects that match a search filter
+func showFilterMatches(cache *EntryCache, text string, table *tview.Table, numResults *tview.TextView) {
+	filter, err := ldaputils.ParseFilter(text)
+	if err != nil {
+		numResults.SetText("-")
+		numResults.SetTextColor(tcell.ColorRed)
+		updateLog(fmt.Sprint(err), "red")
+		return
+	}
+
+	var matches []string
+	for _, dn := range cache.Keys() {
+		if entry, ok := cache.Get(dn); ok && filter.Matches(entry) {
+			matches = append(matches, dn)
+		}
+	}
+	slices.Sort(matches)
+
+	numResults.SetText(strconv.Itoa(len(matches)))
+	if len(matches) == 0 {
+		numResults.SetTextColor(tcell.ColorRed)
+		return
+	}
+	numResults.SetTextColor(tcell.ColorDefault)
+
+	table.SetCell(0, 0, tview.NewTableCell("Match").SetSelectable(false))
+	table.SetCell(0, 1, tview.NewTableCell("Object").SetSelectable(false))
+
+	for idx, dn := range matches {
+		table.SetCell(idx+1, 0, tview.NewTableCell("[yellow]Filter"))
+		table.SetCell(idx+1, 1, tview.NewTableCell(dn))
+	}
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/rivo/tview"
+)
+
+// Opens the editor of a flag attribute, with a checkbox per bit,
+// or of an enum attribute, with a dropdown of its values
+func openFlagsEditor(baseDN string, attrName string, rawValue string, flags ldaputils.FlagAttribute, done func()) {
+	currentFocus := app.GetFocus()
+
+	if flags.ReadOnly {
+		updateLog("'"+attrName+"' is computed by the server and can't be edited", "red")
+		return
+	}
+
+	var flagsState int = 0
+	if rawValue != "" {
+		value, err := strconv.Atoi(rawValue)
+		if err != nil {
+			updateLog("Invalid value for '"+attrName+"': "+rawValue, "red")
+			return
+		}
+		flagsState = value
+	}
+
+	flagsForm := NewXForm()
+	flagsForm.SetInputCapture(handleEscape(currentFocus))
+	flagsForm.SetItemPadding(0)
+
+	flagsForm.
+		AddTextView("Object DN", baseDN, 0, 1, false, true).
+		AddTextView("Raw Value", strconv.Itoa(flagsState), 0, 1, false, true)
+
+	updatePreview := func() {
+		rawPreview := flagsForm.GetFormItemByLabel("Raw Value").(*tview.TextView)
+		if rawPreview != nil {
+			rawPreview.SetText(strconv.Itoa(flagsState))
+		}
+	}
+
+	if flags.Enum != nil {
+		enumValues := ldaputils.SortedFlags(flags.Enum)
+
+		// Values missing from the table are kept as an option
+		if _, ok := flags.Enum[flagsState]; !ok {
+			enumValues = append([]int{flagsState}, enumValues...)
+		}
+
+		options := make([]string, len(enumValues))
+		for idx, value := range enumValues {
+			name, ok := flags.Enum[value]
+			if !ok {
+				name = "Unknown"
+			}
+			options[idx] = fmt.Sprintf("%s (%d)", name, value)
+		}
+
+		flagsForm.AddDropDown("Value", options, slices.Index(enumValues, flagsState), func(option string, index int) {
+			if index >= 0 {
+				flagsState = enumValues[index]
+				updatePreview()
+			}
+		})
+	} else {
+		for _, val := range ldaputils.SortedFlags(flags.Bits) {
+			flagValue := int(uint32(val))
+			flagsForm.AddCheckbox(
+				flags.Bits[val],
+				uint32(flagsState)&uint32(flagValue) != 0,
+				func(checked bool) {
+					if checked {
+						flagsState = int(int32(uint32(flagsState) | uint32(flagValue)))
+					} else {
+						flagsState = int(int32(uint32(flagsState) &^ uint32(flagValue)))
+					}
+
+					updatePreview()
+				})
+		}
+	}
+
+	flagsForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Update", func() {
+			strFlagsState := strconv.Itoa(flagsState)
+			err := lc.ModifyAttribute(baseDN, attrName, []string{strFlagsState})
+
+			if err != nil {
+				updateLog(fmt.Sprintf("%s", err), "red")
+			} else {
+				if done != nil {
+					done()
+				}
+
+				updateLog("Object's "+attrName+" updated to "+strFlagsState+" at: "+baseDN, "green")
+			}
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	flagsForm.SetTitle(attrName + " Editor").SetBorder(true)
+	app.SetRoot(flagsForm, true).SetFocus(flagsForm)
+}
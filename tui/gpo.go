@@ -5,7 +5,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/Macmod/godap/v2/pkg/ldaputils"
 	"github.com/gdamore/tcell/v2"
@@ -14,9 +13,6 @@ import (
 )
 
 var (
-	runControlGpo sync.Mutex
-	runningGpo    bool
-
 	gpoTarget string
 
 	gpoTargetInput *tview.InputField
@@ -106,7 +102,7 @@ func initGPOPage() {
 		AddItem(gpoFlex, 0, 1, false)
 
 	gpoTargetInput.SetDoneFunc(func(key tcell.Key) {
-		go updateGPOEntries()
+		go updateGPOEntries(gpoTargetInput.GetText())
 	})
 
 	gpoListPanel.SetSelectedFunc(func(row, col int) {
@@ -180,32 +176,23 @@ func gpoRotateFocus() {
 	}
 }
 
-func updateGPOEntries() {
-	runControlGpo.Lock()
-	if runningGpo {
-		runControlGpo.Unlock()
-		updateLog("Another query is still running...", "yellow")
+func updateGPOEntries(target string) {
+	job := startJob("GPOs")
+	if job == nil {
 		return
 	}
-	runningGpo = true
-	runControlGpo.Unlock()
-
-	defer func() {
-		runControlGpo.Lock()
-		runningGpo = false
-		runControlGpo.Unlock()
-	}()
-
-	gpLinks = make(map[string][]GPOLink)
-	gpEntry = make(map[string]*ldap.Entry)
-	containerLinks = make(map[string][]string)
+	defer job.Finish()
 
-	gpoListPanel.SetTitle("Applied GPOs")
-	gpoLinksPanel.Clear()
-	gpoListPanel.Clear()
-	gpoPath.Clear()
+	newGpLinks := make(map[string][]GPOLink)
+	newGpEntry := make(map[string]*ldap.Entry)
+	newContainerLinks := make(map[string][]string)
 
 	app.QueueUpdateDraw(func() {
+		gpoListPanel.SetTitle("Applied GPOs")
+		gpoLinksPanel.Clear()
+		gpoListPanel.Clear()
+		gpoPath.Clear()
+
 		gpoListPanel.SetCell(0, 0, tview.NewTableCell("Name").SetSelectable(false))
 		gpoListPanel.SetCell(0, 1, tview.NewTableCell("Created").SetSelectable(false))
 		gpoListPanel.SetCell(0, 2, tview.NewTableCell("Changed").SetSelectable(false))
@@ -213,80 +200,97 @@ func updateGPOEntries() {
 
 		// Load all gpLinks
 		updateLog("Querying all gpLinks", "yellow")
+	})
 
-		gpLinkObjs, _ := lc.Query(lc.DefaultRootDN, "(gpLink=*)", ldap.ScopeWholeSubtree, false)
+	gpLinkObjs, err := lc.QueryContext(
+		job.Context(), lc.DefaultRootDN, "(gpLink=*)",
+		ldap.ScopeWholeSubtree, false, job.Progress(),
+	)
+	if err != nil {
+		job.LogError(err)
+		return
+	}
 
-		for _, gpLinkObj := range gpLinkObjs {
-			gpLinkVals := gpLinkObj.GetAttributeValue("gPLink")
+	for _, gpLinkObj := range gpLinkObjs {
+		gpLinkVals := gpLinkObj.GetAttributeValue("gPLink")
 
-			links, _ := ParseGPLinks(gpLinkVals, gpLinkObj.DN)
+		links, _ := ParseGPLinks(gpLinkVals, gpLinkObj.DN)
 
-			for _, link := range links {
-				gpLinks[link.GUID] = append(gpLinks[link.GUID], link)
-				containerLinks[link.Target] = append(containerLinks[link.Target], link.GUID)
-			}
+		for _, link := range links {
+			newGpLinks[link.GUID] = append(newGpLinks[link.GUID], link)
+			newContainerLinks[link.Target] = append(newContainerLinks[link.Target], link.GUID)
 		}
-		updateLog("gpLinks loaded successfully", "green")
-
-		// Load all GPOs from corresponding links
-		gpoQuery := "(objectClass=groupPolicyContainer)"
-		gpoTarget = gpoTargetInput.GetText()
+	}
 
-		gpoTargetDN := gpoTarget
-		if gpoTarget != "" {
-			gpoTargetQuery := fmt.Sprintf("(distinguishedName=%s)", ldap.EscapeFilter(gpoTarget))
-			if !strings.Contains(gpoTarget, "=") {
-				gpoTargetQuery = fmt.Sprintf("(cn=%s)", ldap.EscapeFilter(gpoTarget))
-			}
+	// Load all GPOs from corresponding links
+	gpoQuery := "(objectClass=groupPolicyContainer)"
 
-			entries, err := lc.Query(lc.DefaultRootDN, gpoTargetQuery, ldap.ScopeWholeSubtree, false)
+	gpoTargetDN := target
+	if target != "" {
+		gpoTargetQuery := fmt.Sprintf("(distinguishedName=%s)", ldap.EscapeFilter(target))
+		if !strings.Contains(target, "=") {
+			gpoTargetQuery = fmt.Sprintf("(cn=%s)", ldap.EscapeFilter(target))
+		}
 
+		app.QueueUpdateDraw(func() {
 			updateLog("Querying for '"+gpoTargetQuery+"'", "yellow")
-			if err != nil {
-				updateLog(fmt.Sprint(err), "red")
-				return
-			}
+		})
 
-			if len(entries) > 0 {
-				updateLog("GPO target found ("+entries[0].DN+")", "green")
-				gpoTargetDN = entries[0].DN
-			} else {
+		entries, err := lc.QueryContext(job.Context(), lc.DefaultRootDN, gpoTargetQuery, ldap.ScopeWholeSubtree, false, nil)
+		if err != nil {
+			job.LogError(err)
+			return
+		}
+
+		if len(entries) == 0 {
+			app.QueueUpdateDraw(func() {
 				updateLog("GPO target not found", "red")
-				return
-			}
+			})
+			return
 		}
 
-		var applicableGPOs []string
+		gpoTargetDN = entries[0].DN
+	}
 
-		dnParts := strings.Split(gpoTargetDN, ",")
-		for idx := len(dnParts) - 1; idx >= 0; idx -= 1 {
-			candidateDN := strings.Join(dnParts[idx:], ",")
+	var applicableGPOs []string
 
-			candidateGuids, ok := containerLinks[candidateDN]
-			if ok {
-				applicableGPOs = append(applicableGPOs, candidateGuids...)
-			}
-		}
+	dnParts := strings.Split(gpoTargetDN, ",")
+	for idx := len(dnParts) - 1; idx >= 0; idx -= 1 {
+		candidateDN := strings.Join(dnParts[idx:], ",")
 
-		gpoQuerySuffix := ""
-		if len(applicableGPOs) > 0 {
-			gpoQuerySuffix = "name=" + ldap.EscapeFilter(applicableGPOs[0])
-			for _, gpoGuid := range applicableGPOs[1:] {
-				gpoQuerySuffix = "(|(" + gpoQuerySuffix + ")(name=" + ldap.EscapeFilter(gpoGuid) + "))"
-			}
+		candidateGuids, ok := newContainerLinks[candidateDN]
+		if ok {
+			applicableGPOs = append(applicableGPOs, candidateGuids...)
 		}
+	}
 
-		if gpoQuerySuffix != "" {
-			gpoQuery = "(&(" + gpoQuery + ")(" + gpoQuerySuffix + "))"
+	gpoQuerySuffix := ""
+	if len(applicableGPOs) > 0 {
+		gpoQuerySuffix = "name=" + ldap.EscapeFilter(applicableGPOs[0])
+		for _, gpoGuid := range applicableGPOs[1:] {
+			gpoQuerySuffix = "(|(" + gpoQuerySuffix + ")(name=" + ldap.EscapeFilter(gpoGuid) + "))"
 		}
+	}
+
+	if gpoQuerySuffix != "" {
+		gpoQuery = "(&(" + gpoQuery + ")(" + gpoQuerySuffix + "))"
+	}
 
+	app.QueueUpdateDraw(func() {
 		updateLog("Searching applicable GPOs...", "yellow")
+	})
 
-		entries, err := lc.Query(lc.DefaultRootDN, gpoQuery, ldap.ScopeWholeSubtree, false)
-		if err != nil {
-			updateLog(fmt.Sprint(err), "red")
-			return
-		}
+	entries, err := lc.QueryContext(job.Context(), lc.DefaultRootDN, gpoQuery, ldap.ScopeWholeSubtree, false, job.Progress())
+	if err != nil {
+		job.LogError(err)
+		return
+	}
+
+	app.QueueUpdateDraw(func() {
+		gpLinks = newGpLinks
+		gpEntry = newGpEntry
+		containerLinks = newContainerLinks
+		gpoTarget = target
 
 		if len(entries) > 0 {
 			updateLog("GPOs query completed ("+strconv.Itoa(len(entries))+" GPOs found)", "green")
@@ -71,16 +71,22 @@ func updateMaxDepth() {
 	}
 }
 
-func searchGroupMembersAD(groupDN string) {
-	updateMaxDepth()
-	membersPanel.Clear()
-
-	members, err = lc.QueryGroupMembersDeep(groupDN, maxDepth)
+func searchGroupMembersAD(job *Job, groupDN string) {
+	newMembers, err := lc.QueryGroupMembersDeepContext(job.Context(), groupDN, maxDepth)
 	if err != nil {
-		updateLog(fmt.Sprint(err), "red")
+		job.LogError(err)
 		return
 	}
 
+	app.QueueUpdateDraw(func() {
+		members = newMembers
+		renderGroupMembersAD(groupDN)
+	})
+}
+
+func renderGroupMembersAD(groupDN string) {
+	membersPanel.Clear()
+
 	updateLog("Found "+strconv.Itoa(len(members))+" members of '"+groupDN+"'", "green")
 
 	for idx, entry := range members {
@@ -114,15 +120,22 @@ func searchGroupMembersAD(groupDN string) {
 	app.SetFocus(membersPanel)
 }
 
-func searchGroupMembersBasic(groupDN string) {
-	membersPanel.Clear()
-
-	membersSimple, err = lc.QueryGroupMembersBasic(groupDN)
+func searchGroupMembersBasic(job *Job, groupDN string) {
+	newMembers, err := lc.QueryGroupMembersBasic(groupDN)
 	if err != nil {
-		updateLog(fmt.Sprint(err), "red")
+		job.LogError(err)
 		return
 	}
 
+	app.QueueUpdateDraw(func() {
+		membersSimple = newMembers
+		renderGroupMembersBasic(groupDN)
+	})
+}
+
+func renderGroupMembersBasic(groupDN string) {
+	membersPanel.Clear()
+
 	updateLog("Found "+strconv.Itoa(len(membersSimple))+" members of '"+groupDN+"'", "green")
 
 	for idx, entry := range membersSimple {
@@ -135,23 +148,40 @@ func searchGroupMembersBasic(groupDN string) {
 	app.SetFocus(membersPanel)
 }
 
-func searchObjectGroupsAD(objectDN string) {
-	updateMaxDepth()
-	groupsPanel.Clear()
-
-	groups, err = lc.QueryObjectGroupsDeep(objectDN, maxDepth)
+func searchObjectGroupsAD(job *Job, objectDN string) {
+	newGroups, err := lc.QueryObjectGroupsDeepContext(job.Context(), objectDN, maxDepth)
 	if err != nil {
-		updateLog(fmt.Sprint(err), "red")
+		job.LogError(err)
 		return
 	}
 
+	app.QueueUpdateDraw(func() {
+		groups = newGroups
+		renderObjectGroupsAD(objectDN)
+	})
+}
+
+func renderObjectGroupsAD(objectDN string) {
+	groupsPanel.Clear()
+
 	updateLog("Found "+strconv.Itoa(len(groups))+" groups containing '"+objectDN+"'", "green")
 
 	for idx, group := range groups {
 		groupName := group.GetAttributeValue("name")
 		groupDN := group.DN
+
+		// In GC mode, memberships from other domains are
+		// only visible for universal groups, so show the scopes
+		groupKind := "👥"
+		if lc.GlobalCatalog {
+			groupType, err := strconv.Atoi(group.GetAttributeValue("groupType"))
+			if desc, ok := ldaputils.GroupTypeMap[groupType]; err == nil && ok {
+				groupKind += " " + desc
+			}
+		}
+
 		groupsPanel.SetCell(idx, 0, tview.NewTableCell(groupName).SetReference(group.DN))
-		groupsPanel.SetCell(idx, 1, tview.NewTableCell("👥").SetReference(group.DN))
+		groupsPanel.SetCell(idx, 1, tview.NewTableCell(groupKind).SetReference(group.DN))
 		groupsPanel.SetCell(idx, 2, tview.NewTableCell(groupDN).SetReference(group.DN))
 	}
 
@@ -160,15 +190,22 @@ func searchObjectGroupsAD(objectDN string) {
 	app.SetFocus(groupsPanel)
 }
 
-func searchObjectGroupsBasic(objectDN string) {
-	groupsPanel.Clear()
-
-	groups, err = lc.QueryObjectGroupsBasic(objectDN)
+func searchObjectGroupsBasic(job *Job, objectDN string) {
+	newGroups, err := lc.QueryObjectGroupsBasic(objectDN)
 	if err != nil {
-		updateLog(fmt.Sprint(err), "red")
+		job.LogError(err)
 		return
 	}
 
+	app.QueueUpdateDraw(func() {
+		groups = newGroups
+		renderObjectGroupsBasic(objectDN)
+	})
+}
+
+func renderObjectGroupsBasic(objectDN string) {
+	groupsPanel.Clear()
+
 	updateLog("Found "+strconv.Itoa(len(groups))+" groups containing '"+objectDN+"'", "green")
 
 	for idx, group := range groups {
@@ -261,63 +298,92 @@ func initGroupPage() {
 
 	groupNameInput.SetDoneFunc(func(key tcell.Key) {
 		queryGroup = groupNameInput.GetText()
+		updateMaxDepth()
 
-		groupDN = queryGroup
+		go queryGroupMembers(queryGroup)
+	})
 
-		if lc.Flavor == ldaputils.MicrosoftADFlavor {
-			samOrDn, isSam := ldaputils.SamOrDN(queryGroup)
-			if isSam {
-				groupDNQuery := fmt.Sprintf("(&(objectCategory=group)%s)", samOrDn)
-				result, err := lc.QueryFirst(groupDNQuery)
-				if err != nil {
-					updateLog(fmt.Sprintf("Group '%s' not found", queryGroup), "red")
-					return
-				}
+	objectNameInput.SetDoneFunc(func(key tcell.Key) {
+		queryObject = objectNameInput.GetText()
+		updateMaxDepth()
 
-				groupDN = result.DN
-			}
+		go queryObjectGroups(queryObject)
+	})
+}
 
-			searchGroupMembersAD(groupDN)
-		} else if lc.Flavor == ldaputils.BasicLDAPFlavor {
-			cnUidOrDN, isCnOrUid := ldaputils.CnUidOrDN(queryGroup)
-			if isCnOrUid {
-				groupDNQuery := fmt.Sprintf(
-					"(&(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=posixGroup))%s)",
-					cnUidOrDN,
-				)
+func queryGroupMembers(query string) {
+	job := startJob("group members")
+	if job == nil {
+		return
+	}
+	defer job.Finish()
 
-				result, err := lc.QueryFirst(groupDNQuery)
-				if err != nil {
-					updateLog(fmt.Sprintf("Group '%s' not found", queryGroup), "red")
-					return
-				}
+	targetDN := query
 
-				groupDN = result.DN
+	if lc.Flavor == ldaputils.MicrosoftADFlavor {
+		samOrDn, isSam := ldaputils.SamOrDN(query)
+		if isSam {
+			groupDNQuery := fmt.Sprintf("(&(objectCategory=group)%s)", samOrDn)
+			result, err := lc.QueryFirst(groupDNQuery)
+			if err != nil {
+				app.QueueUpdateDraw(func() {
+					updateLog(fmt.Sprintf("Group '%s' not found", query), "red")
+				})
+				return
 			}
 
-			searchGroupMembersBasic(groupDN)
+			targetDN = result.DN
 		}
-	})
 
-	objectNameInput.SetDoneFunc(func(key tcell.Key) {
-		queryObject = objectNameInput.GetText()
-		objectDN = queryObject
+		groupDN = targetDN
+		searchGroupMembersAD(job, targetDN)
+	} else if lc.Flavor == ldaputils.BasicLDAPFlavor {
+		cnUidOrDN, isCnOrUid := ldaputils.CnUidOrDN(query)
+		if isCnOrUid {
+			groupDNQuery := fmt.Sprintf(
+				"(&(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=posixGroup))%s)",
+				cnUidOrDN,
+			)
+
+			result, err := lc.QueryFirst(groupDNQuery)
+			if err != nil {
+				app.QueueUpdateDraw(func() {
+					updateLog(fmt.Sprintf("Group '%s' not found", query), "red")
+				})
+				return
+			}
 
-		queryFilter := ldaputils.GuessQueryFilter(queryObject, lc.Flavor)
-		result, err := lc.QueryFirst(queryFilter)
-		if err != nil {
-			updateLog(fmt.Sprintf("Object '%s' not found", queryObject), "red")
-			return
-		} else {
-			objectDN = result.DN
+			targetDN = result.DN
 		}
 
-		if lc.Flavor == ldaputils.MicrosoftADFlavor {
-			searchObjectGroupsAD(objectDN)
-		} else {
-			searchObjectGroupsBasic(objectDN)
-		}
-	})
+		groupDN = targetDN
+		searchGroupMembersBasic(job, targetDN)
+	}
+}
+
+func queryObjectGroups(query string) {
+	job := startJob("object groups")
+	if job == nil {
+		return
+	}
+	defer job.Finish()
+
+	queryFilter := ldaputils.GuessQueryFilter(query, lc.Flavor)
+	result, err := lc.QueryFirst(queryFilter)
+	if err != nil {
+		app.QueueUpdateDraw(func() {
+			updateLog(fmt.Sprintf("Object '%s' not found", query), "red")
+		})
+		return
+	}
+
+	objectDN = result.DN
+
+	if lc.Flavor == ldaputils.MicrosoftADFlavor {
+		searchObjectGroupsAD(job, objectDN)
+	} else {
+		searchObjectGroupsBasic(job, objectDN)
+	}
 }
 
 func groupRotateFocus() {
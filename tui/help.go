@@ -23,20 +23,53 @@ func initHelpPage() {
 		{"l", "Global", "Change current server address & credentials"},
 		{"Ctrl + r", "Global", "Reconnect to the server"},
 		{"Ctrl + u", "Global", "Upgrade connection to use TLS (with StartTLS)"},
+		{"Ctrl + t", "Global", "Open a new session with another server or credentials"},
+		{"] / [", "Global", "Switch to the next / previous session"},
+		{"Ctrl + w", "Global", "Close the current session"},
+		{"Esc", "Global", "Cancel the running queries"},
 		{"Ctrl + f", "Explorer & Object Search pages", "Open the finder to search for cached objects & attributes with regex"},
+		{"Ctrl + o", "Search page", "Select the attributes requested by searches, including operational ones"},
+		{"Ctrl + b", "Search page", "Open the filter builder with a pretty-printed preview of the current filter"},
+		{"v", "Search results", "Switch between the tree and the table view of the results"},
+		{"o", "Search results table", "Sort the table by the selected column (again to reverse)"},
+		{"Ctrl + e", "Search results table", "Choose the attribute columns of the table"},
+		{"Ctrl + s", "Search results table", "Export the table into a CSV or JSON file"},
+		{"Enter", "Search history panel", "Run the selected search again with its base, scope and limits"},
+		{"*", "Search history panel", "Star the selected entry as a saved search (again to unstar)"},
+		{"Delete", "Search history panel", "Remove the selected entry from the history"},
+		{"/", "Search history panel", "Filter the history by query or base DN"},
 		{"Left Arrow", "Explorer panel", "Collapse the children of the selected object"},
 		{"Right Arrow", "Explorer panel", "Expand the children of the selected object"},
 		{"r", "Explorer panel", "Reload the attributes and children of the selected object"},
+		{"/", "Explorer panel", "Jump to the children of the selected object starting with a prefix"},
+		{"m", "Explorer panel", "Show the replication metadata timeline of the selected object"},
+		{"w", "Explorer panel / Obj. Search panel", "List the objects that reference the selected object (where used)"},
+		{"o", "Explorer panel", "Go to a DN, expanding the tree up to it"},
+		{"b", "Explorer panel", "Add or remove a bookmark for the selected object"},
+		{"< / >", "Explorer panel", "Go back / forward to the objects visited by jumps"},
+		{"Delete", "Bookmarks panel", "Remove the selected bookmark"},
+		{"Space", "Explorer panel / Obj. Search panel", "Mark or unmark the selected object for bulk actions"},
+		{"*", "Explorer panel / Obj. Search panel", "Mark the loaded children of the object (all results in the search)"},
+		{"u", "Explorer panel / Obj. Search panel", "Unmark all objects"},
+		{"x", "Explorer panel / Obj. Search panel", "Open the bulk actions for the marked objects"},
+		{"t", "Explorer panel", "List the deleted objects to restore them (AD only)"},
+		{"Enter", "Explorer panel (load more)", "Load the next page of children of the object"},
 		{"Ctrl + n", "Explorer panel", "Create a new object under the selected object"},
 		{"Ctrl + s", "Explorer panel", "Export all loaded nodes in the selected subtree into a JSON file"},
 		{"Ctrl + p", "Explorer panel", "Change the password of the selected user or computer account"},
 		{"Ctrl + a", "Explorer panel", "Update the userAccountControl of the object interactively"},
 		{"Ctrl + l", "Explorer panel", "Move the selected object to another location"},
+		{"y", "Explorer panel / Obj. Search panel", "Copy the selected object (can be pasted in any session)"},
+		{"Ctrl + v", "Explorer panel / Obj. Search panel", "Paste the copied object as a new object under the selected object"},
+		{"Ctrl + v", "Input fields", "Paste the DN of the copied object"},
 		{"Delete", "Explorer panel", "Delete the selected object"},
 		{"Ctrl + e", "Attributes panel", "Edit the selected attribute of the selected object"},
 		{"Ctrl + n", "Attributes panel", "Create a new attribute in the selected object"},
+		{"Ctrl + k", "Attributes panel", "Query the objects referenced by the selected attribute into the search page"},
+		{"Ctrl + s", "Attributes panel", "Export a certificate of the selected attribute as PEM or DER"},
 		{"Delete", "Attributes panel", "Delete the selected attribute of the selected object"},
 		{"Enter", "Attributes panel (entries hidden)", "Expand all hidden entries of an attribute"},
+		{"Enter", "Attributes panel (DN values)", "Go to the object referenced by the selected value in the explorer"},
 		{"Delete", "Groups panels", "Remove the selected member from the searched group or vice-versa"},
 		{"Ctrl + s", "Object groups panel", "Export the current groups innto a JSON file"},
 		{"Ctrl + s", "Group members panel", "Export the current group members into a JSON file"},
@@ -0,0 +1,249 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Maximum number of unsaved entries kept in the history file
+const maxSearchHistory = 1000
+
+var (
+	searchHistoryFilter *tview.InputField
+	searchHistoryPage   *tview.Flex
+)
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._=-]+`)
+
+// Path of a file under the config directory kept per domain (or
+// per server for directories without a naming context)
+func domainConfigPath(dir string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := lc.DefaultRootDN
+	if name == "" {
+		name = LdapServer
+	}
+
+	name = unsafePathChars.ReplaceAllString(strings.ToLower(name), "_")
+	return filepath.Join(configDir, "godap", dir, name+".json"), nil
+}
+
+// Path of the file keeping the search history of the current domain
+func searchHistoryPath() (string, error) {
+	return domainConfigPath("history")
+}
+
+// Replaces the history in memory by the one stored for the current domain
+func loadSearchHistory() {
+	searchHistoryEntries = nil
+
+	historyPath, err := searchHistoryPath()
+	if err != nil {
+		return
+	}
+
+	data, err := os.ReadFile(historyPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			updateLog(fmt.Sprintf("Error loading search history: %s", err), "red")
+		}
+		return
+	}
+
+	if err := json.Unmarshal(data, &searchHistoryEntries); err != nil {
+		updateLog(fmt.Sprintf("Error loading search history: %s", err), "red")
+	}
+
+	// Entries edited by hand may have scopes that
+	// can't be selected, which run as subtree searches
+	for idx := range searchHistoryEntries {
+		if !slices.Contains(searchScopes, searchHistoryEntries[idx].Params.Scope) {
+			searchHistoryEntries[idx].Params.Scope = searchScopes[0]
+		}
+	}
+}
+
+// Stores the history of the current domain, dropping
+// the oldest unsaved entries past maxSearchHistory
+func saveSearchHistory() {
+	historyPath, err := searchHistoryPath()
+	if err != nil {
+		return
+	}
+
+	var entries []SearchHistoryEntry
+	for idx, entry := range searchHistoryEntries {
+		if idx < maxSearchHistory || entry.Saved {
+			entries = append(entries, entry)
+		}
+	}
+
+	data, _ := json.MarshalIndent(entries, "", " ")
+
+	err = os.MkdirAll(filepath.Dir(historyPath), 0700)
+	if err == nil {
+		err = os.WriteFile(historyPath, data, 0600)
+	}
+
+	if err != nil {
+		updateLog(fmt.Sprintf("Error saving search history: %s", err), "red")
+	}
+}
+
+func initSearchHistory() {
+	searchHistoryPanel = tview.NewTable().
+		SetSelectable(true, false).
+		SetBorders(false).
+		SetFixed(1, 0)
+
+	searchHistoryFilter = tview.NewInputField().
+		SetLabel("Filter: ").
+		SetPlaceholder("Query or base DN")
+	assignInputFieldTheme(searchHistoryFilter)
+
+	searchHistoryFilter.SetChangedFunc(func(text string) {
+		updateSearchHistoryPanel()
+	})
+
+	searchHistoryFilter.SetDoneFunc(func(key tcell.Key) {
+		app.SetFocus(searchHistoryPanel)
+	})
+
+	searchHistoryPanel.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := searchHistoryPanel.GetSelection()
+		idx, ok := searchHistoryPanel.GetCell(row, 0).GetReference().(int)
+		if !ok {
+			return event
+		}
+
+		switch event.Key() {
+		case tcell.KeyEnter:
+			runHistoryEntry(searchHistoryEntries[idx])
+			return nil
+		case tcell.KeyDelete:
+			searchHistoryEntries = slices.Delete(searchHistoryEntries, idx, idx+1)
+			saveSearchHistory()
+			updateSearchHistoryPanel()
+			updateSavedSearches()
+			return nil
+		}
+
+		switch event.Rune() {
+		case '*':
+			searchHistoryEntries[idx].Saved = !searchHistoryEntries[idx].Saved
+			saveSearchHistory()
+			updateSearchHistoryPanel()
+			updateSavedSearches()
+			return nil
+		case '/':
+			app.SetFocus(searchHistoryFilter)
+			return nil
+		}
+
+		return event
+	})
+
+	searchHistoryPage = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(searchHistoryFilter, 1, 0, false).
+		AddItem(searchHistoryPanel, 0, 1, true)
+}
+
+func historyEntryMatches(entry SearchHistoryEntry, filter string) bool {
+	filter = strings.ToLower(filter)
+	return strings.Contains(strings.ToLower(entry.Query), filter) ||
+		strings.Contains(strings.ToLower(entry.Params.BaseDN), filter)
+}
+
+func updateSearchHistoryPanel() {
+	searchHistoryPanel.Clear()
+
+	headers := []string{"", "StartTime", "Duration", "Results", "Scope", "Query"}
+	for col, header := range headers {
+		searchHistoryPanel.SetCell(0, col, tview.NewTableCell(header).SetSelectable(false))
+	}
+
+	filter := searchHistoryFilter.GetText()
+
+	row := 1
+	for idx, entry := range searchHistoryEntries {
+		if filter != "" && !historyEntryMatches(entry, filter) {
+			continue
+		}
+
+		saved := ""
+		if entry.Saved {
+			saved = "★"
+		}
+
+		timestamp := entry.Timestamp.Format(TimeFormat)
+		duration := fmt.Sprintf("%.4fs", entry.Duration.Seconds())
+		results := strconv.Itoa(entry.Results)
+
+		searchHistoryPanel.SetCell(row, 0, tview.NewTableCell(saved).SetReference(idx).SetTextColor(tcell.ColorYellow))
+		searchHistoryPanel.SetCell(row, 1, tview.NewTableCell(timestamp))
+		searchHistoryPanel.SetCell(row, 2, tview.NewTableCell(duration))
+		searchHistoryPanel.SetCell(row, 3, tview.NewTableCell(results))
+		searchHistoryPanel.SetCell(row, 4, tview.NewTableCell(searchScopeNames[slices.Index(searchScopes, entry.Params.Scope)]))
+		searchHistoryPanel.SetCell(row, 5, tview.NewTableCell(entry.Query))
+
+		row += 1
+	}
+}
+
+// Lists the saved searches at the top of the search library
+func updateSavedSearches() {
+	libraryRoot := searchLibraryPanel.GetRoot()
+
+	var savedNode *tview.TreeNode
+	for _, node := range libraryRoot.GetChildren() {
+		if node.GetReference() == "saved" {
+			savedNode = node
+		}
+	}
+
+	if savedNode == nil {
+		savedNode = tview.NewTreeNode("Saved Searches").
+			SetReference("saved").
+			SetSelectable(false).
+			SetExpanded(true)
+
+		libraryRoot.SetChildren(append([]*tview.TreeNode{savedNode}, libraryRoot.GetChildren()...))
+	}
+
+	savedNode.ClearChildren()
+	for _, entry := range searchHistoryEntries {
+		if entry.Saved {
+			savedNode.AddChild(
+				tview.NewTreeNode(entry.Query).
+					SetReference(entry).
+					SetSelectable(true))
+		}
+	}
+
+	if len(savedNode.GetChildren()) == 0 {
+		libraryRoot.RemoveChild(savedNode)
+	}
+}
+
+// Restores the query and parameters of a history entry and runs it again
+func runHistoryEntry(entry SearchHistoryEntry) {
+	searchQueryPanel.SetText(entry.Query)
+	setSearchParams(entry.Params)
+
+	searchQueryDoneHandler(tcell.KeyEnter)
+}
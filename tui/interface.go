@@ -31,7 +31,7 @@ func findEntryInChildren(dn string, parent *tview.TreeNode) int {
 	siblings := parent.GetChildren()
 
 	for idx, loopNode := range siblings {
-		if loopNode.GetReference().(string) == dn {
+		if ref, ok := loopNode.GetReference().(string); ok && ref == dn {
 			return idx
 		}
 	}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Job is a cancellable query started by one of the pages.
+// Only one job with a given name can run at a time.
+type Job struct {
+	Name    string
+	Started time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var (
+	jobsLock sync.Mutex
+	jobs     = make(map[string]*Job)
+)
+
+// startJob registers a new job, logging a warning and
+// returning nil if a job with the same name is still running
+func startJob(name string) *Job {
+	jobsLock.Lock()
+
+	if _, running := jobs[name]; running {
+		jobsLock.Unlock()
+
+		// Callers may be on the UI goroutine, which
+		// QueueUpdateDraw would wait for forever
+		go app.QueueUpdateDraw(func() {
+			updateLog("Another query is still running...", "yellow")
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{Name: name, Started: time.Now(), ctx: ctx, cancel: cancel}
+	jobs[name] = job
+
+	jobsLock.Unlock()
+	return job
+}
+
+func (job *Job) Context() context.Context {
+	return job.ctx
+}
+
+// Cancelled reports whether the job was cancelled by the user
+func (job *Job) Cancelled() bool {
+	return errors.Is(job.ctx.Err(), context.Canceled)
+}
+
+// Finish releases the job so that it can be started again
+func (job *Job) Finish() {
+	jobsLock.Lock()
+	if jobs[job.Name] == job {
+		delete(jobs, job.Name)
+	}
+	jobsLock.Unlock()
+
+	job.cancel()
+}
+
+// Progress returns a page handler that shows how
+// many results the job has received so far
+func (job *Job) Progress() ldaputils.PageHandler {
+	return func(entries []*ldap.Entry, progress ldaputils.QueryProgress) {
+		app.QueueUpdateDraw(func() {
+			updateLog(fmt.Sprintf(
+				"Querying %s... (%d objects in %d pages, %.1fs - Esc to cancel)",
+				job.Name, progress.Entries, progress.Pages, time.Since(job.Started).Seconds(),
+			), "yellow")
+		})
+	}
+}
+
+func isJobRunning() bool {
+	jobsLock.Lock()
+	defer jobsLock.Unlock()
+
+	return len(jobs) > 0
+}
+
+// cancelJobs cancels every running job and returns how many were cancelled
+func cancelJobs() int {
+	jobsLock.Lock()
+	defer jobsLock.Unlock()
+
+	for _, job := range jobs {
+		job.cancel()
+	}
+
+	return len(jobs)
+}
+
+// LogError shows why the job stopped, which for
+// cancelled jobs is not reported as a failure
+func (job *Job) LogError(err error) {
+	app.QueueUpdateDraw(func() {
+		if job.Cancelled() {
+			updateLog("Query cancelled", "yellow")
+		} else {
+			updateLog(fmt.Sprint(err), "red")
+		}
+	})
+}
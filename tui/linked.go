@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+// Reports whether the values of an attribute reference other objects,
+// either by DN (member, managedBy, directReports...) or by SPN
+func isLinkAttribute(entry *ldap.Entry, attrName string) bool {
+	if strings.EqualFold(attrName, "msDS-AllowedToDelegateTo") {
+		return true
+	}
+
+	values := entry.GetEqualFoldAttributeValues(attrName)
+	if len(values) == 0 {
+		return false
+	}
+
+	for _, value := range values {
+		dn, err := ldap.ParseDN(value)
+		if err != nil || len(dn.RDNs) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func handleAttrsKeyCtrlK(currentNode *tview.TreeNode, attrsPanel *tview.Table, cache *EntryCache) {
+	baseDN, ok := currentNode.GetReference().(string)
+	if !ok {
+		return
+	}
+
+	attrRow, _ := attrsPanel.GetSelection()
+	attrName, ok := attrsPanel.GetCell(attrRow, 0).GetReference().(string)
+	if !ok {
+		return
+	}
+
+	entry, ok := cache.Get(baseDN)
+	if !ok || !isLinkAttribute(entry, attrName) {
+		updateLog("The attribute '"+attrName+"' doesn't reference other objects", "yellow")
+		return
+	}
+
+	openLinkedQueryForm(baseDN, attrName)
+}
+
+// Asks for a filter and the attributes to read from the objects
+// referenced by an attribute, and lists them in the search page
+func openLinkedQueryForm(baseDN string, attrName string) {
+	currentFocus := app.GetFocus()
+
+	linkedForm := NewXForm()
+	linkedForm.
+		AddTextView("Object DN", baseDN, 0, 1, false, true).
+		AddTextView("Attribute", attrName, 0, 1, false, true).
+		AddInputField("Filter", "(objectClass=*)", 0, nil, nil).
+		AddInputField("Attributes", strings.Join(SearchAttributes, ","), 0, nil, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Query", func() {
+			filter := linkedForm.GetFormItemByLabel("Filter").(*tview.InputField).GetText()
+			if _, err := ldaputils.ParseFilter(filter); err != nil {
+				updateLog(err.Error(), "red")
+				return
+			}
+
+			attrsText := linkedForm.GetFormItemByLabel("Attributes").(*tview.InputField).GetText()
+			SearchAttributes = ldaputils.ParseAttributeList(attrsText)
+			searchAttrsInput.SetText(strings.Join(SearchAttributes, ","))
+
+			job := startJob("search")
+			if job == nil {
+				return
+			}
+
+			updateLog("Querying the objects in '"+attrName+"' of '"+baseDN+"'...", "yellow")
+
+			resetSearchResults(attrName + " of " + baseDN)
+			searchQueryPanel.SetText(filter)
+
+			app.SetRoot(appPanel, true)
+			info.Highlight("1")
+			app.SetFocus(searchResultsPanel())
+
+			go runLinkedSearch(job, baseDN, attrName, filter)
+		})
+
+	linkedForm.SetInputCapture(handleEscape(currentFocus))
+	linkedForm.SetTitle("Query Linked Objects").SetBorder(true)
+	app.SetRoot(linkedForm, true).SetFocus(linkedForm)
+}
+
+// Lists the objects referenced by an attribute in the search results
+func runLinkedSearch(job *Job, baseDN string, attrName string, filter string) {
+	defer job.Finish()
+
+	opts, partial := searchQueryOptions(ldap.ScopeWholeSubtree, SearchAttributes)
+
+	streamSearchResults(job, "", partial, func(onPage ldaputils.PageHandler) (ldaputils.QueryProgress, error) {
+		return lc.QueryLinkedPages(job.Context(), baseDN, attrName, filter, opts, onPage)
+	})
+
+	app.QueueUpdateDraw(func() {
+		refreshSearchTable()
+	})
+}
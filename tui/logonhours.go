@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Opens a grid of the hours of the week in which the account may log on,
+// in the time zone of TimeOffset. Space/Enter toggles an hour, d and h
+// toggle the whole day and hour, and Tab moves to the buttons.
+func openLogonHoursEditor(baseDN string, rawValue []byte, done func()) {
+	currentFocus := app.GetFocus()
+
+	var hours ldaputils.LogonHours
+	if len(rawValue) > 0 {
+		var err error
+		hours, err = ldaputils.DecodeLogonHours(rawValue, TimeOffset)
+		if err != nil {
+			updateLog(fmt.Sprint(err), "red")
+			return
+		}
+	}
+
+	hoursGrid := tview.NewTable().
+		SetSelectable(true, true).
+		SetFixed(1, 1)
+
+	hoursGrid.SetCell(0, 0, tview.NewTableCell(fmt.Sprintf("UTC%+d", TimeOffset)).
+		SetTextColor(tcell.ColorYellow).
+		SetSelectable(false))
+
+	for hour := 0; hour < 24; hour++ {
+		hoursGrid.SetCell(0, hour+1, tview.NewTableCell(fmt.Sprintf("%02d", hour)).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+
+	for day, dayName := range ldaputils.WeekDays {
+		hoursGrid.SetCell(day+1, 0, tview.NewTableCell(dayName).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+
+	hoursForm := NewXForm()
+
+	updateCell := func(day int, hour int) {
+		cell := tview.NewTableCell(" · ").SetTextColor(tcell.ColorGray)
+		if hours[day][hour] {
+			cell = tview.NewTableCell(" █ ").SetTextColor(tcell.ColorGreen)
+		}
+
+		hoursGrid.SetCell(day+1, hour+1, cell)
+	}
+
+	updateGrid := func() {
+		for day := 0; day < 7; day++ {
+			for hour := 0; hour < 24; hour++ {
+				updateCell(day, hour)
+			}
+		}
+
+		hoursGrid.SetTitle("Logon Hours (" + strconv.Itoa(hours.Count()) + "/168 allowed)")
+	}
+
+	// Toggles a set of hours together: all of them become
+	// allowed unless they already were
+	toggleHours := func(cells [][2]int) {
+		allowed := true
+		for _, cell := range cells {
+			allowed = allowed && hours[cell[0]][cell[1]]
+		}
+
+		for _, cell := range cells {
+			hours[cell[0]][cell[1]] = !allowed
+		}
+
+		updateGrid()
+	}
+
+	hoursGrid.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, col := hoursGrid.GetSelection()
+		day, hour := row-1, col-1
+
+		switch event.Key() {
+		case tcell.KeyEscape:
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+			return nil
+		case tcell.KeyTab:
+			app.SetFocus(hoursForm)
+			return nil
+		case tcell.KeyEnter:
+			toggleHours([][2]int{{day, hour}})
+			return nil
+		}
+
+		switch event.Rune() {
+		case ' ':
+			toggleHours([][2]int{{day, hour}})
+			return nil
+		case 'd', 'D':
+			var cells [][2]int
+			for otherHour := 0; otherHour < 24; otherHour++ {
+				cells = append(cells, [2]int{day, otherHour})
+			}
+			toggleHours(cells)
+			return nil
+		case 'h', 'H':
+			var cells [][2]int
+			for otherDay := 0; otherDay < 7; otherDay++ {
+				cells = append(cells, [2]int{otherDay, hour})
+			}
+			toggleHours(cells)
+			return nil
+		}
+
+		return event
+	})
+
+	hoursForm.
+		AddButton("Allow All", func() {
+			hours = ldaputils.LogonHours{}
+			for day := range hours {
+				for hour := range hours[day] {
+					hours[day][hour] = true
+				}
+			}
+			updateGrid()
+		}).
+		AddButton("Deny All", func() {
+			hours = ldaputils.LogonHours{}
+			updateGrid()
+		}).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Update", func() {
+			raw := hours.Encode(TimeOffset)
+
+			err := lc.ModifyAttribute(baseDN, "logonHours", []string{string(raw)})
+			if err != nil {
+				updateLog(fmt.Sprint(err), "red")
+				return
+			}
+
+			updateLog("Logon hours updated ("+strconv.Itoa(hours.Count())+"/168 allowed) at: "+baseDN, "green")
+			if done != nil {
+				done()
+			}
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	hoursForm.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+			return nil
+		case tcell.KeyBacktab:
+			app.SetFocus(hoursGrid)
+			return nil
+		}
+
+		return event
+	})
+
+	updateGrid()
+	hoursGrid.Select(1, 1)
+	hoursGrid.SetBorder(true)
+
+	editorPanel := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(tview.NewTextView().SetText("Object DN: "+baseDN+"\nSpace/Enter: toggle hour | d: toggle day | h: toggle hour of every day | Tab: buttons"), 2, 0, false).
+		AddItem(hoursGrid, 11, 0, true).
+		AddItem(hoursForm, 3, 0, false)
+
+	app.SetRoot(editorPanel, true).SetFocus(hoursGrid)
+}
@@ -20,7 +20,6 @@ import (
 	"github.com/rivo/tview"
 	"golang.org/x/crypto/ssh/terminal"
 	"golang.org/x/term"
-	"h12.io/socks"
 	"software.sslmate.com/src/go-pkcs12"
 )
 
@@ -44,26 +43,35 @@ var (
 	PfxFile          string
 	CCachePath       string
 	BackendFlavor    string
-
-	Kerberos     bool
-	Emojis       bool
-	Colors       bool
-	FormatAttrs  bool
-	ExpandAttrs  bool
-	AttrSort     string
-	AttrLimit    int
-	CacheEntries bool
-	Deleted      bool
-	LoadSchema   bool
-	PagingSize   uint32
-	Timeout      int32
-	Insecure     bool
-	Ldaps        bool
-	SearchFilter string
-	RootDN       string
-	ShowHeader   bool
-	AuthType     int
-	ExportDir    string
+	DnsServer        string
+	DcSite           string
+
+	Kerberos      bool
+	Emojis        bool
+	Colors        bool
+	FormatAttrs   bool
+	ExpandAttrs   bool
+	AttrSort      string
+	AttrLimit     int
+	CacheEntries  bool
+	Deleted       bool
+	LoadSchema    bool
+	PagingSize    uint32
+	Timeout       int32
+	Insecure      bool
+	Ldaps         bool
+	SearchFilter  string
+	RootDN        string
+	ShowHeader    bool
+	AuthType      int
+	ExportDir     string
+	DiscoverDCs   bool
+	GlobalCatalog bool
+
+	KeepaliveInterval int
+	SearchAttributes  []string
+	LibraryPaths      []string
+	ChildrenPageSize  int
 
 	page int
 )
@@ -137,6 +145,8 @@ func toggleFlagF() {
 	if nodeSearch != nil {
 		reloadSearchAttrsPanel(nodeSearch, CacheEntries)
 	}
+
+	refreshSearchTable()
 }
 
 func toggleFlagE() {
@@ -158,6 +168,8 @@ func toggleFlagC() {
 	if nodeSearch != nil {
 		reloadSearchAttrsPanel(nodeSearch, CacheEntries)
 	}
+
+	refreshSearchTable()
 }
 
 func reloadAllAttrPanels() {
@@ -237,13 +249,18 @@ func writeDataExport(data map[string]any, dumpSuffix string, dumpFormat string)
 
 	jsonExportMap, _ := json.MarshalIndent(objectToExport, "", " ")
 
+	writeExportFile(outputFilename, jsonExportMap)
+}
+
+// Saves an export into ExportDir, logging the outcome
+func writeExportFile(filename string, contents []byte) {
 	err := os.MkdirAll(ExportDir, 0755)
 	if err != nil {
 		updateLog(fmt.Sprintf("%s", err), "red")
 	}
 
-	outputFilepath := filepath.Join(ExportDir, outputFilename)
-	err = ioutil.WriteFile(outputFilepath, jsonExportMap, 0644)
+	outputFilepath := filepath.Join(ExportDir, filename)
+	err = ioutil.WriteFile(outputFilepath, contents, 0644)
 
 	if err != nil {
 		updateLog(fmt.Sprintf("%s", err), "red")
@@ -268,7 +285,9 @@ func upgradeStartTLS() {
 
 func reconnectLdap() {
 	go app.QueueUpdateDraw(func() {
-		setupLDAPConn()
+		if err := setupLDAPConn(); err != nil {
+			updateLog(fmt.Sprint(err), "red")
+		}
 	})
 }
 
@@ -301,16 +320,35 @@ func getCurrentAuthType() int {
 }
 
 func openConfigForm() {
+	openConnConfigForm("Connection Configuration", func() {
+		updateSessionsPanel()
+		reconnectLdap()
+	})
+}
+
+func openConnConfigForm(title string, done func()) {
 	currentFocus := app.GetFocus()
 
 	// Main config form with connection settings
 	configForm := NewXForm()
 	configForm.
 		AddInputField("Server", LdapServer, 20, nil, nil).
-		AddInputField("Port", strconv.Itoa(LdapPort), 20, nil, nil).
+		AddInputField("Port", strconv.Itoa(LdapPort), 20, nil, nil)
+
+	// Picker to switch between DCs found with --discover
+	if len(discoveredDCs) > 0 {
+		configForm.AddDropDown("Discovered DC", discoveredDCOptions(), -1, func(option string, optionIndex int) {
+			if optionIndex >= 0 && optionIndex < len(discoveredDCs) {
+				configForm.GetFormItemByLabel("Server").(*tview.InputField).SetText(discoveredDCs[optionIndex].Host)
+			}
+		})
+	}
+
+	configForm.
 		AddCheckbox("LDAPS", Ldaps, nil).
 		AddCheckbox("IgnoreCert", Insecure, nil).
-		AddInputField("SOCKSProxy", SocksServer, 20, nil, nil).
+		AddCheckbox("GlobalCatalog", GlobalCatalog, nil).
+		AddInputField("Proxy", SocksServer, 20, nil, nil).
 		AddInputField("Domain", DomainName, 20, nil, nil).
 		AddDropDown("Auth Type", []string{
 			"Password",
@@ -403,7 +441,8 @@ func openConfigForm() {
 			LdapPort, _ = strconv.Atoi(configForm.GetFormItemByLabel("Port").(*tview.InputField).GetText())
 			Ldaps = configForm.GetFormItemByLabel("LDAPS").(*tview.Checkbox).IsChecked()
 			Insecure = configForm.GetFormItemByLabel("IgnoreCert").(*tview.Checkbox).IsChecked()
-			SocksServer = configForm.GetFormItemByLabel("SOCKSProxy").(*tview.InputField).GetText()
+			GlobalCatalog = configForm.GetFormItemByLabel("GlobalCatalog").(*tview.Checkbox).IsChecked()
+			SocksServer = configForm.GetFormItemByLabel("Proxy").(*tview.InputField).GetText()
 			DomainName = configForm.GetFormItemByLabel("Domain").(*tview.InputField).GetText()
 
 			// Update auth settings based on selected type
@@ -435,7 +474,7 @@ func openConfigForm() {
 			AuthType = authTypeField
 
 			app.SetRoot(appPanel, true).SetFocus(currentFocus)
-			reconnectLdap()
+			done()
 		})
 
 	// Create configPanel container for both forms
@@ -443,7 +482,7 @@ func openConfigForm() {
 		AddItem(configForm, 0, 1, true).
 		AddItem(authPages, 0, 1, false)
 
-	configPanel.SetBorder(true).SetTitle("Connection Configuration")
+	configPanel.SetBorder(true).SetTitle(title)
 
 	//assignFormTheme(credsForm)
 
@@ -494,6 +533,10 @@ func appPanelKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 		}
 	case 'l', 'L':
 		openConfigForm()
+	case ']':
+		nextSession()
+	case '[':
+		prevSession()
 	}
 
 	switch event.Key() {
@@ -501,6 +544,12 @@ func appPanelKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 		upgradeStartTLS()
 	case tcell.KeyCtrlR:
 		reconnectLdap()
+	case tcell.KeyCtrlT:
+		openNewSessionForm()
+		return nil
+	case tcell.KeyCtrlW:
+		closeCurrentSession()
+		return nil
 	}
 
 	return event
@@ -519,12 +568,14 @@ func setupLDAPConn() error {
 	updateLog("Connecting to LDAP server...", "yellow")
 
 	if lc != nil && lc.Conn != nil {
-		lc.Conn.Close()
+		lc.Close()
 	}
 
-	tlsConfig = secureTlsConfig
+	// Each connection gets its own copy, since the
+	// client certificates differ between sessions
+	tlsConfig = secureTlsConfig.Clone()
 	if Insecure {
-		tlsConfig = insecureTlsConfig
+		tlsConfig = insecureTlsConfig.Clone()
 	}
 
 	var (
@@ -541,20 +592,16 @@ func setupLDAPConn() error {
 		currentLdapPassword = strings.TrimSpace(LdapPassword)
 	} else if AuthType == 1 {
 		pw, err = readFileOrStdin(LdapPasswordFile, "Password: ")
-
 		if err != nil {
-			app.Stop()
-			log.Fatal(err)
+			return err
 		}
 		currentLdapPassword = strings.TrimSpace(string(pw))
 	} else if AuthType == 2 {
 		currentNtlmHash = strings.TrimSpace(NtlmHash)
 	} else if AuthType == 3 {
 		hash, err = readFileOrStdin(NtlmHashFile, "NTLM hash: ")
-
 		if err != nil {
-			app.Stop()
-			log.Fatal(err)
+			return err
 		}
 		currentNtlmHash = strings.TrimSpace(string(hash))
 	}
@@ -564,15 +611,13 @@ func setupLDAPConn() error {
 	if AuthType == 6 {
 		pfxData, err := os.ReadFile(PfxFile)
 		if err != nil {
-			app.Stop()
-			log.Fatalf("Error reading PFX file: %v", err)
+			return fmt.Errorf("Error reading PFX file: %v", err)
 		}
 
 		// Empty password for now - can be made configurable in the future
 		privateKey, cert, err := pkcs12.Decode(pfxData, "")
 		if err != nil {
-			app.Stop()
-			log.Fatalf("Error decoding PFX: %v", err)
+			return fmt.Errorf("Error decoding PFX: %v", err)
 		}
 
 		tlsCert := tls.Certificate{
@@ -585,22 +630,30 @@ func setupLDAPConn() error {
 	} else if AuthType == 5 {
 		cert, err := tls.LoadX509KeyPair(CertFile, KeyFile)
 		if err != nil {
-			app.Stop()
-			log.Fatalf("Error loading certificate / key: %v", err)
+			return fmt.Errorf("Error loading certificate / key: %v", err)
 		}
 
 		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
+	if AuthType == 4 {
+		if _, err := os.Stat(CCachePath); err != nil {
+			return err
+		}
+	}
+
 	var proxyConn net.Conn = nil
 	var err error = nil
 
-	if SocksServer != "" {
-		proxyDial := socks.Dial(SocksServer)
-		proxyConn, err = proxyDial("tcp", fmt.Sprintf("%s:%s", LdapServer, strconv.Itoa(LdapPort)))
+	proxyDial, err := getProxyDial()
+	if err != nil {
+		return err
+	}
+
+	if proxyDial != nil {
+		proxyConn, err = proxyDial("tcp", net.JoinHostPort(LdapServer, strconv.Itoa(LdapPort)))
 		if err != nil {
-			app.Stop()
-			log.Fatal(fmt.Sprint(err))
+			return err
 		}
 	}
 
@@ -617,6 +670,7 @@ func setupLDAPConn() error {
 	} else {
 		updateLog("Connection success", "green")
 		isSecure := Ldaps
+		lc.GlobalCatalog = GlobalCatalog
 
 		switch strings.ToLower(BackendFlavor) {
 		case "msad":
@@ -627,60 +681,92 @@ func setupLDAPConn() error {
 			lc.GuessFlavor()
 		}
 
+		// The bind is also used to restore the
+		// connection when it drops (see ldaputils.ConnParams)
 		var bindType string
-		if AuthType == 5 || AuthType == 6 {
-			if !Ldaps {
-				// If the connection was not using LDAPS, upgrade it with StartTLS
-				// and then perform an ExternalBind
-				err = lc.UpgradeToTLS(tlsConfig)
-				if err != nil {
-					app.Stop()
-					log.Fatal(err)
-				}
+		currentAuthType := AuthType
+		currentLdapUsername = LdapUsername
+		currentDomainName := DomainName
+		currentCCachePath := CCachePath
+		currentTargetSpn := TargetSpn
+		currentTlsConfig := tlsConfig
+		currentLdaps := Ldaps
+
+		var KdcAddr string
+		if KdcHost != "" {
+			KdcAddr = KdcHost
+		} else {
+			KdcAddr = LdapServer
+		}
+
+		if currentAuthType == 0 || currentAuthType == 1 {
+			if !strings.Contains(LdapUsername, "@") && !strings.Contains(LdapUsername, ",") && LdapUsername != "" && DomainName != "" {
+				currentLdapUsername += "@" + DomainName
+			}
+		}
 
-				err = lc.ExternalBind()
-				if err != nil {
-					app.Stop()
-					log.Fatal(err)
+		bind := func(conn *ldaputils.LDAPConn) error {
+			switch currentAuthType {
+			case 5, 6:
+				if !currentLdaps {
+					// If the connection was not using LDAPS, upgrade it with StartTLS
+					// and then perform an ExternalBind
+					if _, hasTLS := conn.TLSConnectionState(); !hasTLS {
+						err := conn.UpgradeToTLS(currentTlsConfig)
+						if err != nil {
+							return err
+						}
+					}
+
+					return conn.ExternalBind()
 				}
+				return nil
+			case 4:
+				return conn.KerbBindWithCCache(currentCCachePath, KdcAddr, currentDomainName, currentTargetSpn, "aes", proxyDial)
+			case 2, 3:
+				return conn.NTLMBindWithHash(currentDomainName, currentLdapUsername, currentNtlmHash)
+			default:
+				return conn.LDAPBind(currentLdapUsername, currentLdapPassword)
 			}
+		}
 
+		switch currentAuthType {
+		case 5, 6:
 			isSecure = true
 			bindType = "LDAP+ClientCertificate"
-		} else if AuthType == 4 {
-			if _, err := os.Stat(CCachePath); err != nil {
-				app.Stop()
-				log.Fatal(err)
-			}
-
-			var KdcAddr string
-			if KdcHost != "" {
-				KdcAddr = KdcHost
-			} else {
-				KdcAddr = LdapServer
-			}
-
-			err = lc.KerbBindWithCCache(CCachePath, KdcAddr, DomainName, TargetSpn, "aes")
+		case 4:
 			bindType = "Kerberos"
-		} else if AuthType == 2 || AuthType == 3 {
-			err = lc.NTLMBindWithHash(DomainName, LdapUsername, currentNtlmHash)
+		case 2, 3:
 			bindType = "NTLM"
-		} else {
-			currentLdapUsername = LdapUsername
-			if !strings.Contains(LdapUsername, "@") && !strings.Contains(LdapUsername, ",") && LdapUsername != "" && DomainName != "" {
-				currentLdapUsername += "@" + DomainName
-			}
-
-			err = lc.LDAPBind(currentLdapUsername, currentLdapPassword)
+		default:
 			bindType = "LDAP"
 		}
 
+		err = bind(lc)
 		if err != nil {
 			// Bind failed
 			updateLog(fmt.Sprint(err), "red")
 		} else {
 			updateStateBox(tlsPanel, isSecure)
 			updateLog("Bind success ("+bindType+")", "green")
+
+			lc.SetConnParams(ldaputils.ConnParams{
+				Server: LdapServer,
+				Port:   LdapPort,
+				Ldaps:  Ldaps,
+				Dial:   proxyDial,
+				Bind:   bind,
+			})
+
+			monitorConnection(lc)
+
+			// Each connection keeps the syntaxes of the attributes
+			// of its own server for the formatters
+			if LoadSchema {
+				if err := lc.LoadAttributeSchemas(); err != nil {
+					updateLog(fmt.Sprintf("Error loading attribute syntaxes: %s", err), "red")
+				}
+			}
 		}
 	}
 
@@ -689,11 +775,53 @@ func setupLDAPConn() error {
 	return err
 }
 
+// Reflects the health of a connection in the Bind status box
+// while it's the active one and keeps it alive in the background
+func monitorConnection(conn *ldaputils.LDAPConn) {
+	conn.OnStateChange = func(state ldaputils.ConnState) {
+		// Called from the goroutines of the connection,
+		// so lc is only compared on the UI goroutine
+		go app.QueueUpdateDraw(func() {
+			if lc != conn {
+				return
+			}
+
+			updateConnStateBox(state)
+
+			switch state {
+			case ldaputils.StateReconnecting:
+				updateLog("Connection lost, reconnecting...", "yellow")
+			case ldaputils.StateDisconnected:
+				updateLog("Connection lost (use Ctrl+R to reconnect)", "red")
+			}
+		})
+	}
+
+	if KeepaliveInterval > 0 {
+		conn.StartKeepalive(
+			time.Duration(KeepaliveInterval)*time.Second,
+			time.Duration(Timeout)*time.Second/2,
+		)
+	}
+}
+
 func appKeyHandler(event *tcell.EventKey) *tcell.EventKey {
+	// Forms and modals handle Esc themselves
+	if event.Key() == tcell.KeyEscape && appPanel.HasFocus() {
+		if cancelled := cancelJobs(); cancelled > 0 {
+			updateLog(fmt.Sprintf("Cancelling %d running queries...", cancelled), "yellow")
+			return nil
+		}
+	}
+
 	_, isTextArea := app.GetFocus().(*tview.TextArea)
 	_, isInputField := app.GetFocus().(*tview.InputField)
 
 	if isTextArea || isInputField {
+		if event.Key() == tcell.KeyCtrlV && pasteClipboardDN(app.GetFocus()) {
+			return nil
+		}
+
 		return event
 	}
 
@@ -774,21 +902,24 @@ func SetupApp() {
 
 	AuthType = getCurrentAuthType()
 
+	if DiscoverDCs {
+		updateLog("Discovering domain controllers...", "yellow")
+		err := discoverDomainControllers()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	err := setupLDAPConn()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	if RootDN == "" {
-		RootDN, err = lc.FindRootDN()
-		if err != nil {
-			log.Fatal(err)
-		}
+	err = setupRootDN()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	lc.DefaultRootDN = RootDN
-	lc.RootDN = RootDN
-
 	// Pages setup
 	// TODO: Refactor this chunk
 	initExplorerPage()
@@ -799,32 +930,6 @@ func SetupApp() {
 	initADIDNSPage()
 	initHelpPage()
 
-	var pageVars []GodapPage
-	if lc.Flavor == ldaputils.MicrosoftADFlavor {
-		pageVars = []GodapPage{
-			{0, explorerPage, "Explorer"},
-			{1, searchPage, "Search"},
-			{2, groupPage, "Groups"},
-			{3, daclPage, "DACLs"},
-			{4, gpoPage, "GPOs"},
-			{5, dnsPage, "ADIDNS"},
-			{6, helpPage, "Help"},
-		}
-	} else if lc.Flavor == ldaputils.BasicLDAPFlavor {
-		pageVars = []GodapPage{
-			{0, explorerPage, "Explorer"},
-			{1, searchPage, "Search"},
-			{2, groupPage, "Groups"},
-			{3, helpPage, "Help"},
-		}
-	}
-
-	for _, page := range pageVars {
-		pages.AddPage("page-"+strconv.Itoa(page.idx), page.prim, true, false)
-	}
-
-	pages.ShowPage("page-0")
-
 	info.SetDynamicColors(true).
 		SetRegions(true).
 		SetWrap(false).
@@ -840,12 +945,6 @@ func SetupApp() {
 			}
 		})
 
-	for idx, page := range pageVars {
-		fmt.Fprintf(info, `%d ["%s"][darkcyan]%s[white][""]  `, idx+1, strconv.Itoa(idx), page.title)
-	}
-
-	info.Highlight("0")
-
 	headerPanel = tview.NewFlex().
 		AddItem(tlsPanel, 0, 1, false).
 		AddItem(statusPanel, 0, 1, false).
@@ -855,12 +954,15 @@ func SetupApp() {
 		AddItem(sortAttrsFlagPanel, 0, 1, false).
 		AddItem(emojiFlagPanel, 0, 1, false)
 
-	if lc.Flavor == ldaputils.MicrosoftADFlavor {
-		headerPanel.AddItem(deletedFlagPanel, 0, 1, false)
-	}
+	setupPages()
+	initSessions()
+
+	topPanel := tview.NewFlex().
+		AddItem(info, 0, 2, false).
+		AddItem(sessionsPanel, 0, 1, false)
 
 	appPanel = tview.NewFlex().SetDirection(tview.FlexRow).
-		AddItem(info, 1, 1, false).
+		AddItem(topPanel, 1, 1, false).
 		AddItem(logPanel, 3, 0, false).
 		AddItem(headerPanel, 3, 0, false).
 		AddItem(pages, 0, 8, false)
@@ -882,6 +984,79 @@ func SetupApp() {
 	}
 }
 
+// setupRootDN finds the default naming context of the connection
+// and the initial root of the explorer, which in GC mode is the
+// RootDSE (listing every domain partition) unless -r was provided
+func setupRootDN() error {
+	explorerRootDN := RootDN
+
+	// The naming context is only looked up when no root DN
+	// was given, since some servers don't advertise it
+	if RootDN == "" {
+		defaultRootDN, err := lc.FindRootDN()
+		if err != nil {
+			return err
+		}
+
+		RootDN = defaultRootDN
+		if !lc.GlobalCatalog {
+			explorerRootDN = RootDN
+		}
+	}
+
+	lc.DefaultRootDN = RootDN
+	lc.RootDN = explorerRootDN
+	RootDN = explorerRootDN
+
+	return nil
+}
+
+// setupPages registers the pages available for the
+// flavor of the current connection and their tabs
+func setupPages() {
+	for idx := 0; pages.HasPage("page-" + strconv.Itoa(idx)); idx++ {
+		pages.RemovePage("page-" + strconv.Itoa(idx))
+	}
+
+	var pageVars []GodapPage
+	if lc.Flavor == ldaputils.MicrosoftADFlavor {
+		pageVars = []GodapPage{
+			{0, explorerPage, "Explorer"},
+			{1, searchPage, "Search"},
+			{2, groupPage, "Groups"},
+			{3, daclPage, "DACLs"},
+			{4, gpoPage, "GPOs"},
+			{5, dnsPage, "ADIDNS"},
+			{6, helpPage, "Help"},
+		}
+	} else if lc.Flavor == ldaputils.BasicLDAPFlavor {
+		pageVars = []GodapPage{
+			{0, explorerPage, "Explorer"},
+			{1, searchPage, "Search"},
+			{2, groupPage, "Groups"},
+			{3, helpPage, "Help"},
+		}
+	}
+
+	for _, page := range pageVars {
+		pages.AddPage("page-"+strconv.Itoa(page.idx), page.prim, true, false)
+	}
+
+	pages.ShowPage("page-0")
+
+	info.Clear()
+	for idx, page := range pageVars {
+		fmt.Fprintf(info, `%d ["%s"][darkcyan]%s[white][""]  `, idx+1, strconv.Itoa(idx), page.title)
+	}
+
+	info.Highlight("0")
+
+	headerPanel.RemoveItem(deletedFlagPanel)
+	if lc.Flavor == ldaputils.MicrosoftADFlavor {
+		headerPanel.AddItem(deletedFlagPanel, 0, 1, false)
+	}
+}
+
 // setupTimeFormat returns the time format string based on the given format code.
 // The format code can be one of the following:
 // - "EU" or empty string: returns the format "02/01/2006 15:04:05" (day/month/year hour:minute:second)
@@ -912,6 +1087,19 @@ func updateStateBox(target *tview.TextView, control bool) {
 	})
 }
 
+func updateConnStateBox(state ldaputils.ConnState) {
+	statusPanel.SetText(state.String())
+
+	switch state {
+	case ldaputils.StateConnected:
+		statusPanel.SetTextColor(tcell.GetColor("green"))
+	case ldaputils.StateDegraded, ldaputils.StateReconnecting:
+		statusPanel.SetTextColor(tcell.GetColor("yellow"))
+	default:
+		statusPanel.SetTextColor(tcell.GetColor("red"))
+	}
+}
+
 func updateLog(msg string, color string) {
 	currentTime := time.Now()
 	formattedTime := currentTime.Format("2006-01-02 15:04:05")
@@ -924,7 +1112,7 @@ func setPageFocus() {
 	case 0:
 		app.SetFocus(treePanel)
 	case 1:
-		app.SetFocus(searchTreePanel)
+		app.SetFocus(searchResultsPanel())
 	case 2:
 		app.SetFocus(membersPanel)
 	case 3:
@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"net"
+	"time"
+
+	"github.com/Macmod/godap/v2/pkg/proxy"
+)
+
+// Chains are kept for the whole execution so that
+// reconnections reuse their persistent hops (SSH)
+var proxyChains = make(map[string]*proxy.Chain)
+
+// getProxyDial returns the dial function of the proxy chain
+// specified in SocksServer, or nil if no proxy is being used
+func getProxyDial() (func(network, address string) (net.Conn, error), error) {
+	if SocksServer == "" {
+		return nil, nil
+	}
+
+	chain, ok := proxyChains[SocksServer]
+	if !ok {
+		var err error
+		chain, err = proxy.ParseChain(SocksServer, time.Duration(Timeout)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+
+		proxyChains[SocksServer] = chain
+	}
+
+	return chain.Dial, nil
+}
@@ -0,0 +1,248 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Loads the objects of the Deleted Objects containers in the
+// background and lists them once done, to restore them
+func openDeletedObjects() {
+	if lc.Flavor != ldaputils.MicrosoftADFlavor {
+		updateLog("Deleted objects can only be listed on Active Directory", "red")
+		return
+	}
+
+	job := startJob("deleted objects")
+	if job == nil {
+		return
+	}
+
+	currentFocus := app.GetFocus()
+	updateLog("Loading deleted objects...", "yellow")
+
+	go func() {
+		defer job.Finish()
+
+		objects, err := lc.QueryDeletedObjects(job.Context(), job.Progress())
+		if err != nil {
+			job.LogError(err)
+			return
+		}
+
+		recycleBin, err := lc.RecycleBinEnabled()
+		if err != nil {
+			app.QueueUpdateDraw(func() {
+				updateLog(fmt.Sprintf("Error checking the Recycle Bin: %s", err), "red")
+			})
+		}
+
+		app.QueueUpdateDraw(func() {
+			showDeletedObjects(objects, recycleBin, currentFocus)
+			updateLog(fmt.Sprintf("Loaded %d deleted objects", len(objects)), "green")
+		})
+	}()
+}
+
+// State of a deleted object as shown in the list
+func deletedObjectState(obj ldaputils.DeletedObject, recycleBin bool) (string, tcell.Color) {
+	switch {
+	case obj.Recycled:
+		return "Recycled", tcell.ColorRed
+	case obj.ParentDeleted():
+		return "Parent deleted", tcell.ColorYellow
+	case !recycleBin:
+		return "Tombstone", tcell.ColorYellow
+	}
+
+	return "Deleted", tcell.ColorGreen
+}
+
+// Lists the deleted objects with a filter over their names and
+// parents. Enter restores the selected object and Tab moves
+// between the filter and the list.
+func showDeletedObjects(objects []ldaputils.DeletedObject, recycleBin bool, returnTo tview.Primitive) {
+	filterInput := tview.NewInputField()
+	filterInput.SetTitle("Filter").SetBorder(true)
+	assignInputFieldTheme(filterInput)
+
+	objectsTable := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	objectsTable.SetBorder(true)
+
+	recycleBinState := "Disabled"
+	if recycleBin {
+		recycleBinState = "Enabled"
+	}
+
+	// Objects listed under the current filter, by row
+	var listed []ldaputils.DeletedObject
+
+	updateTable := func() {
+		objectsTable.Clear()
+
+		headers := []string{"Deleted", "Name", "Class", "Last Known Parent", "State"}
+		for col, header := range headers {
+			objectsTable.SetCell(0, col, tview.NewTableCell(header).
+				SetTextColor(tcell.ColorYellow).
+				SetSelectable(false))
+		}
+
+		filter := strings.ToLower(filterInput.GetText())
+
+		listed = nil
+		for _, obj := range objects {
+			if filter != "" && !strings.Contains(strings.ToLower(obj.Name+"\n"+obj.LastKnownParent+"\n"+obj.ObjectClass), filter) {
+				continue
+			}
+
+			deleted := "(Unknown)"
+			if !obj.WhenDeleted.IsZero() {
+				deleted = obj.WhenDeleted.Add(time.Hour * time.Duration(TimeOffset)).Format(TimeFormat)
+			}
+
+			state, color := deletedObjectState(obj, recycleBin)
+
+			row := len(listed) + 1
+			objectsTable.SetCell(row, 0, tview.NewTableCell(deleted))
+			objectsTable.SetCell(row, 1, tview.NewTableCell(obj.Name).SetMaxWidth(40))
+			objectsTable.SetCell(row, 2, tview.NewTableCell(obj.ObjectClass))
+			objectsTable.SetCell(row, 3, tview.NewTableCell(obj.LastKnownParent).SetMaxWidth(60))
+			objectsTable.SetCell(row, 4, tview.NewTableCell(state).SetTextColor(color))
+
+			listed = append(listed, obj)
+		}
+
+		objectsTable.SetTitle(fmt.Sprintf("Deleted Objects (%d) - Recycle Bin: %s", len(listed), recycleBinState))
+		objectsTable.ScrollToBeginning()
+		if len(listed) > 0 {
+			objectsTable.Select(1, 0)
+		}
+	}
+
+	var deletedPanel *tview.Flex
+
+	back := func() {
+		app.SetRoot(appPanel, true).SetFocus(returnTo)
+	}
+
+	objectsTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			back()
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab:
+			app.SetFocus(filterInput)
+			return nil
+		case tcell.KeyEnter:
+			row, _ := objectsTable.GetSelection()
+			if row < 1 || row > len(listed) {
+				return nil
+			}
+
+			obj := listed[row-1]
+			if obj.Recycled {
+				updateLog("Recycled objects can't be restored anymore", "red")
+				return nil
+			}
+
+			openRestoreObjectForm(obj, recycleBin, deletedPanel, func() {
+				objects = slices.DeleteFunc(objects, func(other ldaputils.DeletedObject) bool {
+					return other.DN == obj.DN
+				})
+				updateTable()
+			})
+			return nil
+		}
+
+		return event
+	})
+
+	filterInput.SetChangedFunc(func(text string) {
+		updateTable()
+	})
+
+	filterInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			back()
+			return nil
+		case tcell.KeyTab, tcell.KeyBacktab, tcell.KeyEnter:
+			app.SetFocus(objectsTable)
+			return nil
+		}
+
+		return event
+	})
+
+	updateTable()
+
+	deletedPanel = tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(filterInput, 3, 0, false).
+		AddItem(objectsTable, 0, 1, true)
+
+	app.SetRoot(deletedPanel, true).SetFocus(objectsTable)
+}
+
+// Asks for the DN to restore a deleted object as,
+// which defaults to the one it had before
+func openRestoreObjectForm(obj ldaputils.DeletedObject, recycleBin bool, returnTo tview.Primitive, done func()) {
+	restoreForm := NewXForm()
+	restoreForm.SetItemPadding(0)
+	restoreForm.
+		AddTextView("Deleted DN", obj.DN, 0, 1, false, true).
+		AddTextView("Last Known Parent", obj.LastKnownParent, 0, 1, false, true).
+		AddInputField("Restore as", obj.OriginalDN(), 0, nil, nil)
+
+	if obj.ParentDeleted() {
+		restoreForm.AddTextView("Note", "The parent was deleted too - restore it first or pick another DN", 0, 1, false, false)
+	} else if !recycleBin {
+		restoreForm.AddTextView("Note", "The Recycle Bin is disabled - only the attributes kept in the tombstone are restored", 0, 1, false, false)
+	}
+
+	restoreForm.GetFormItemByLabel("Restore as").(*tview.InputField).SetAutocompleteFunc(dnCompletions)
+
+	restoreForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(returnTo, true).SetFocus(returnTo)
+		}).
+		AddButton("Restore", func() {
+			newDN := strings.TrimSpace(restoreForm.GetFormItemByLabel("Restore as").(*tview.InputField).GetText())
+			if newDN == "" {
+				updateLog("The DN to restore the object as is required", "red")
+				return
+			}
+
+			err := lc.RestoreDeletedObject(obj.DN, newDN)
+			if err != nil {
+				updateLog(fmt.Sprint(err), "red")
+				return
+			}
+
+			updateLog("Object restored: '"+newDN+"'", "green")
+			if done != nil {
+				done()
+			}
+
+			app.SetRoot(returnTo, true).SetFocus(returnTo)
+		})
+
+	restoreForm.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			app.SetRoot(returnTo, true).SetFocus(returnTo)
+			return nil
+		}
+		return event
+	})
+
+	restoreForm.SetTitle("Restore Deleted Object").SetBorder(true)
+	app.SetRoot(restoreForm, true).SetFocus(restoreForm)
+}
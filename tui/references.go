@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+// Returns the loaded child of a node with the given DN
+func findChildNode(parent *tview.TreeNode, dn *ldap.DN) *tview.TreeNode {
+	for _, child := range parent.GetChildren() {
+		ref, ok := child.GetReference().(string)
+		if !ok {
+			continue
+		}
+
+		childDN, err := ldap.ParseDN(ref)
+		if err == nil && childDN.EqualFold(dn) {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Selects an object in the explorer, loading and expanding every
+// container on the path to it. Containers with more children than
+// a page jump straight to the page where the next object is.
+func navigateToDN(dn string) error {
+	root := treePanel.GetRoot()
+	if root == nil {
+		return fmt.Errorf("The explorer is not loaded")
+	}
+
+	target, err := ldap.ParseDN(dn)
+	if err != nil {
+		return err
+	}
+
+	rootDN, err := ldap.ParseDN(root.GetReference().(string))
+	if err != nil {
+		return err
+	}
+
+	if !target.EqualFold(rootDN) && !rootDN.AncestorOfFold(target) {
+		return fmt.Errorf("'%s' is outside of the explorer root", dn)
+	}
+
+	node := root
+	for depth := len(rootDN.RDNs) + 1; depth <= len(target.RDNs); depth++ {
+		childDN := &ldap.DN{RDNs: target.RDNs[len(target.RDNs)-depth:]}
+
+		if len(node.GetChildren()) == 0 {
+			loadChildren(node)
+		}
+
+		child := findChildNode(node, childDN)
+		if child == nil {
+			if _, paged := childPagers[node]; paged {
+				loadChildrenPage(node, childDN.RDNs[0].Attributes[0].Value)
+				child = findChildNode(node, childDN)
+			}
+		}
+
+		if child == nil {
+			return fmt.Errorf("'%s' not found in the explorer", childDN.String())
+		}
+
+		node.SetExpanded(true)
+		node = child
+	}
+
+	info.Highlight("0")
+	treePanel.SetCurrentNode(node)
+	reloadExplorerAttrsPanel(node, CacheEntries)
+	app.SetFocus(treePanel)
+
+	return nil
+}
+
+// Jumps to the object referenced by a value of a link attribute,
+// resolving SPNs to the accounts that hold them
+func navigateToReference(attrName string, value string) {
+	dn, err := lc.ResolveReference(attrName, value)
+	if err == nil {
+		err = jumpToDN(dn)
+	}
+
+	if err != nil {
+		updateLog(fmt.Sprint(err), "red")
+		return
+	}
+
+	updateLog("Jumped to '"+dn+"'", "green")
+}
+
+// Handles Enter on a value of a link attribute. In the expanded view
+// each row holds one value; otherwise the value is picked from a list.
+func handleAttrsKeyEnterReference(attrsPanel *tview.Table, entry *ldap.Entry, attrName string) {
+	values := entry.GetEqualFoldAttributeValues(attrName)
+
+	selectedRow, _ := attrsPanel.GetSelection()
+	if cellText := attrsPanel.GetCell(selectedRow, 1).Text; slices.Contains(values, cellText) {
+		values = []string{cellText}
+	}
+
+	if len(values) == 1 {
+		navigateToReference(attrName, values[0])
+		return
+	}
+
+	openReferencePicker(attrName, values)
+}
+
+// Lists the values of a link attribute to pick the one to jump to
+func openReferencePicker(attrName string, values []string) {
+	currentFocus := app.GetFocus()
+
+	valuesList := tview.NewList().ShowSecondaryText(false)
+	for _, value := range values {
+		value := value
+		valuesList.AddItem(value, "", 0, func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+			navigateToReference(attrName, value)
+		})
+	}
+
+	valuesList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+			return nil
+		}
+		return event
+	})
+
+	valuesList.SetTitle("Go to a value of " + attrName).SetBorder(true)
+	app.SetRoot(valuesList, true).SetFocus(valuesList)
+}
+
+// Lists in the search page the objects that reference
+// an object in any DN-valued attribute
+func openWhereUsed(baseDN string) {
+	job := startJob("search")
+	if job == nil {
+		return
+	}
+
+	updateLog("Searching the objects that reference '"+baseDN+"'...", "yellow")
+
+	resetSearchResults("Where used: " + baseDN)
+
+	info.Highlight("1")
+	app.SetFocus(searchResultsPanel())
+
+	go runWhereUsedSearch(job, baseDN)
+}
+
+func runWhereUsedSearch(job *Job, baseDN string) {
+	defer job.Finish()
+
+	opts, partial := searchQueryOptions(ldap.ScopeWholeSubtree, SearchAttributes)
+
+	searchBase := lc.SearchBase()
+	streamSearchResults(job, searchBase, partial, func(onPage ldaputils.PageHandler) (ldaputils.QueryProgress, error) {
+		return lc.QueryReferencesPages(job.Context(), searchBase, baseDN, opts, onPage)
+	})
+
+	app.QueueUpdateDraw(func() {
+		refreshSearchTable()
+	})
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Loads the replication metadata of an object in the background
+// and shows it as a timeline of the last change of each attribute
+// and linked value. Esc cancels it while it's running.
+func openReplicationTimeline(baseDN string) {
+	job := startJob("replication metadata")
+	if job == nil {
+		return
+	}
+
+	currentFocus := app.GetFocus()
+	updateLog("Loading the replication metadata of '"+baseDN+"'...", "yellow")
+
+	go func() {
+		defer job.Finish()
+
+		changes, err := lc.ReplicationTimeline(baseDN)
+		if err == nil && job.Cancelled() {
+			err = job.Context().Err()
+		}
+
+		if err != nil {
+			job.LogError(err)
+			return
+		}
+
+		app.QueueUpdateDraw(func() {
+			if len(changes) == 0 {
+				updateLog("No replication metadata found for '"+baseDN+"'", "yellow")
+				return
+			}
+
+			showReplicationTimeline(baseDN, changes, currentFocus)
+		})
+	}()
+}
+
+func showReplicationTimeline(baseDN string, changes []ldaputils.ReplChange, returnTo tview.Primitive) {
+	timelinePanel := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+
+	headers := []string{"Time", "Attribute", "Change", "Version", "Originating DC", "Orig. USN", "Local USN"}
+	for col, header := range headers {
+		timelinePanel.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+
+	for idx, change := range changes {
+		row := idx + 1
+
+		changeTime := "(Unknown)"
+		if !change.Time.IsZero() {
+			changeTime = change.Time.Add(time.Hour * time.Duration(TimeOffset)).Format(TimeFormat)
+		}
+
+		description := "Modified"
+		color := tview.Styles.PrimaryTextColor
+		if change.Value != "" {
+			if change.Removed {
+				description = "Removed " + change.Value
+				color = tcell.ColorRed
+			} else {
+				description = "Added " + change.Value
+				color = tcell.ColorGreen
+			}
+		} else if change.Version == 1 {
+			description = "Set"
+		}
+
+		timelinePanel.SetCell(row, 0, tview.NewTableCell(changeTime))
+		timelinePanel.SetCell(row, 1, tview.NewTableCell(change.Attribute))
+		timelinePanel.SetCell(row, 2, tview.NewTableCell(description).SetTextColor(color).SetMaxWidth(80))
+		timelinePanel.SetCell(row, 3, tview.NewTableCell(strconv.Itoa(change.Version)))
+		timelinePanel.SetCell(row, 4, tview.NewTableCell(change.OriginatingDC))
+		timelinePanel.SetCell(row, 5, tview.NewTableCell(strconv.FormatInt(change.OriginatingUSN, 10)))
+		timelinePanel.SetCell(row, 6, tview.NewTableCell(strconv.FormatInt(change.LocalUSN, 10)))
+	}
+
+	timelinePanel.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			app.SetRoot(appPanel, true).SetFocus(returnTo)
+			return nil
+		}
+
+		return event
+	})
+
+	timelinePanel.SetTitle("Replication Metadata (" + baseDN + ")").SetBorder(true)
+	app.SetRoot(timelinePanel, true).SetFocus(timelinePanel)
+
+	updateLog(fmt.Sprintf("Loaded %d changes from the replication metadata of '%s'", len(changes), baseDN), "green")
+}
@@ -2,10 +2,11 @@ package tui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/Macmod/godap/v2/pkg/ldaputils"
@@ -16,14 +17,18 @@ import (
 
 var (
 	searchTreePanel  *tview.TreeView
-	searchQueryPanel *tview.InputField
+	searchQueryPanel *filterInputField
 	searchAttrsPanel *tview.Table
+	searchAttrsInput *tview.InputField
+
+	searchBaseInput      *tview.InputField
+	searchScopeInput     *tview.DropDown
+	searchSizeLimitInput *tview.InputField
+	searchTimeLimitInput *tview.InputField
 
 	searchLibraryPanel *tview.TreeView
 	sidePanel          *tview.Pages
 	searchPage         *tview.Flex
-	runControl         sync.Mutex
-	running            bool
 
 	searchCache          EntryCache
 	searchHistoryEntries []SearchHistoryEntry
@@ -31,14 +36,29 @@ var (
 	searchHistoryPanel *tview.Table
 )
 
+// Where and how far the search page searches
+type SearchParams struct {
+	BaseDN     string
+	Scope      int
+	SizeLimit  int
+	TimeLimit  int
+	Attributes []string
+}
+
+// Scopes in the order they're listed in the scope picker
+var searchScopes = []int{ldap.ScopeWholeSubtree, ldap.ScopeSingleLevel, ldap.ScopeBaseObject}
+var searchScopeNames = []string{"Subtree", "One level", "Base"}
+
 type SearchHistoryEntry struct {
 	Timestamp time.Time
 	Query     string
+	Params    SearchParams
 	Duration  time.Duration
 	Results   int
+	Saved     bool
 }
 
-func addToSearchHistory(query string, duration time.Duration, results int) {
+func addToSearchHistory(query string, params SearchParams, duration time.Duration, results int) {
 	// Don't add empty queries to history
 	if query == "" {
 		return
@@ -47,18 +67,104 @@ func addToSearchHistory(query string, duration time.Duration, results int) {
 	entry := SearchHistoryEntry{
 		Timestamp: time.Now(),
 		Query:     query,
+		Params:    params,
 		Duration:  duration,
 		Results:   results,
 	}
 
 	// Add to beginning of slice (most recent first)
 	searchHistoryEntries = append([]SearchHistoryEntry{entry}, searchHistoryEntries...)
+	saveSearchHistory()
 }
 
 var searchLoadedDNs map[string]*tview.TreeNode = make(map[string]*tview.TreeNode)
 
+// Results loaded without all of their attributes
+var searchPartialDNs map[string]bool = make(map[string]bool)
+
+// Operational attributes selected with Ctrl+O
+var searchOperationalAttrs []string
+
+// Returns the options of searches made with the given scope and
+// attributes, and whether they return partial entries. Attributes that
+// AD only computes for base searches are left out of other scopes.
+func searchQueryOptions(scope int, attributes []string) (ldaputils.QueryOptions, bool) {
+	opts := ldaputils.QueryOptions{
+		Attributes:  attributes,
+		ShowDeleted: Deleted,
+	}
+
+	partial := opts.Partial()
+
+	// The columns of the results table are requested even
+	// if they're not among the projected attributes
+	if partial {
+		for _, column := range SearchTableColumns {
+			if !slices.Contains(opts.Attributes, column) {
+				opts.Attributes = append(slices.Clone(opts.Attributes), column)
+			}
+		}
+	}
+
+	var extraAttrs []string
+	for _, attr := range searchOperationalAttrs {
+		if scope != ldap.ScopeBaseObject && slices.Contains(ldaputils.BaseScopeOnlyAttributes, attr) {
+			partial = true
+			continue
+		}
+
+		extraAttrs = append(extraAttrs, attr)
+	}
+
+	if len(extraAttrs) > 0 {
+		if len(opts.Attributes) == 0 {
+			opts.Attributes = []string{"*"}
+		}
+
+		opts.Attributes = append(slices.Clone(opts.Attributes), extraAttrs...)
+	}
+
+	return opts, partial
+}
+
 func reloadSearchAttrsPanel(node *tview.TreeNode, useCache bool) {
-	reloadAttributesPanel(node, searchAttrsPanel, useCache, &searchCache)
+	ref, ok := node.GetReference().(string)
+	if ok && !useCache {
+		if err := fetchFullSearchEntry(ref); err != nil {
+			updateLog(fmt.Sprint(err), "red")
+		}
+	}
+
+	title := ""
+	if ok && searchPartialDNs[ref] {
+		title = "Partial attributes - press r to load all"
+	}
+
+	sidePanel.SetTitle(gcAttrsMarker(title))
+	reloadAttributesPanel(node, searchAttrsPanel, true, &searchCache)
+}
+
+// Fetches every user attribute of a search result along with
+// the selected operational ones, completing partial entries
+func fetchFullSearchEntry(baseDN string) error {
+	opts := ldaputils.QueryOptions{
+		Attributes:  append([]string{"*"}, searchOperationalAttrs...),
+		ShowDeleted: Deleted,
+	}
+
+	entries, err := lc.QueryWithOptions(baseDN, "(objectClass=*)", ldap.ScopeBaseObject, opts)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) != 1 {
+		return fmt.Errorf("Entry not found")
+	}
+
+	searchCache.Add(baseDN, entries[0])
+	delete(searchPartialDNs, baseDN)
+
+	return nil
 }
 
 func reloadSearchNode(currentNode *tview.TreeNode) {
@@ -69,7 +175,7 @@ func reloadSearchNode(currentNode *tview.TreeNode) {
 	selectAnchoredAttribute(searchAttrsPanel)
 
 	updatedEntry := searchCache.entries[baseDN]
-	entryName := getNodeName(updatedEntry)
+	entryName := markedNodeName(updatedEntry, &searchMarks)
 
 	if Colors {
 		color, _ := GetEntryColor(updatedEntry)
@@ -84,39 +190,74 @@ func reloadSearchNode(currentNode *tview.TreeNode) {
 	updateLog("Node "+baseDN+" reloaded", "green")
 }
 
-func updateSearchHistoryPanel() {
-	searchHistoryPanel.Clear()
-
-	searchHistoryPanel.SetCell(0, 0, tview.NewTableCell("StartTime").SetSelectable(false))
-	searchHistoryPanel.SetCell(0, 1, tview.NewTableCell("Duration").SetSelectable(false))
-	searchHistoryPanel.SetCell(0, 2, tview.NewTableCell("Results").SetSelectable(false))
-	searchHistoryPanel.SetCell(0, 3, tview.NewTableCell("Query").SetSelectable(false))
-
-	for i, entry := range searchHistoryEntries {
-		row := i + 1
-
-		timestamp := entry.Timestamp.Format(TimeFormat)
-		duration := fmt.Sprintf("%.4fs", entry.Duration.Seconds())
-		results := strconv.Itoa(entry.Results)
-
-		searchHistoryPanel.SetCell(row, 0, tview.NewTableCell(timestamp))
-		searchHistoryPanel.SetCell(row, 1, tview.NewTableCell(duration))
-		searchHistoryPanel.SetCell(row, 2, tview.NewTableCell(results))
-		searchHistoryPanel.SetCell(row, 3, tview.NewTableCell(entry.Query))
-	}
-}
-
 func initSearchPage() {
 	searchCache = EntryCache{
 		entries: make(map[string]*ldap.Entry),
 	}
 
-	searchQueryPanel = tview.NewInputField()
+	searchQueryPanel = newFilterInputField()
 	searchQueryPanel.
 		SetPlaceholder("Type an LDAP search filter or the name of an object").
-		SetTitle("Search Filter (Recursive)").
+		SetTitle("Search Filter").
+		SetBorder(true)
+	assignInputFieldTheme(searchQueryPanel.InputField)
+
+	searchAttrsInput = tview.NewInputField()
+	searchAttrsInput.
+		SetText(strings.Join(SearchAttributes, ",")).
+		SetPlaceholder("All user attributes").
+		SetTitle("Attributes (Ctrl+O)").
+		SetBorder(true)
+	assignInputFieldTheme(searchAttrsInput)
+
+	searchAttrsInput.SetChangedFunc(func(text string) {
+		SearchAttributes = ldaputils.ParseAttributeList(text)
+	})
+
+	searchAttrsInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			searchQueryDoneHandler(key)
+		}
+	})
+
+	searchBaseInput = tview.NewInputField()
+	searchBaseInput.
+		SetPlaceholder("Default naming context").
+		SetTitle("Base DN").
+		SetBorder(true)
+	assignInputFieldTheme(searchBaseInput)
+	searchBaseInput.SetAutocompleteFunc(searchBaseCompletions)
+
+	searchScopeInput = tview.NewDropDown().
+		SetOptions(searchScopeNames, nil).
+		SetCurrentOption(0)
+	searchScopeInput.
+		SetTitle("Scope").
+		SetBorder(true)
+
+	searchSizeLimitInput = tview.NewInputField()
+	searchSizeLimitInput.
+		SetAcceptanceFunc(tview.InputFieldInteger).
+		SetPlaceholder("No limit").
+		SetTitle("Size Limit").
+		SetBorder(true)
+	assignInputFieldTheme(searchSizeLimitInput)
+
+	searchTimeLimitInput = tview.NewInputField()
+	searchTimeLimitInput.
+		SetAcceptanceFunc(tview.InputFieldInteger).
+		SetPlaceholder("No limit").
+		SetTitle("Time Limit (s)").
 		SetBorder(true)
-	assignInputFieldTheme(searchQueryPanel)
+	assignInputFieldTheme(searchTimeLimitInput)
+
+	for _, input := range []*tview.InputField{searchBaseInput, searchSizeLimitInput, searchTimeLimitInput} {
+		input.SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEnter {
+				searchQueryDoneHandler(key)
+			}
+		})
+	}
 
 	tabs := tview.NewTextView().
 		SetTextAlign(tview.AlignCenter).
@@ -155,105 +296,96 @@ func initSearchPage() {
 	searchLibraryRoot := tview.NewTreeNode("Queries").SetSelectable(false)
 	searchLibraryPanel.SetRoot(searchLibraryRoot)
 
-	searchHistoryPanel = tview.NewTable().
-		SetSelectable(true, false).
-		SetBorders(false).
-		SetFixed(1, 0)
-
-	searchHistoryPanel.
-		SetTitle("Search History")
-
-	searchHistoryPanel.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		switch event.Key() {
-		case tcell.KeyEnter:
-			row, _ := searchHistoryPanel.GetSelection()
-
-			if row > 0 && row <= len(searchHistoryEntries) {
-				entry := searchHistoryEntries[row-1]
-				searchQueryPanel.SetText(entry.Query)
-				app.SetFocus(searchQueryPanel)
-
-				return nil
-			}
-		}
-
-		return event
-	})
+	initSearchHistory()
 
 	sidePanel = tview.NewPages().
 		AddPage("page-0", searchLibraryPanel, true, true).
 		AddPage("page-1", searchAttrsPanel, true, false).
-		AddPage("page-2", searchHistoryPanel, true, false)
+		AddPage("page-2", searchHistoryPage, true, false)
 
 	sidePanel.SetBorder(true)
 
-	var predefinedLdapQueriesKeys []string
-
-	var chosenLibrary map[string][]ldaputils.LibQuery
-
-	if lc.Flavor == ldaputils.MicrosoftADFlavor {
-		predefinedLdapQueriesKeys = []string{"Security", "Group Members", "Users", "Computers", "Enum"}
-		chosenLibrary = ldaputils.PredefinedLdapQueriesAD
-	} else {
-		predefinedLdapQueriesKeys = []string{"Users", "Groups", "Enum"}
-		chosenLibrary = ldaputils.PredefinedLdapQueriesBasic
-	}
-
-	for _, key := range predefinedLdapQueriesKeys {
-		children := chosenLibrary[key]
-
-		childNode := tview.NewTreeNode(key).
-			SetSelectable(false).
-			SetExpanded(true)
+	for _, query := range loadSearchLibrary() {
+		var categoryNode *tview.TreeNode
+		for _, node := range searchLibraryRoot.GetChildren() {
+			if node.GetText() == query.Category {
+				categoryNode = node
+			}
+		}
 
-		for _, val := range children {
-			childNode.AddChild(
-				tview.NewTreeNode(val.Title).
-					SetReference(val.Filter).
-					SetSelectable(true))
+		if categoryNode == nil {
+			categoryNode = tview.NewTreeNode(query.Category).
+				SetSelectable(false).
+				SetExpanded(true)
+			searchLibraryRoot.AddChild(categoryNode)
 		}
 
-		searchLibraryRoot.AddChild(childNode)
+		categoryNode.AddChild(
+			tview.NewTreeNode(query.Title).
+				SetReference(query).
+				SetSelectable(true))
 	}
 
+	loadSearchHistory()
+	updateSearchHistoryPanel()
+	updateSavedSearches()
+
 	searchLibraryPanel.SetSelectedFunc(
 		func(node *tview.TreeNode) {
-			runControl.Lock()
-			if running {
-				runControl.Unlock()
+			if isJobRunning() {
 				updateLog("Another query is still running...", "yellow")
 				return
 			}
-			runControl.Unlock()
 
-			searchQueryDoneHandler(tcell.KeyEnter)
+			if entry, ok := node.GetReference().(SearchHistoryEntry); ok {
+				runHistoryEntry(entry)
+				return
+			}
+
+			query, ok := node.GetReference().(ldaputils.LibraryQuery)
+			if !ok {
+				return
+			}
+
+			if len(query.Params) > 0 {
+				openLibraryParamsForm(query)
+				return
+			}
+
+			runSearchQuery(query.Attributes)
 		},
 	)
 
 	searchLibraryPanel.SetChangedFunc(
 		func(node *tview.TreeNode) {
-			ref := node.GetReference()
-			if ref == nil {
-				searchQueryPanel.SetText("")
+			if entry, ok := node.GetReference().(SearchHistoryEntry); ok {
+				searchQueryPanel.SetText(entry.Query)
+				setSearchParams(entry.Params)
 				return
 			}
 
-			nowTimestamp := time.Now().UnixNano()
+			query, ok := node.GetReference().(ldaputils.LibraryQuery)
+			if !ok {
+				searchQueryPanel.SetText("")
+				return
+			}
 
-			nowTimestampStr := strconv.FormatInt(nowTimestamp, 10)
-			lastDayTimestampStr := strconv.FormatInt(nowTimestamp-86400, 10)
-			lastMonthTimestampStr := strconv.FormatInt(nowTimestamp-2592000, 10)
+			// Parameters are shown with their default values
+			filter, err := query.Expand(lc.DefaultRootDN, nil)
+			if err != nil {
+				filter = query.Filter
+			}
 
-			editedQuery := strings.Replace(ref.(string), "DC=domain,DC=com", lc.DefaultRootDN, -1)
-			editedQuery = strings.Replace(editedQuery, "<timestamp>", nowTimestampStr, -1)
-			editedQuery = strings.Replace(editedQuery, "<timestamp1d>", lastDayTimestampStr, -1)
-			editedQuery = strings.Replace(editedQuery, "<timestamp30d>", lastMonthTimestampStr, -1)
+			searchQueryPanel.SetText(filter)
 
-			searchQueryPanel.SetText(editedQuery)
+			if query.Description != "" {
+				updateLog(query.Description, "white")
+			}
 		},
 	)
 
 	searchQueryPanel.SetDoneFunc(searchQueryDoneHandler)
+	searchQueryPanel.SetChangedFunc(validateSearchQuery)
 
 	searchTreePanel.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		currentNode := searchTreePanel.GetCurrentNode()
@@ -284,6 +416,10 @@ func initSearchPage() {
 			if currentNode.GetReference() != nil {
 				openDeleteObjectForm(currentNode, nil)
 			}
+		case tcell.KeyCtrlV:
+			if currentNode.GetReference() != nil {
+				openPasteObjectForm(currentNode, nil)
+			}
 		case tcell.KeyCtrlS:
 			exportCacheToFile(currentNode, &searchCache, "results")
 		case tcell.KeyCtrlP:
@@ -323,6 +459,11 @@ func initSearchPage() {
 			}
 		}
 
+		selectedDN, _ := currentNode.GetReference().(string)
+		if handleMarksKey(event, searchBulkTarget(), selectedDN, func() []string { return resultDNs(currentNode) }) {
+			return nil
+		}
+
 		switch event.Rune() {
 		case 'r', 'R':
 			if currentNode.GetReference() != nil {
@@ -330,6 +471,19 @@ func initSearchPage() {
 					reloadSearchNode(currentNode)
 				})
 			}
+		case 'y', 'Y':
+			if currentNode.GetReference() != nil {
+				copyObjectToClipboard(currentNode.GetReference().(string))
+				return nil
+			}
+		case 'w', 'W':
+			if currentNode.GetReference() != nil {
+				openWhereUsed(currentNode.GetReference().(string))
+				return nil
+			}
+		case 'v', 'V':
+			toggleSearchResultsView()
+			return nil
 		}
 
 		return event
@@ -349,16 +503,27 @@ func initSearchPage() {
 
 	tabs.Highlight("0")
 
+	initSearchTable()
+
 	searchPage = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(
 			tview.NewFlex().
-				AddItem(searchQueryPanel, 0, 1, false).
+				AddItem(searchQueryPanel, 0, 2, false).
+				AddItem(searchAttrsInput, 0, 1, false).
 				AddItem(tabs, 23, 0, false),
 			3, 0, false,
 		).
 		AddItem(
 			tview.NewFlex().
-				AddItem(searchTreePanel, 0, 1, false).
+				AddItem(searchBaseInput, 0, 1, false).
+				AddItem(searchScopeInput, 15, 0, false).
+				AddItem(searchSizeLimitInput, 14, 0, false).
+				AddItem(searchTimeLimitInput, 18, 0, false),
+			3, 0, false,
+		).
+		AddItem(
+			tview.NewFlex().
+				AddItem(searchResultsPages, 0, 1, false).
 				AddItem(sidePanel, 0, 1, false),
 			0, 8, false,
 		)
@@ -366,114 +531,376 @@ func initSearchPage() {
 	searchPage.SetInputCapture(searchPageKeyHandler)
 }
 
+// Returns the predefined queries for the current flavor
+// followed by the queries of the user's library files
+func loadSearchLibrary() []ldaputils.LibraryQuery {
+	var categories []string
+	var predefinedQueries map[string][]ldaputils.LibQuery
+
+	if lc.Flavor == ldaputils.MicrosoftADFlavor {
+		categories = []string{"Security", "Group Members", "Users", "Computers", "Enum"}
+		predefinedQueries = ldaputils.PredefinedLdapQueriesAD
+	} else {
+		categories = []string{"Users", "Groups", "Enum"}
+		predefinedQueries = ldaputils.PredefinedLdapQueriesBasic
+	}
+
+	var library []ldaputils.LibraryQuery
+	for _, category := range categories {
+		for _, query := range predefinedQueries[category] {
+			library = append(library, ldaputils.LibraryQuery{LibQuery: query, Category: category})
+		}
+	}
+
+	libraryPaths := slices.Clone(LibraryPaths)
+	if configDir, err := os.UserConfigDir(); err == nil {
+		defaultDir := filepath.Join(configDir, "godap", "queries")
+		if _, err := os.Stat(defaultDir); err == nil {
+			libraryPaths = append([]string{defaultDir}, libraryPaths...)
+		}
+	}
+
+	// Libraries with errors are skipped without affecting the others
+	userQueries, err := ldaputils.LoadQueryLibraries(libraryPaths)
+	if err != nil {
+		updateLog(fmt.Sprintf("Skipped query libraries with errors: %s", strings.ReplaceAll(err.Error(), "\n", "; ")), "red")
+	}
+
+	for _, query := range userQueries {
+		if query.MatchesFlavor(lc.Flavor) {
+			library = append(library, query)
+		}
+	}
+
+	return library
+}
+
+// Form asking the values of the parameters of a
+// library query, which is run once they're filled
+func openLibraryParamsForm(query ldaputils.LibraryQuery) {
+	currentFocus := app.GetFocus()
+
+	paramsForm := NewXForm()
+	paramsForm.SetInputCapture(handleEscape(currentFocus))
+	paramsForm.SetItemPadding(0)
+
+	for _, param := range query.Params {
+		label := param.Prompt
+		if label == "" {
+			label = param.Name
+		}
+
+		paramsForm.AddInputField(label, param.Default, 0, nil, nil)
+	}
+
+	paramsForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Run", func() {
+			values := make(map[string]string)
+			for idx, param := range query.Params {
+				values[param.Name] = paramsForm.GetFormItem(idx).(*tview.InputField).GetText()
+			}
+
+			filter, err := query.Expand(lc.DefaultRootDN, values)
+			if err != nil {
+				updateLog(fmt.Sprint(err), "red")
+				return
+			}
+
+			searchQueryPanel.SetText(filter)
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+
+			runSearchQuery(query.Attributes)
+		})
+
+	paramsForm.SetTitle(query.Title).SetBorder(true)
+	app.SetRoot(paramsForm, true).SetFocus(paramsForm)
+}
+
+// Number of results added to the tree per UI update, so that the
+// interface stays responsive while large pages are being rendered
+const searchRenderBatch = 200
+
 func searchQueryDoneHandler(key tcell.Key) {
-	updateLog("Performing recursive query...", "yellow")
+	runSearchQuery(nil)
+}
+
+// Runs the filter of the search box, requesting the given attributes
+// (such as the ones of a library query) instead of the selected ones
+func runSearchQuery(attributes []string) {
+	// Malformed filters are reported before reaching the server
+	if filter, isFilter := ldaputils.SearchInputFilter(searchQueryPanel.GetText()); isFilter {
+		if _, err := ldaputils.ParseFilter(filter); err != nil {
+			updateLog(fmt.Sprint(err), "red")
+			return
+		}
+	}
+
+	job := startJob("search")
+	if job == nil {
+		return
+	}
+
+	updateLog("Performing query...", "yellow")
 
-	rootNode := tview.NewTreeNode(lc.DefaultRootDN).SetSelectable(true)
+	params := getSearchParams()
+	if len(attributes) > 0 {
+		params.Attributes = attributes
+	}
+
+	searchBase := params.BaseDN
+	if searchBase == "" {
+		searchBase = lc.SearchBase()
+	}
+
+	rootNodeName := searchBase
+	if searchBase == "" && lc.GlobalCatalog {
+		rootNodeName = "Global Catalog"
+	}
+
+	resetSearchResults(rootNodeName)
+
+	go runSearch(job, searchBase, params, searchQueryPanel.GetText())
+}
+
+// Clears the search results before a new search
+func resetSearchResults(rootNodeName string) {
+	rootNode := tview.NewTreeNode(rootNodeName).SetSelectable(true)
 	searchTreePanel.
 		SetRoot(rootNode).
-		SetCurrentNode(rootNode)
+		SetCurrentNode(rootNode).
+		SetTitle("Search Results")
 
 	searchCache.Clear()
 	clear(searchLoadedDNs)
+	clear(searchPartialDNs)
+	searchMarks.Clear()
+}
+
+// Reads the search parameters from the controls of the search page
+func getSearchParams() SearchParams {
+	_, scopeName := searchScopeInput.GetCurrentOption()
+	sizeLimit, _ := strconv.Atoi(searchSizeLimitInput.GetText())
+	timeLimit, _ := strconv.Atoi(searchTimeLimitInput.GetText())
+
+	return SearchParams{
+		BaseDN:     strings.TrimSpace(searchBaseInput.GetText()),
+		Scope:      searchScopes[slices.Index(searchScopeNames, scopeName)],
+		SizeLimit:  sizeLimit,
+		TimeLimit:  timeLimit,
+		Attributes: SearchAttributes,
+	}
+}
 
-	searchQuery := searchQueryPanel.GetText()
+func setSearchParams(params SearchParams) {
+	searchBaseInput.SetText(params.BaseDN)
+	searchScopeInput.SetCurrentOption(slices.Index(searchScopes, params.Scope))
 
-	go func() {
-		runControl.Lock()
-		if running {
-			runControl.Unlock()
-			return
-		}
-		running = true
-		runControl.Unlock()
-
-		if searchQuery != "" && !strings.Contains(searchQuery, "(") {
-			searchQuery = fmt.Sprintf(
-				"(|(samAccountName=%s)(cn=%s)(ou=%s)(name=%s))",
-				searchQuery, searchQuery, searchQuery, searchQuery,
-			)
+	limitText := func(limit int) string {
+		if limit == 0 {
+			return ""
 		}
+		return strconv.Itoa(limit)
+	}
 
-		startTime := time.Now()
+	searchSizeLimitInput.SetText(limitText(params.SizeLimit))
+	searchTimeLimitInput.SetText(limitText(params.TimeLimit))
 
-		entries, _ := lc.Query(lc.DefaultRootDN, searchQuery, ldap.ScopeWholeSubtree, Deleted)
+	SearchAttributes = params.Attributes
+	searchAttrsInput.SetText(strings.Join(params.Attributes, ","))
+}
 
-		duration := time.Since(startTime)
+var (
+	namingContexts     []string
+	namingContextsConn *ldaputils.LDAPConn
+)
 
-		firstLeaf := true
+// Suggests naming contexts (Configuration, Schema, DNS partitions...)
+// and DNs loaded in the explorer that contain the text typed so far
+func searchBaseCompletions(currentText string) []string {
+	if currentText == "" {
+		return nil
+	}
 
-		for _, entry := range entries {
-			if entry.DN == lc.DefaultRootDN {
-				continue
-			}
+	if namingContextsConn != lc {
+		namingContexts, _ = lc.FindNamingContexts()
+		namingContextsConn = lc
+	}
 
-			var nodeName string
-			entryName := getNodeName(entry)
-			dnPath := strings.TrimSuffix(entry.DN, ","+lc.DefaultRootDN)
-
-			components := strings.Split(dnPath, ",")
-			currentNode := searchTreePanel.GetRoot()
-
-			for i := len(components) - 1; i >= 0; i-- {
-				partialDN := strings.Join(components[i:], ",")
-
-				childNode, ok := searchLoadedDNs[partialDN]
-				if !ok {
-					app.QueueUpdateDraw(func() {
-						if i == 0 {
-							// Leaf node
-							nodeName = entryName
-							childNode = tview.NewTreeNode(nodeName).
-								SetReference(entry.DN).
-								SetExpanded(false).
-								SetSelectable(true)
-
-							if Colors {
-								color, changed := GetEntryColor(entry)
-								if changed {
-									childNode.SetColor(color)
-								}
-							}
-							currentNode.AddChild(childNode)
-
-							if firstLeaf {
-								searchTreePanel.SetCurrentNode(childNode)
-								firstLeaf = false
-							}
-
-							searchCache.Add(entry.DN, entry)
-						} else {
-							// Non-leaf node
-							nodeName = components[i]
-							childNode = tview.NewTreeNode(nodeName).
-								SetExpanded(true).
-								SetSelectable(true)
-							currentNode.AddChild(childNode)
-						}
-					})
+	candidates := append(slices.Clone(namingContexts), explorerCache.Keys()...)
+	slices.Sort(candidates)
+
+	lowerText := strings.ToLower(currentText)
+
+	var matches []string
+	for _, candidate := range slices.Compact(candidates) {
+		if strings.Contains(strings.ToLower(candidate), lowerText) {
+			matches = append(matches, candidate)
+		}
+
+		if len(matches) == 20 {
+			break
+		}
+	}
+
+	return matches
+}
 
-					searchLoadedDNs[partialDN] = childNode
+// Adds a result to the search tree along with the nodes of
+// its DN path that are still missing, returning the leaf node
+func addSearchResult(searchBase string, entry *ldap.Entry, partial bool) *tview.TreeNode {
+	// The base object itself is returned by base & subtree searches
+	if searchBase != "" && strings.EqualFold(entry.DN, searchBase) {
+		root := searchTreePanel.GetRoot().SetReference(entry.DN)
+		searchCache.Add(entry.DN, entry)
+		if partial {
+			searchPartialDNs[entry.DN] = true
+		}
+
+		return root
+	}
+
+	dnPath := entry.DN
+	baseSuffix := "," + searchBase
+	if searchBase != "" && len(dnPath) > len(baseSuffix) &&
+		strings.EqualFold(dnPath[len(dnPath)-len(baseSuffix):], baseSuffix) {
+		dnPath = dnPath[:len(dnPath)-len(baseSuffix)]
+	}
+
+	components := strings.Split(dnPath, ",")
+	currentNode := searchTreePanel.GetRoot()
+
+	for i := len(components) - 1; i >= 0; i-- {
+		partialDN := strings.Join(components[i:], ",")
+
+		childNode, ok := searchLoadedDNs[partialDN]
+		if !ok {
+			if i == 0 {
+				// Leaf node
+				childNode = tview.NewTreeNode(markedNodeName(entry, &searchMarks)).
+					SetReference(entry.DN).
+					SetExpanded(false).
+					SetSelectable(true)
+
+				if Colors {
+					color, changed := GetEntryColor(entry)
+					if changed {
+						childNode.SetColor(color)
+					}
 				}
 
-				currentNode = childNode
+				searchCache.Add(entry.DN, entry)
+				if partial {
+					searchPartialDNs[entry.DN] = true
+				}
+			} else {
+				// Non-leaf node
+				childNode = tview.NewTreeNode(components[i]).
+					SetExpanded(true).
+					SetSelectable(true)
 			}
+
+			currentNode.AddChild(childNode)
+			searchLoadedDNs[partialDN] = childNode
 		}
 
-		app.QueueUpdateDraw(func() {
-			updateLog(
-				fmt.Sprintf("Query completed (%d objects found in %.4fs)", len(entries), duration.Seconds()), "green")
-		})
+		currentNode = childNode
+	}
+
+	return currentNode
+}
+
+// Filter used to search for objects by name when
+// the text of the search box isn't a filter
+func nameSearchFilter(name string) string {
+	return fmt.Sprintf(
+		"(|(samAccountName=%s)(cn=%s)(ou=%s)(name=%s))",
+		name, name, name, name,
+	)
+}
+
+// Runs a search in the background, rendering
+// each page of results as soon as it arrives
+func runSearch(job *Job, searchBase string, params SearchParams, searchQuery string) {
+	defer job.Finish()
+
+	if filter, isFilter := ldaputils.SearchInputFilter(searchQuery); isFilter {
+		searchQuery = filter
+	} else if searchQuery != "" {
+		searchQuery = nameSearchFilter(searchQuery)
+	}
+
+	opts, partial := searchQueryOptions(params.Scope, params.Attributes)
+	opts.SizeLimit = params.SizeLimit
+	opts.TimeLimit = params.TimeLimit
+
+	progress, duration := streamSearchResults(job, searchBase, partial, func(onPage ldaputils.PageHandler) (ldaputils.QueryProgress, error) {
+		return lc.QueryPages(
+			job.Context(), searchBase, searchQuery,
+			params.Scope, opts, onPage,
+		)
+	})
+
+	app.QueueUpdateDraw(func() {
+		addToSearchHistory(searchQuery, params, duration, progress.Entries)
+		updateSearchHistoryPanel()
+		refreshSearchTable()
+	})
+}
+
+// Runs query, rendering each page of results in the search
+// tree as soon as it arrives, and logs how it finished
+func streamSearchResults(job *Job, searchBase string, partial bool, query func(onPage ldaputils.PageHandler) (ldaputils.QueryProgress, error)) (ldaputils.QueryProgress, time.Duration) {
+	startTime := time.Now()
+
+	firstLeaf := true
+
+	renderPage := func(entries []*ldap.Entry, progress ldaputils.QueryProgress) {
+		for start := 0; start < len(entries); start += searchRenderBatch {
+			batch := entries[start:min(start+searchRenderBatch, len(entries))]
+
+			app.QueueUpdateDraw(func() {
+				for _, entry := range batch {
+					leaf := addSearchResult(searchBase, entry, partial)
+					if leaf != nil && firstLeaf {
+						searchTreePanel.SetCurrentNode(leaf)
+						firstLeaf = false
+					}
+				}
+			})
+		}
 
-		addToSearchHistory(searchQuery, duration, len(entries))
 		app.QueueUpdateDraw(func() {
-			updateSearchHistoryPanel()
+			searchTreePanel.SetTitle(fmt.Sprintf("Search Results (%d)", progress.Entries))
+			updateLog(fmt.Sprintf(
+				"Performing query... (%d objects received in %.1fs - Esc to cancel)",
+				progress.Entries, time.Since(startTime).Seconds(),
+			), "yellow")
 		})
+	}
+
+	progress, err := query(renderPage)
+
+	duration := time.Since(startTime)
+
+	app.QueueUpdateDraw(func() {
+		if job.Cancelled() {
+			updateLog(
+				fmt.Sprintf("Query cancelled (%d objects received in %.4fs)", progress.Entries, duration.Seconds()), "yellow")
+		} else if ldaputils.IsLimitExceeded(err) {
+			updateLog(
+				fmt.Sprintf("Query stopped at its limit (%d objects received in %.4fs)", progress.Entries, duration.Seconds()), "yellow")
+		} else if err != nil {
+			updateLog(fmt.Sprint(err), "red")
+		} else {
+			updateLog(
+				fmt.Sprintf("Query completed (%d objects found in %.4fs)", progress.Entries, duration.Seconds()), "green")
+		}
+	})
 
-		runControl.Lock()
-		running = false
-		runControl.Unlock()
-	}()
+	return progress, duration
 }
 
 func searchPageKeyHandler(event *tcell.EventKey) *tcell.EventKey {
@@ -485,6 +912,12 @@ func searchPageKeyHandler(event *tcell.EventKey) *tcell.EventKey {
 	switch event.Key() {
 	case tcell.KeyCtrlF:
 		openFinder(&searchCache, "Object Search")
+	case tcell.KeyCtrlB:
+		openFilterBuilder()
+		return nil
+	case tcell.KeyCtrlO:
+		openSearchAttrsForm()
+		return nil
 	}
 
 	return event
@@ -494,11 +927,71 @@ func searchRotateFocus() {
 	currentFocus := app.GetFocus()
 
 	switch currentFocus {
-	case searchTreePanel:
+	case searchTreePanel, searchTablePanel:
 		app.SetFocus(searchQueryPanel)
 	case searchQueryPanel:
+		app.SetFocus(searchAttrsInput)
+	case searchAttrsInput:
+		app.SetFocus(searchBaseInput)
+	case searchBaseInput:
+		app.SetFocus(searchScopeInput)
+	case searchScopeInput:
+		app.SetFocus(searchSizeLimitInput)
+	case searchSizeLimitInput:
+		app.SetFocus(searchTimeLimitInput)
+	case searchTimeLimitInput:
 		app.SetFocus(sidePanel)
-	case searchLibraryPanel, searchAttrsPanel, searchHistoryPanel:
-		app.SetFocus(searchTreePanel)
+	case searchLibraryPanel, searchAttrsPanel, searchHistoryPanel, searchHistoryFilter:
+		app.SetFocus(searchResultsPanel())
+	}
+}
+
+// Form to pick the attributes requested by the search
+// page, including operational and constructed ones
+func openSearchAttrsForm() {
+	currentFocus := app.GetFocus()
+
+	operationalAttrs := ldaputils.OperationalAttributesAD
+	if lc.Flavor == ldaputils.BasicLDAPFlavor {
+		operationalAttrs = ldaputils.OperationalAttributesBasic
+	}
+
+	selected := slices.Clone(searchOperationalAttrs)
+
+	attrsForm := NewXForm()
+	attrsForm.SetInputCapture(handleEscape(currentFocus))
+	attrsForm.SetItemPadding(0)
+
+	attrsForm.AddInputField("Attributes", strings.Join(SearchAttributes, ","), 0, nil, nil)
+	if lc.Flavor == ldaputils.MicrosoftADFlavor {
+		attrsForm.AddTextView("", "tokenGroups is only loaded when reloading a result (r)", 0, 1, false, false)
+	}
+
+	for _, attr := range operationalAttrs {
+		attrName := attr
+		attrsForm.AddCheckbox(attrName, slices.Contains(selected, attrName), func(checked bool) {
+			selected = slices.DeleteFunc(selected, func(s string) bool { return s == attrName })
+			if checked {
+				selected = append(selected, attrName)
+			}
+		})
 	}
+
+	attrsForm.
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Update", func() {
+			attrsText := attrsForm.GetFormItemByLabel("Attributes").(*tview.InputField).GetText()
+			SearchAttributes = ldaputils.ParseAttributeList(attrsText)
+			searchOperationalAttrs = selected
+
+			searchAttrsInput.SetText(strings.Join(SearchAttributes, ","))
+			updateLog("Search attributes updated", "green")
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	attrsForm.SetTitle("Search Attributes").SetBorder(true)
+	app.SetRoot(attrsForm, true).SetFocus(attrsForm)
 }
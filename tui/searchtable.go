@@ -0,0 +1,387 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/gdamore/tcell/v2"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+var (
+	searchTablePanel   *tview.Table
+	searchResultsPages *tview.Pages
+
+	// Attribute columns of the results table
+	SearchTableColumns []string
+
+	// Column the table is sorted by (-1 for the tree
+	// order) and whether it's sorted in descending order
+	searchTableSortColumn = -1
+	searchTableSortDesc   bool
+)
+
+var defaultTableColumnsAD = []string{"sAMAccountName", "pwdLastSet", "lastLogonTimestamp", "userAccountControl"}
+var defaultTableColumnsBasic = []string{"cn", "mail", "objectClass", "modifyTimestamp"}
+
+func initSearchTable() {
+	if SearchTableColumns == nil {
+		if lc.Flavor == ldaputils.MicrosoftADFlavor {
+			SearchTableColumns = slices.Clone(defaultTableColumnsAD)
+		} else {
+			SearchTableColumns = slices.Clone(defaultTableColumnsBasic)
+		}
+	}
+
+	searchTablePanel = tview.NewTable().
+		SetSelectable(true, true).
+		SetFixed(1, 1).
+		SetEvaluateAllRows(true)
+	searchTablePanel.
+		SetTitle("Search Results").
+		SetBorder(true)
+
+	// Follows the selected row in the tree, so that
+	// its attributes are shown in the side panel
+	searchTablePanel.SetSelectionChangedFunc(func(row, column int) {
+		if node, ok := searchTablePanel.GetCell(row, 0).GetReference().(*tview.TreeNode); ok {
+			searchTreePanel.SetCurrentNode(node)
+			searchAttrsPanel.Clear()
+			reloadSearchAttrsPanel(node, true)
+		}
+	})
+
+	searchTablePanel.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		row, _ := searchTablePanel.GetSelection()
+		selectedDN := ""
+		if node, ok := searchTablePanel.GetCell(row, 0).GetReference().(*tview.TreeNode); ok {
+			selectedDN, _ = node.GetReference().(string)
+		}
+
+		allResults := func() []string {
+			return resultDNs(searchTreePanel.GetRoot())
+		}
+
+		if handleMarksKey(event, searchBulkTarget(), selectedDN, allResults) {
+			return nil
+		}
+
+		switch event.Key() {
+		case tcell.KeyCtrlE:
+			openSearchTableColumnsForm()
+			return nil
+		case tcell.KeyCtrlS:
+			openSearchTableExportForm()
+			return nil
+		}
+
+		switch event.Rune() {
+		case 'o', 'O':
+			_, column := searchTablePanel.GetSelection()
+			if column == searchTableSortColumn {
+				searchTableSortDesc = !searchTableSortDesc
+			} else {
+				searchTableSortColumn = column
+				searchTableSortDesc = false
+			}
+
+			refreshSearchTable()
+			return nil
+		case 'v', 'V':
+			toggleSearchResultsView()
+			return nil
+		}
+
+		return event
+	})
+
+	searchResultsPages = tview.NewPages().
+		AddPage("tree", searchTreePanel, true, true).
+		AddPage("table", searchTablePanel, true, false)
+}
+
+func isSearchTableVisible() bool {
+	name, _ := searchResultsPages.GetFrontPage()
+	return name == "table"
+}
+
+// The panel currently showing the search results
+func searchResultsPanel() tview.Primitive {
+	if isSearchTableVisible() {
+		return searchTablePanel
+	}
+
+	return searchTreePanel
+}
+
+// Switches the search results between the tree and the table view
+func toggleSearchResultsView() {
+	if isSearchTableVisible() {
+		searchResultsPages.SwitchToPage("tree")
+		app.SetFocus(searchTreePanel)
+		return
+	}
+
+	refreshSearchTable()
+	searchResultsPages.SwitchToPage("table")
+	app.SetFocus(searchTablePanel)
+}
+
+// A search result as listed in the table
+type searchTableRow struct {
+	node  *tview.TreeNode
+	entry *ldap.Entry
+}
+
+// Lists the results in the order they're shown in the tree
+func searchTableRows() []searchTableRow {
+	var rows []searchTableRow
+
+	root := searchTreePanel.GetRoot()
+	if root == nil {
+		return rows
+	}
+
+	root.Walk(func(node, parent *tview.TreeNode) bool {
+		if dn, ok := node.GetReference().(string); ok {
+			if entry, ok := searchCache.Get(dn); ok {
+				rows = append(rows, searchTableRow{node, entry})
+			}
+		}
+		return true
+	})
+
+	return rows
+}
+
+// The attribute of a column, whose name may be typed in any case
+func searchTableAttribute(entry *ldap.Entry, column string) *ldap.EntryAttribute {
+	idx := slices.IndexFunc(entry.Attributes, func(attr *ldap.EntryAttribute) bool {
+		return strings.EqualFold(attr.Name, column)
+	})
+	if idx < 0 || len(entry.Attributes[idx].Values) == 0 {
+		return nil
+	}
+
+	return entry.Attributes[idx]
+}
+
+// The formatted value of a column of the table
+func searchTableValue(entry *ldap.Entry, column string) string {
+	attr := searchTableAttribute(entry, column)
+	if attr == nil {
+		return ""
+	}
+
+	if FormatAttrs {
+		return strings.Join(lc.FormatLDAPAttribute(attr, TimeFormat, TimeOffset), "; ")
+	}
+
+	return strings.Join(attr.Values, "; ")
+}
+
+// Compares the values of two rows in a column, numerically
+// when both are integers (timestamps, flags, counters...)
+func compareSearchTableValues(a *ldap.Entry, b *ldap.Entry, column string) int {
+	if column == "DN" {
+		return strings.Compare(strings.ToLower(a.DN), strings.ToLower(b.DN))
+	}
+
+	rawA := a.GetEqualFoldAttributeValue(column)
+	rawB := b.GetEqualFoldAttributeValue(column)
+
+	numA, errA := strconv.ParseInt(rawA, 10, 64)
+	numB, errB := strconv.ParseInt(rawB, 10, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case numA < numB:
+			return -1
+		case numA > numB:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(strings.ToLower(rawA), strings.ToLower(rawB))
+}
+
+// Returns the columns and rows of the table, sorted as selected
+func searchTableData() ([]string, []searchTableRow) {
+	columns := append([]string{"DN"}, SearchTableColumns...)
+	rows := searchTableRows()
+
+	if searchTableSortColumn >= 0 && searchTableSortColumn < len(columns) {
+		column := columns[searchTableSortColumn]
+		slices.SortStableFunc(rows, func(a, b searchTableRow) int {
+			cmp := compareSearchTableValues(a.entry, b.entry, column)
+			if searchTableSortDesc {
+				return -cmp
+			}
+			return cmp
+		})
+	}
+
+	return columns, rows
+}
+
+// Rebuilds the table from the results in the search tree
+func refreshSearchTable() {
+	if searchTablePanel == nil {
+		return
+	}
+
+	columns, rows := searchTableData()
+
+	selectedRow, selectedColumn := searchTablePanel.GetSelection()
+	searchTablePanel.Clear()
+
+	for idx, column := range columns {
+		header := column
+		if idx == searchTableSortColumn {
+			if searchTableSortDesc {
+				header += " ▼"
+			} else {
+				header += " ▲"
+			}
+		}
+
+		searchTablePanel.SetCell(0, idx,
+			tview.NewTableCell(header).
+				SetTextColor(tcell.GetColor("yellow")).
+				SetSelectable(false))
+	}
+
+	for rowIdx, row := range rows {
+		dnText := row.entry.DN
+		if searchMarks.Has(row.entry.DN) {
+			dnText = markPrefix + dnText
+		}
+
+		searchTablePanel.SetCell(rowIdx+1, 0,
+			tview.NewTableCell(dnText).
+				SetReference(row.node).
+				SetMaxWidth(60))
+
+		for colIdx, column := range SearchTableColumns {
+			cell := tview.NewTableCell(searchTableValue(row.entry, column)).
+				SetMaxWidth(40)
+
+			// Colors are picked by the name the server returned
+			if attr := searchTableAttribute(row.entry, column); Colors && attr != nil {
+				if color, ok := GetAttrCellColor(attr.Name, row.entry.GetEqualFoldAttributeValue(column)); ok {
+					cell.SetTextColor(tcell.GetColor(color))
+				}
+			}
+
+			searchTablePanel.SetCell(rowIdx+1, colIdx+1, cell)
+		}
+	}
+
+	searchTablePanel.SetTitle(fmt.Sprintf("Search Results (%d)", len(rows)))
+
+	if len(rows) > 0 {
+		searchTablePanel.Select(
+			max(1, min(selectedRow, len(rows))),
+			min(selectedColumn, len(columns)-1),
+		)
+	}
+}
+
+// Form to pick the attribute columns of the table
+func openSearchTableColumnsForm() {
+	currentFocus := app.GetFocus()
+
+	columnsForm := NewXForm()
+	columnsForm.SetInputCapture(handleEscape(currentFocus))
+	columnsForm.SetItemPadding(0)
+
+	columnsForm.
+		AddInputField("Columns", strings.Join(SearchTableColumns, ","), 0, nil, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Update", func() {
+			columnsText := columnsForm.GetFormItemByLabel("Columns").(*tview.InputField).GetText()
+			SearchTableColumns = ldaputils.ParseAttributeList(columnsText)
+			searchTableSortColumn = -1
+
+			refreshSearchTable()
+			updateLog("Table columns updated", "green")
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	columnsForm.SetTitle("Table Columns").SetBorder(true)
+	app.SetRoot(columnsForm, true).SetFocus(columnsForm)
+}
+
+// Form to export the table to a CSV or JSON file
+func openSearchTableExportForm() {
+	currentFocus := app.GetFocus()
+
+	exportForm := NewXForm()
+	exportForm.SetInputCapture(handleEscape(currentFocus))
+	exportForm.SetItemPadding(0)
+
+	exportForm.
+		AddDropDown("Format", []string{"CSV", "JSON"}, 0, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Export", func() {
+			_, format := exportForm.GetFormItemByLabel("Format").(*tview.DropDown).GetCurrentOption()
+			if format == "CSV" {
+				exportSearchTableCSV()
+			} else {
+				exportSearchTableJSON()
+			}
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	exportForm.SetTitle("Export Table").SetBorder(true)
+	app.SetRoot(exportForm, true).SetFocus(exportForm)
+}
+
+func exportSearchTableCSV() {
+	columns, rows := searchTableData()
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(columns)
+
+	for _, row := range rows {
+		record := []string{row.entry.DN}
+		for _, column := range SearchTableColumns {
+			record = append(record, searchTableValue(row.entry, column))
+		}
+		writer.Write(record)
+	}
+
+	writer.Flush()
+
+	filename := fmt.Sprintf("%d_results_table.csv", time.Now().UnixMilli())
+	writeExportFile(filename, buf.Bytes())
+}
+
+func exportSearchTableJSON() {
+	_, rows := searchTableData()
+
+	exportMap := make(map[string]any)
+	for _, row := range rows {
+		values := make(map[string]string)
+		for _, column := range SearchTableColumns {
+			values[column] = searchTableValue(row.entry, column)
+		}
+		exportMap[row.entry.DN] = values
+	}
+
+	writeDataExport(exportMap, "results_table", "search_table")
+}
@@ -0,0 +1,550 @@
+package tui
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/rivo/tview"
+)
+
+// ConnConfig holds the connection & credential settings
+// used to establish the LDAP connection of a session
+type ConnConfig struct {
+	LdapServer       string
+	LdapPort         int
+	Ldaps            bool
+	Insecure         bool
+	GlobalCatalog    bool
+	SocksServer      string
+	DomainName       string
+	AuthType         int
+	LdapUsername     string
+	LdapPassword     string
+	LdapPasswordFile string
+	NtlmHash         string
+	NtlmHashFile     string
+	CCachePath       string
+	TargetSpn        string
+	KdcHost          string
+	CertFile         string
+	KeyFile          string
+	PfxFile          string
+	BackendFlavor    string
+	RootDN           string
+	SearchFilter     string
+}
+
+func currentConnConfig() ConnConfig {
+	return ConnConfig{
+		LdapServer:       LdapServer,
+		LdapPort:         LdapPort,
+		Ldaps:            Ldaps,
+		Insecure:         Insecure,
+		GlobalCatalog:    GlobalCatalog,
+		SocksServer:      SocksServer,
+		DomainName:       DomainName,
+		AuthType:         AuthType,
+		LdapUsername:     LdapUsername,
+		LdapPassword:     LdapPassword,
+		LdapPasswordFile: LdapPasswordFile,
+		NtlmHash:         NtlmHash,
+		NtlmHashFile:     NtlmHashFile,
+		CCachePath:       CCachePath,
+		TargetSpn:        TargetSpn,
+		KdcHost:          KdcHost,
+		CertFile:         CertFile,
+		KeyFile:          KeyFile,
+		PfxFile:          PfxFile,
+		BackendFlavor:    BackendFlavor,
+		RootDN:           RootDN,
+		SearchFilter:     SearchFilter,
+	}
+}
+
+func (cc ConnConfig) apply() {
+	LdapServer = cc.LdapServer
+	LdapPort = cc.LdapPort
+	Ldaps = cc.Ldaps
+	Insecure = cc.Insecure
+	GlobalCatalog = cc.GlobalCatalog
+	SocksServer = cc.SocksServer
+	DomainName = cc.DomainName
+	AuthType = cc.AuthType
+	LdapUsername = cc.LdapUsername
+	LdapPassword = cc.LdapPassword
+	LdapPasswordFile = cc.LdapPasswordFile
+	NtlmHash = cc.NtlmHash
+	NtlmHashFile = cc.NtlmHashFile
+	CCachePath = cc.CCachePath
+	TargetSpn = cc.TargetSpn
+	KdcHost = cc.KdcHost
+	CertFile = cc.CertFile
+	KeyFile = cc.KeyFile
+	PfxFile = cc.PfxFile
+	BackendFlavor = cc.BackendFlavor
+	RootDN = cc.RootDN
+	SearchFilter = cc.SearchFilter
+}
+
+// Session stores a connection along with the state of the
+// explorer and search pages that belongs to it
+type Session struct {
+	Config    ConnConfig
+	Conn      *ldaputils.LDAPConn
+	TLSConfig *tls.Config
+
+	explorerRoot    *tview.TreeNode
+	explorerCurrent *tview.TreeNode
+	explorerEntries map[string]*ldap.Entry
+
+	searchRoot      *tview.TreeNode
+	searchCurrent   *tview.TreeNode
+	searchEntries   map[string]*ldap.Entry
+	searchLoadedDNs map[string]*tview.TreeNode
+	searchPartial   map[string]bool
+	searchHistory   []SearchHistoryEntry
+	searchQuery     string
+	searchBaseDN    string
+
+	explorerMarks Marks
+	searchMarks   Marks
+}
+
+func (s *Session) Name() string {
+	name := s.Config.LdapServer
+	if s.Config.AuthType == 4 {
+		name = "krb@" + name
+	} else if s.Config.LdapUsername != "" {
+		name = s.Config.LdapUsername + "@" + name
+	}
+
+	return name
+}
+
+// Object copied with `y` that can be pasted
+// into any session with Ctrl+V
+type ClipboardObject struct {
+	Source string
+	Entry  *ldap.Entry
+}
+
+var (
+	sessions       []*Session
+	currentSession int
+	sessionsPanel  *tview.TextView
+
+	sessionClipboard *ClipboardObject
+)
+
+// Snapshots the globals of the active session into s
+func saveSession(s *Session) {
+	s.Config = currentConnConfig()
+	s.Conn = lc
+	s.TLSConfig = tlsConfig
+
+	s.explorerRoot = treePanel.GetRoot()
+	s.explorerCurrent = treePanel.GetCurrentNode()
+	s.explorerEntries = explorerCache.Replace(make(map[string]*ldap.Entry))
+
+	s.searchRoot = searchTreePanel.GetRoot()
+	s.searchCurrent = searchTreePanel.GetCurrentNode()
+	s.searchEntries = searchCache.Replace(make(map[string]*ldap.Entry))
+	s.searchLoadedDNs = searchLoadedDNs
+	s.searchPartial = searchPartialDNs
+	s.searchHistory = searchHistoryEntries
+	s.searchQuery = searchQueryPanel.GetText()
+	s.searchBaseDN = searchBaseInput.GetText()
+	s.explorerMarks = explorerMarks
+	s.searchMarks = searchMarks
+
+	searchLoadedDNs = make(map[string]*tview.TreeNode)
+	searchPartialDNs = make(map[string]bool)
+	searchHistoryEntries = nil
+	explorerMarks = Marks{}
+	searchMarks = Marks{}
+}
+
+// Restores the globals and panels of the explorer
+// and search pages from the state stored in s
+func loadSession(s *Session) {
+	s.Config.apply()
+	lc = s.Conn
+	tlsConfig = s.TLSConfig
+
+	explorerCache.Replace(s.explorerEntries)
+	rootNode = s.explorerRoot
+	treePanel.SetRoot(s.explorerRoot).SetCurrentNode(s.explorerCurrent)
+	rootDNInput.SetText(lc.RootDN)
+	searchFilterInput.SetText(SearchFilter)
+
+	searchCache.Replace(s.searchEntries)
+	searchLoadedDNs = s.searchLoadedDNs
+	searchPartialDNs = s.searchPartial
+	searchHistoryEntries = s.searchHistory
+	explorerMarks = s.explorerMarks
+	searchMarks = s.searchMarks
+	searchTreePanel.SetRoot(s.searchRoot).SetCurrentNode(s.searchCurrent)
+	searchQueryPanel.SetText(s.searchQuery)
+	searchBaseInput.SetText(s.searchBaseDN)
+	refreshSearchTable()
+	updateSearchHistoryPanel()
+	updateSavedSearches()
+	loadBookmarks()
+
+	explorerAttrsPanel.Clear()
+	if s.explorerCurrent != nil {
+		reloadExplorerAttrsPanel(s.explorerCurrent, true)
+	}
+
+	searchAttrsPanel.Clear()
+	if s.searchCurrent != nil && s.searchCurrent.GetReference() != nil {
+		reloadSearchAttrsPanel(s.searchCurrent, true)
+	}
+
+	clearPageStates()
+
+	isSecure := Ldaps || AuthType == 5 || AuthType == 6
+	if lc != nil && lc.Conn != nil {
+		_, hasTLS := lc.TLSConnectionState()
+		isSecure = isSecure || hasTLS
+	}
+
+	updateStateBox(tlsPanel, isSecure)
+	if lc != nil && lc.Conn != nil {
+		updateConnStateBox(lc.State())
+	} else {
+		updateStateBox(statusPanel, false)
+	}
+}
+
+// The groups, DACL, GPO and ADIDNS pages show results of the
+// session they were queried on, so they're cleared on a switch
+func clearPageStates() {
+	groupNameInput.SetText("")
+	objectNameInput.SetText("")
+	membersPanel.Clear()
+	groupsPanel.Clear()
+	groups, members, membersSimple = nil, nil, nil
+	queryGroup, queryObject, groupDN, objectDN = "", "", "", ""
+
+	objectNameInputDacl.SetText("")
+	daclEntriesPanel.Clear()
+	acePanel.Clear()
+	daclOwnerTextView.SetText("")
+	controlFlagsTextView.SetText("")
+	aceMask.SetText("")
+	aceMaskBinary.SetText("")
+	sd, parsedAces = nil, nil
+	object, ownerPrincipal, groupPrincipal = "", "", ""
+
+	gpoTargetInput.SetText("")
+	gpoPath.SetText("")
+	gpoListPanel.Clear()
+	gpoLinksPanel.Clear()
+	gpoTarget = ""
+	gpLinks, containerLinks, gpEntry = nil, nil, nil
+
+	dnsTreePanel.SetRoot(nil)
+	clear(zoneCache)
+	clear(nodeCache)
+	domainZones, forestZones = nil, nil
+	dnsQueryPanel.SetText("")
+	dnsZoneProps.Clear()
+	dnsNodeRecords.SetRoot(nil)
+}
+
+func newSessionFromGlobals() *Session {
+	s := &Session{}
+	s.Config = currentConnConfig()
+	s.Conn = lc
+	s.TLSConfig = tlsConfig
+	return s
+}
+
+func initSessions() {
+	sessionsPanel = tview.NewTextView().
+		SetDynamicColors(true).
+		SetRegions(true).
+		SetWrap(false).
+		SetTextAlign(tview.AlignRight)
+
+	sessionsPanel.SetHighlightedFunc(func(added, removed, remaining []string) {
+		if len(added) > 0 {
+			idx, err := strconv.Atoi(added[0])
+			if err == nil && idx != currentSession {
+				switchSession(idx)
+			}
+		}
+	})
+
+	sessions = []*Session{newSessionFromGlobals()}
+	currentSession = 0
+
+	updateSessionsPanel()
+}
+
+func updateSessionsPanel() {
+	sessions[currentSession].Config = currentConnConfig()
+	sessionsPanel.Clear()
+
+	for idx, s := range sessions {
+		fmt.Fprintf(sessionsPanel, `["%d"][darkcyan]%d:%s[white][""] `, idx, idx+1, s.Name())
+	}
+
+	sessionsPanel.Highlight(strconv.Itoa(currentSession))
+}
+
+func switchSession(idx int) {
+	if idx < 0 || idx >= len(sessions) || idx == currentSession {
+		return
+	}
+
+	if isJobRunning() {
+		updateLog("Wait for the running query to finish before switching sessions", "yellow")
+		updateSessionsPanel()
+		return
+	}
+
+	previousFlavor := lc.Flavor
+
+	saveSession(sessions[currentSession])
+	currentSession = idx
+	loadSession(sessions[currentSession])
+
+	if lc.Flavor != previousFlavor {
+		setupPages()
+	}
+
+	updateSessionsPanel()
+	updateLog("Switched to session "+sessions[currentSession].Name(), "green")
+}
+
+func nextSession() {
+	if len(sessions) > 1 {
+		switchSession((currentSession + 1) % len(sessions))
+	}
+}
+
+func prevSession() {
+	if len(sessions) > 1 {
+		switchSession((currentSession - 1 + len(sessions)) % len(sessions))
+	}
+}
+
+// Connects using the current globals and, if successful,
+// registers the connection as a new session with empty pages
+func createSession(previousConfig ConnConfig) {
+	newConfig := currentConnConfig()
+	previousConfig.apply()
+
+	if isJobRunning() {
+		updateLog("Wait for the running query to finish before opening a new session", "yellow")
+		return
+	}
+
+	previous := sessions[currentSession]
+	previousFlavor := lc.Flavor
+	saveSession(previous)
+
+	// The connection of the previous session must stay open
+	newConfig.apply()
+	lc = nil
+	RootDN = ""
+
+	err := setupLDAPConn()
+	if err == nil {
+		err = setupRootDN()
+	}
+
+	if err != nil {
+		if lc != nil && lc.Conn != nil {
+			lc.Close()
+		}
+
+		updateLog(fmt.Sprintf("New session failed: %s", err), "red")
+		loadSession(previous)
+		return
+	}
+
+	rootNode = renderPartialTree(RootDN, SearchFilter)
+	treePanel.SetRoot(rootNode).SetCurrentNode(rootNode)
+	rootDNInput.SetText(RootDN)
+
+	searchTreePanel.SetRoot(nil)
+	searchQueryPanel.SetText("")
+	searchBaseInput.SetText("")
+	refreshSearchTable()
+	loadSearchHistory()
+	updateSearchHistoryPanel()
+	updateSavedSearches()
+	loadBookmarks()
+
+	explorerAttrsPanel.Clear()
+	reloadExplorerAttrsPanel(rootNode, true)
+	searchAttrsPanel.Clear()
+	clearPageStates()
+
+	sessions = append(sessions, newSessionFromGlobals())
+	currentSession = len(sessions) - 1
+
+	if lc.Flavor != previousFlavor {
+		setupPages()
+	}
+
+	updateSessionsPanel()
+	updateLog("Session "+sessions[currentSession].Name()+" opened", "green")
+}
+
+func openNewSessionForm() {
+	// The form overwrites the connection globals,
+	// so the settings of the active session are kept here
+	previousConfig := currentConnConfig()
+
+	openConnConfigForm("New Session", func() {
+		go app.QueueUpdateDraw(func() {
+			createSession(previousConfig)
+		})
+	})
+}
+
+func closeCurrentSession() {
+	if len(sessions) < 2 {
+		updateLog("The last session can't be closed", "yellow")
+		return
+	}
+
+	if isJobRunning() {
+		updateLog("Wait for the running query to finish before closing the session", "yellow")
+		return
+	}
+
+	closed := sessions[currentSession]
+	closedIdx := currentSession
+
+	if closed.Conn != nil && closed.Conn.Conn != nil {
+		closed.Conn.Close()
+	}
+
+	previousFlavor := lc.Flavor
+
+	sessions = append(sessions[:closedIdx], sessions[closedIdx+1:]...)
+	currentSession = closedIdx % len(sessions)
+
+	loadSession(sessions[currentSession])
+
+	if lc.Flavor != previousFlavor {
+		setupPages()
+	}
+
+	updateSessionsPanel()
+	updateLog("Session "+closed.Name()+" closed", "green")
+}
+
+// Copies an object with all of its attributes, reading it again
+// since the caches only keep the attributes that were requested
+func copyObjectToClipboard(baseDN string) {
+	entries, err := lc.Query(baseDN, "(objectClass=*)", ldap.ScopeBaseObject, Deleted)
+	if err != nil {
+		updateLog(fmt.Sprintf("Could not copy '%s': %s", baseDN, err), "red")
+		return
+	}
+
+	if len(entries) == 0 {
+		updateLog(fmt.Sprintf("Could not copy '%s': object not found", baseDN), "red")
+		return
+	}
+
+	sessionClipboard = &ClipboardObject{
+		Source: sessions[currentSession].Name(),
+		Entry:  entries[0],
+	}
+
+	updateLog("Object copied: "+baseDN, "green")
+}
+
+func openPasteObjectForm(node *tview.TreeNode, done func()) {
+	if sessionClipboard == nil {
+		updateLog("No object was copied yet (use 'y' to copy an object)", "yellow")
+		return
+	}
+
+	parentDN := node.GetReference().(string)
+	sourceEntry := sessionClipboard.Entry
+
+	currentFocus := app.GetFocus()
+
+	sourceRDN := ""
+	parsedDN, err := ldap.ParseDN(sourceEntry.DN)
+	if err == nil && len(parsedDN.RDNs) > 0 {
+		sourceRDN = parsedDN.RDNs[0].String()
+	}
+
+	pasteObjectForm := NewXForm()
+	pasteObjectForm.
+		AddTextView("Source Session", sessionClipboard.Source, 0, 1, false, true).
+		AddTextView("Source DN", sourceEntry.DN, 0, 1, false, true).
+		AddTextView("Parent DN", parentDN, 0, 1, false, true).
+		AddInputField("Object RDN", sourceRDN, 0, nil, nil).
+		AddButton("Go Back", func() {
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		}).
+		AddButton("Create", func() {
+			objectRDN := pasteObjectForm.GetFormItemByLabel("Object RDN").(*tview.InputField).GetText()
+
+			parsedRDN, err := ldap.ParseDN(objectRDN)
+			if err != nil || len(parsedRDN.RDNs) != 1 || len(parsedRDN.RDNs[0].Attributes) == 0 {
+				updateLog("Invalid RDN: '"+objectRDN+"'", "red")
+				return
+			}
+
+			attrs := ldaputils.CopyableAttributes(sourceEntry)
+
+			// The naming attribute must match the new RDN
+			rdnAttr := parsedRDN.RDNs[0].Attributes[0]
+			for attrName := range attrs {
+				if strings.EqualFold(attrName, rdnAttr.Type) {
+					delete(attrs, attrName)
+				}
+			}
+			attrs[rdnAttr.Type] = []string{rdnAttr.Value}
+
+			objectDN := objectRDN + "," + parentDN
+			err = lc.AddObject(objectDN, attrs)
+			if err != nil {
+				updateLog(fmt.Sprint(err), "red")
+			} else {
+				updateLog("Object pasted: "+objectDN, "green")
+				if done != nil {
+					done()
+				}
+			}
+
+			app.SetRoot(appPanel, true).SetFocus(currentFocus)
+		})
+
+	pasteObjectForm.SetInputCapture(handleEscape(currentFocus))
+	pasteObjectForm.SetTitle("Paste Object").SetBorder(true)
+	app.SetRoot(pasteObjectForm, true).SetFocus(pasteObjectForm)
+}
+
+// Pastes the DN of the copied object into the focused text field
+func pasteClipboardDN(field tview.Primitive) bool {
+	if sessionClipboard == nil {
+		return false
+	}
+
+	handler := field.PasteHandler()
+	if handler == nil {
+		return false
+	}
+
+	handler(sessionClipboard.Entry.DN, func(p tview.Primitive) {
+		app.SetFocus(p)
+	})
+
+	return true
+}
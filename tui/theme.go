@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Macmod/godap/v2/pkg/ldaputils"
 	"github.com/gdamore/tcell/v2"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/rivo/tview"
@@ -268,6 +269,14 @@ func GetAttrCellColor(cellName string, cellValue string) (string, bool) {
 		}
 	}
 
+	// Certificates are colored by their validity, whether the
+	// cell holds the raw value or a line of its summary
+	if ldaputils.IsCertificateAttribute(cellName) {
+		if strings.HasSuffix(cellValue, "(Expired)") || ldaputils.CertificateExpired([]byte(cellValue)) {
+			color = "red"
+		}
+	}
+
 	switch cellValue {
 	case "TRUE", "Enabled", "Normal", "PwdNotExpired":
 		color = "green"
@@ -2,7 +2,6 @@ package tui
 
 import (
 	"bytes"
-	"encoding/hex"
 	"fmt"
 	"regexp"
 	"sort"
@@ -56,7 +55,7 @@ func createTreeNodeFromEntry(entry *ldap.Entry) *tview.TreeNode {
 	_, ok := explorerCache.Get(entry.DN)
 
 	if !ok {
-		nodeName := getNodeName(entry)
+		nodeName := markedNodeName(entry, &explorerMarks)
 
 		node := tview.NewTreeNode(nodeName).
 			SetReference(entry.DN).
@@ -81,163 +80,229 @@ func createTreeNodeFromEntry(entry *ldap.Entry) *tview.TreeNode {
 
 // Unloads child nodes and their attributes from the cache
 func unloadChildren(parentNode *tview.TreeNode) {
+	clearChildren(parentNode)
+	delete(childPagers, parentNode)
+}
+
+// Removes the child nodes while keeping their paging state
+func clearChildren(parentNode *tview.TreeNode) {
 	var children []*tview.TreeNode
 	parentNode.Walk(func(node, parent *tview.TreeNode) bool {
-		if node.GetReference() != parentNode.GetReference() {
+		if node != parentNode {
 			children = append(children, node)
 		}
 		return true
 	})
 
 	for _, child := range children {
-		childDN := child.GetReference().(string)
-		explorerCache.Delete(childDN)
+		if childDN, ok := child.GetReference().(string); ok {
+			explorerCache.Delete(childDN)
+		}
+
+		delete(childPagers, child)
 		parentNode.RemoveChild(child)
 	}
 }
 
+// Queries the children of an object. In GC mode, the children
+// of the RootDSE are the domain partitions of the forest
+func queryChildEntries(baseDN string, filter string) ([]*ldap.Entry, error) {
+	if baseDN == "" && lc.GlobalCatalog {
+		return lc.QueryDomainPartitions(Deleted)
+	}
+
+	return lc.Query(baseDN, filter, ldap.ScopeSingleLevel, Deleted)
+}
+
+// State of the children of a node loaded ChildrenPageSize at a time,
+// either through VLV or from the full list of children when the
+// server doesn't support it
+type childPager struct {
+	useVLV    bool
+	contextID []byte
+
+	// 1-based position of the next child to load
+	// and number of children in the container
+	next  int
+	total int
+
+	entries  []*ldap.Entry
+	moreNode *tview.TreeNode
+}
+
+var childPagers = make(map[*tview.TreeNode]*childPager)
+
+// Number of children of a node, including the ones not loaded yet
+func countChildren(node *tview.TreeNode) int {
+	if pager, ok := childPagers[node]; ok {
+		return pager.total
+	}
+
+	return len(node.GetChildren())
+}
+
+// Number of children of a node loaded in the tree
+func countLoadedChildren(node *tview.TreeNode) int {
+	n := len(node.GetChildren())
+	if pager, ok := childPagers[node]; ok && pager.moreNode != nil {
+		n -= 1
+	}
+
+	return n
+}
+
+// Returns the node whose children are paged by a "load more" node
+func pagedParentNode(moreNode *tview.TreeNode) *tview.TreeNode {
+	for parent, pager := range childPagers {
+		if pager.moreNode == moreNode {
+			return parent
+		}
+	}
+
+	return nil
+}
+
 // Loads child nodes and their attributes directly from LDAP
 func loadChildren(node *tview.TreeNode) {
 	baseDN := node.GetReference().(string)
-	entries, err := lc.Query(baseDN, SearchFilter, ldap.ScopeSingleLevel, Deleted)
-	if err != nil {
+
+	pager := &childPager{next: 1}
+	if ChildrenPageSize > 0 && (baseDN != "" || !lc.GlobalCatalog) && lc.SupportsVLV() {
+		pager.useVLV = true
+	} else if err := pager.queryAll(baseDN); err != nil {
 		updateLog(fmt.Sprint(err), "red")
 		return
 	}
 
+	childPagers[node] = pager
+	loadChildrenPage(node, "")
+}
+
+// Reads all children at once to page them on the client
+func (pager *childPager) queryAll(baseDN string) error {
+	entries, err := queryChildEntries(baseDN, SearchFilter)
+	if err != nil {
+		return err
+	}
+
 	// Sort results to guarantee stable view
 	sort.Slice(entries, func(i int, j int) bool {
 		return getName(entries[i]) < getName(entries[j])
 	})
 
-	for _, entry := range entries {
-		childNode := createTreeNodeFromEntry(entry)
-
-		if childNode != nil {
-			node.AddChild(childNode)
-		}
-	}
+	pager.useVLV = false
+	pager.entries = entries
+	pager.total = len(entries)
+	return nil
 }
 
-func handleAttrsKeyCtrlE(currentNode *tview.TreeNode, attrsPanel *tview.Table, cache *EntryCache) {
-	currentFocus := app.GetFocus()
-	attrRow, attrCol := attrsPanel.GetSelection()
-
-	// Find the correct attribute name and value index
-	selectedIndex := 0
-	parentRow := attrRow
-	if attrCol == 1 && attrsPanel.GetCell(attrRow, 0).Text == "" {
-		// Count back to find parent attribute row
-		for parentRow >= 0 && attrsPanel.GetCell(parentRow, 0).Text == "" {
-			parentRow--
-		}
-		// Calculate index by counting rows from parent
-		selectedIndex = attrRow - parentRow
+// Loads the next page of children of a node or, if startsWith
+// is not empty, replaces them by the page of children
+// starting at the first one whose name is >= startsWith
+func loadChildrenPage(node *tview.TreeNode, startsWith string) {
+	pager, ok := childPagers[node]
+	if !ok {
+		return
 	}
 
-	attrNameRef := attrsPanel.GetCell(parentRow, 0).GetReference().(string)
-
-	baseDN := currentNode.GetReference().(string)
+	baseDN := node.GetReference().(string)
 
-	entry, _ := cache.Get(baseDN)
-	attrVals := entry.GetAttributeValues(attrNameRef)
-	if len(attrVals) == 0 || selectedIndex < 0 || selectedIndex >= len(attrVals) {
-		return
+	if startsWith != "" {
+		clearChildren(node)
+		pager.moreNode = nil
 	}
 
-	// Encode attribute values to hex
-	rawAttrVals := entry.GetRawAttributeValues(attrNameRef)
+	var window *ldaputils.ChildrenWindow
+	if pager.useVLV {
+		var err error
+		window, err = lc.QueryChildrenWindow(
+			baseDN, SearchFilter, pager.next, startsWith,
+			ChildrenPageSize, pager.contextID, Deleted,
+		)
 
-	var attrValsHex []string
-	for idx := range rawAttrVals {
-		hexEncoded := hex.EncodeToString(rawAttrVals[idx])
-		attrValsHex = append(attrValsHex, hexEncoded)
-	}
+		// Some servers advertise the controls but refuse
+		// them for some containers or filters
+		if err != nil && pager.next == 1 && startsWith == "" {
+			updateLog(fmt.Sprintf("VLV failed, paging children on the client (%s)", err), "yellow")
+			err = pager.queryAll(baseDN)
+		}
 
-	valIndices := []string{}
-	for idx := range attrVals {
-		valIndices = append(valIndices, strconv.Itoa(idx))
+		if err != nil {
+			updateLog(fmt.Sprint(err), "red")
+			return
+		}
 	}
-	valIndices = append(valIndices, "New")
 
-	useHexEncoding := false
+	var entries []*ldap.Entry
+	if pager.useVLV {
+		entries = window.Entries
+		pager.contextID = window.ContextID
+		pager.total = window.Total
+		pager.next = window.Offset + len(entries)
+	} else {
+		start := pager.next - 1
+		if startsWith != "" {
+			lowerPrefix := strings.ToLower(startsWith)
+			start = sort.Search(len(pager.entries), func(idx int) bool {
+				return strings.ToLower(getName(pager.entries[idx])) >= lowerPrefix
+			})
+		}
 
-	writeAttrValsForm := NewXForm()
-	writeAttrValsForm.
-		AddTextView("Base DN", baseDN, 0, 1, false, true).
-		AddTextView("Attribute Name", attrNameRef, 0, 1, false, true).
-		AddTextView("Current Value", attrVals[selectedIndex], 0, 1, false, true).
-		AddTextView("Current Value (HEX)", attrValsHex[selectedIndex], 0, 1, false, true).
-		AddDropDown("Value Index", valIndices, selectedIndex, func(option string, optionIndex int) {
-			selectedIndex = optionIndex
+		end := len(pager.entries)
+		if ChildrenPageSize > 0 {
+			end = min(start+ChildrenPageSize, end)
+		}
 
-			currentValItem := writeAttrValsForm.GetFormItemByLabel("Current Value").(*tview.TextView)
-			currentValItemHex := writeAttrValsForm.GetFormItemByLabel("Current Value (HEX)").(*tview.TextView)
+		entries = pager.entries[start:end]
+		pager.next = end + 1
+	}
 
-			if selectedIndex < len(attrVals) {
-				currentValItem.SetText(attrVals[selectedIndex])
-				currentValItemHex.SetText(attrValsHex[selectedIndex])
-			} else {
-				currentValItem.SetText("")
-				currentValItemHex.SetText("")
-			}
-		}).
-		AddInputField("New Value", "", 0, nil, nil).
-		AddCheckbox("Use HEX encoding", false, func(checked bool) {
-			useHexEncoding = checked
-		}).
-		AddButton("Go Back", func() {
-			app.SetRoot(appPanel, false).SetFocus(currentFocus)
-		}).
-		AddButton("Update", func() {
-			newValue := writeAttrValsForm.GetFormItemByLabel("New Value").(*tview.InputField).GetText()
-			if useHexEncoding {
-				newValueBytes, err := hex.DecodeString(newValue)
-				if err == nil {
-					newValue = string(newValueBytes)
-				} else {
-					updateLog(fmt.Sprint(err), "red")
-					return
-				}
-			}
+	if pager.moreNode != nil {
+		node.RemoveChild(pager.moreNode)
+		pager.moreNode = nil
+	}
 
-			if selectedIndex < len(attrVals) {
-				attrVals[selectedIndex] = newValue
-			} else {
-				attrVals = append(attrVals, newValue)
-			}
+	for _, entry := range entries {
+		childNode := createTreeNodeFromEntry(entry)
 
-			err := lc.ModifyAttribute(baseDN, attrNameRef, attrVals)
-			if err != nil {
-				updateLog(fmt.Sprint(err), "red")
-			} else {
-				updateLog("Attribute updated: '"+attrNameRef+"' from '"+baseDN+"'", "green")
-			}
+		if childNode != nil {
+			node.AddChild(childNode)
+		}
+	}
 
-			reloadAttributesPanel(currentNode, attrsPanel, false, cache)
+	remaining := pager.total - pager.next + 1
+	if remaining > 0 {
+		pager.moreNode = tview.NewTreeNode(
+			fmt.Sprintf("[%d more of %d - Enter to load, / to jump]", remaining, pager.total),
+		).SetColor(tcell.ColorGray).SetSelectable(true)
 
-			/*
-				if parentNode != nil {
-					idx := findEntryInChildren(baseDN, parentNode)
+		node.AddChild(pager.moreNode)
+	}
+}
 
-					parent := reloadParentNode(currentNode)
-					siblings := parent.GetChildren()
+func handleAttrsKeyCtrlE(currentNode *tview.TreeNode, attrsPanel *tview.Table, cache *EntryCache) {
+	attrRow, _ := attrsPanel.GetSelection()
 
-					tree.SetCurrentNode(siblings[idx])
-				} else {
-					// Update UI in this edge case
-				}
-			*/
+	attrNameRef, ok := attrsPanel.GetCell(attrRow, 0).GetReference().(string)
+	if !ok {
+		return
+	}
 
-			app.SetRoot(appPanel, false).SetFocus(currentFocus)
-		})
+	baseDN, ok := currentNode.GetReference().(string)
+	if !ok {
+		return
+	}
 
-	//assignFormTheme(writeAttrValsForm)
+	entry, ok := cache.Get(baseDN)
+	if !ok {
+		return
+	}
 
-	writeAttrValsForm.SetInputCapture(handleEscape(treePanel))
-	writeAttrValsForm.SetTitle("Attribute Editor").SetBorder(true)
-	app.SetRoot(writeAttrValsForm, true).SetFocus(writeAttrValsForm)
+	openAttributeEditor(baseDN, entry, attrNameRef, func() {
+		reloadAttributesPanel(currentNode, attrsPanel, false, cache)
+	})
 }
+
 func handleAttrsKeyDelete(currentNode *tview.TreeNode, attrsPanel *tview.Table, cache *EntryCache) {
 	currentFocus := app.GetFocus()
 	baseDN := currentNode.GetReference().(string)
@@ -383,6 +448,18 @@ func handleAttrsKeyEnter(currentNode *tview.TreeNode, attrsPanel *tview.Table, c
 			attrsPanel.SetCell(currentRow, 1, myCell)
 			currentRow++
 		}
+		return
+	}
+
+	// Values that reference other objects lead to them in the explorer
+	attrName, ok := attrsPanel.GetCell(selectedRow, 0).GetReference().(string)
+	if !ok {
+		return
+	}
+
+	entry, ok := cache.Get(currentNode.GetReference().(string))
+	if ok && isLinkAttribute(entry, attrName) {
+		handleAttrsKeyEnterReference(attrsPanel, entry, attrName)
 	}
 }
 
@@ -439,6 +516,10 @@ func attrsPanelKeyHandler(event *tcell.EventKey, currentNode *tview.TreeNode, ca
 		handleAttrsKeyCtrlE(currentNode, attrsPanel, cache)
 	case tcell.KeyCtrlN:
 		handleAttrsKeyCtrlN(currentNode, attrsPanel, cache)
+	case tcell.KeyCtrlK:
+		handleAttrsKeyCtrlK(currentNode, attrsPanel, cache)
+	case tcell.KeyCtrlS:
+		handleAttrsKeyCtrlS(currentNode, attrsPanel, cache)
 	case tcell.KeyDown:
 		handleAttrsKeyDown(attrsPanel)
 	case tcell.KeyUp:
@@ -452,6 +533,20 @@ func attrsPanelKeyHandler(event *tcell.EventKey, currentNode *tview.TreeNode, ca
 	return event
 }
 
+// Entries read from the Global Catalog only carry the attributes
+// in the partial attribute set, so their panels are marked
+func gcAttrsMarker(title string) string {
+	if lc == nil || !lc.GlobalCatalog {
+		return title
+	}
+
+	if title == "" {
+		return "GC: partial attribute set"
+	}
+
+	return title + " (GC: partial attribute set)"
+}
+
 func reloadAttributesPanel(node *tview.TreeNode, attrsTable *tview.Table, useCache bool, cache *EntryCache) error {
 	ref := node.GetReference()
 	if ref == nil {
@@ -501,7 +596,7 @@ func reloadAttributesPanel(node *tview.TreeNode, attrsTable *tview.Table, useCac
 		attrsTable.SetCell(row, 0, tview.NewTableCell(cellName).SetReference(cellName))
 
 		if FormatAttrs {
-			cellValues = ldaputils.FormatLDAPAttribute(attribute, TimeFormat, TimeOffset)
+			cellValues = lc.FormatLDAPAttribute(attribute, TimeFormat, TimeOffset)
 		} else {
 			cellValues = attribute.Values
 		}
@@ -675,7 +770,7 @@ func updateEmojis() {
 				entry, ok := explorerCache.Get(ref.(string))
 
 				if ok {
-					node.SetText(getNodeName(entry))
+					node.SetText(markedNodeName(entry, &explorerMarks))
 				}
 			}
 
@@ -691,7 +786,7 @@ func updateEmojis() {
 				entry, ok := searchCache.Get(ref.(string))
 
 				if ok {
-					node.SetText(getNodeName(entry))
+					node.SetText(markedNodeName(entry, &searchMarks))
 				}
 			}
 
@@ -716,47 +811,37 @@ func renderPartialTree(rootDN string, searchFilter string) *tview.TreeNode {
 
 	rootNodeName := getNodeName(rootEntry[0])
 	if rootDN == "" {
-		rootNodeName += "RootDSE"
+		if lc.GlobalCatalog {
+			rootNodeName += "Global Catalog"
+		} else {
+			rootNodeName += "RootDSE"
+		}
 	}
 
 	rootNode = tview.NewTreeNode(rootNodeName).
 		SetReference(rootDN).
 		SetSelectable(true)
 
-	if rootDN == "" {
+	if rootDN == "" && !lc.GlobalCatalog {
 		return rootNode
 	}
 
-	var rootEntries []*ldap.Entry
-	rootEntries, err = lc.Query(rootDN, searchFilter, ldap.ScopeSingleLevel, Deleted)
-	if err != nil {
-		updateLog(fmt.Sprint(err), "red")
-		return nil
-	}
-
-	// Sort results to guarantee stable view
-	sort.Slice(rootEntries, func(i int, j int) bool {
-		return getName(rootEntries[i]) < getName(rootEntries[j])
-	})
-
-	for _, entry := range rootEntries {
-		node := createTreeNodeFromEntry(entry)
-		if node != nil {
-			rootNode.AddChild(node)
-		}
-	}
+	loadChildren(rootNode)
 
 	return rootNode
 }
 
 func reloadExplorerPage() {
 	explorerAttrsPanel.Clear()
+	if oldRoot := treePanel.GetRoot(); oldRoot != nil {
+		unloadChildren(oldRoot)
+	}
 	explorerCache.Clear()
+	explorerMarks.Clear()
 
 	rootNode = renderPartialTree(lc.RootDN, SearchFilter)
 	if rootNode != nil {
-		numChildren := len(rootNode.GetChildren())
-		updateLog("Tree updated successfully ("+strconv.Itoa(numChildren)+" objects found)", "green")
+		updateLog("Tree updated successfully ("+strconv.Itoa(countChildren(rootNode))+" objects found)", "green")
 	}
 
 	treePanel.SetRoot(rootNode).SetCurrentNode(rootNode)